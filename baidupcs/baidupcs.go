@@ -84,6 +84,8 @@ const (
 	OperationShareSURLInfo = "获取分享详细信息"
 	// OperationShareFileSavetoLocal 分享链接转存到网盘
 	OperationShareFileSavetoLocal = "分享链接转存到网盘"
+	// OperationShareFileList 列出分享链接中的文件
+	OperationShareFileList = "列出分享链接中的文件"
 	// OperationRapidLinkSavetoLocal 秒传链接转存到网盘
 	OperationRapidLinkSavetoLocal = "秒传链接转存到网盘"
 	// OperationRecycleList 列出回收站文件列表
@@ -118,6 +120,8 @@ const (
 	DotBaiduCom = ".baidu.com"
 	// PathSeparator 路径分隔符
 	PathSeparator = "/"
+	// MaxBatchSize 批量操作和分页获取列表时, 服务器每次接受的最大数量
+	MaxBatchSize = 100
 )
 
 var (
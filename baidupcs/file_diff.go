@@ -0,0 +1,129 @@
+package baidupcs
+
+import (
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"errors"
+	"sort"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type (
+	// FileDiff cursor 之后网盘文件的变更
+	FileDiff struct {
+		Added    FileDirectoryList // 新增的文件/目录
+		Modified FileDirectoryList // 修改的文件/目录
+		Deleted  FileDirectoryList // 删除的文件/目录
+		Cursor   string            // 下次获取变更使用的 cursor
+		HasMore  bool              // 是否还有未返回的变更, 为 true 时应使用 Cursor 继续获取
+		Reset    bool              // 为 true 时, 之前的 cursor 已失效, 返回的是全部文件
+	}
+
+	fileDiffEntryJSON struct {
+		FsID     int64  `json:"fs_id"`
+		Path     string `json:"path"`
+		Filename string `json:"server_filename"`
+		Size     int64  `json:"size"`
+		Isdir    int8   `json:"isdir"`
+		Isdelete int8   `json:"isdelete"`
+		MD5      string `json:"md5"`
+		Ctime    int64  `json:"server_ctime"`
+		Mtime    int64  `json:"server_mtime"`
+	}
+
+	fileDiffJSON struct {
+		*pcserror.PanErrorInfo
+		Entries jsoniter.RawMessage `json:"entries"`
+		HasMore bool                `json:"has_more"`
+		Reset   bool                `json:"reset"`
+		Cursor  string              `json:"cursor"`
+	}
+)
+
+// parseFileDiffEntries 解析变更的条目, 服务器返回以 fs_id 为键的对象或数组
+func parseFileDiffEntries(raw jsoniter.RawMessage) (entries []*fileDiffEntryJSON, err error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	if raw[0] == '[' {
+		err = jsoniter.Unmarshal(raw, &entries)
+		return
+	}
+
+	m := map[string]*fileDiffEntryJSON{}
+	err = jsoniter.Unmarshal(raw, &m)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range m {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// FilesDirectoriesDiff 获取 cursor 之后网盘文件的变更, cursor 为空时返回全部文件.
+// 服务器不区分新增和修改, 创建时间与修改时间相同的视为新增
+func (pcs *BaiduPCS) FilesDirectoriesDiff(cursor string) (diff *FileDiff, pcsError pcserror.Error) {
+	dataReadCloser, pcsError := pcs.PrepareFilesDirectoriesDiff(cursor)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+
+	defer dataReadCloser.Close()
+
+	errInfo := pcserror.NewPanErrorInfo(OperationGetCursorDiff)
+	jsonData := fileDiffJSON{
+		PanErrorInfo: errInfo,
+	}
+
+	pcsError = pcserror.HandleJSONParse(OperationGetCursorDiff, dataReadCloser, &jsonData)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+
+	entries, err := parseFileDiffEntries(jsonData.Entries)
+	if err != nil {
+		errInfo.SetJSONError(err)
+		return nil, errInfo
+	}
+	if jsonData.Cursor == "" {
+		errInfo.ErrType = pcserror.ErrTypeOthers
+		errInfo.Err = errors.New("Unknown remote data")
+		return nil, errInfo
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	diff = &FileDiff{
+		Cursor:  jsonData.Cursor,
+		HasMore: jsonData.HasMore,
+		Reset:   jsonData.Reset,
+	}
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		fd := &FileDirectory{
+			FsID:     entry.FsID,
+			Path:     entry.Path,
+			Filename: entry.Filename,
+			Ctime:    entry.Ctime,
+			Mtime:    entry.Mtime,
+			MD5:      DecryptMD5(entry.MD5),
+			Size:     entry.Size,
+			Isdir:    entry.Isdir != 0,
+		}
+		switch {
+		case entry.Isdelete != 0:
+			diff.Deleted = append(diff.Deleted, fd)
+		case entry.Ctime == entry.Mtime:
+			diff.Added = append(diff.Added, fd)
+		default:
+			diff.Modified = append(diff.Modified, fd)
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,46 @@
+package baidupcs
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestParseFileDiffEntries(t *testing.T) {
+	for _, raw := range []string{
+		// 以 fs_id 为键的对象
+		`{"2":{"fs_id":2,"path":"/b","isdir":1,"isdelete":0},"1":{"fs_id":1,"path":"/a.txt","size":10,"isdelete":1}}`,
+		// 数组
+		`[{"fs_id":2,"path":"/b","isdir":1,"isdelete":0},{"fs_id":1,"path":"/a.txt","size":10,"isdelete":1}]`,
+	} {
+		entries, err := parseFileDiffEntries([]byte(raw))
+		if err != nil {
+			t.Fatalf("%s: %s", raw, err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("%s: got %d entries, want 2", raw, len(entries))
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].FsID < entries[j].FsID
+		})
+		a, b := entries[0], entries[1]
+		if a.FsID != 1 || a.Path != "/a.txt" || a.Size != 10 || a.Isdir != 0 || a.Isdelete != 1 {
+			t.Fatalf("%s: unexpected entry %+v", raw, a)
+		}
+		if b.FsID != 2 || b.Path != "/b" || b.Isdir != 1 || b.Isdelete != 0 {
+			t.Fatalf("%s: unexpected entry %+v", raw, b)
+		}
+	}
+
+	for _, raw := range []string{"", "null", "{}", "[]"} {
+		entries, err := parseFileDiffEntries([]byte(raw))
+		if err != nil || len(entries) != 0 {
+			t.Fatalf("%q: got %v, %v, want no entries", raw, entries, err)
+		}
+	}
+
+	for _, raw := range []string{`{"1":[]}`, `[1]`, `"x"`} {
+		if _, err := parseFileDiffEntries([]byte(raw)); err == nil {
+			t.Fatalf("%q: expected error", raw)
+		}
+	}
+}
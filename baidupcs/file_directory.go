@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/olekukonko/tablewriter"
@@ -39,6 +40,11 @@ type (
 	// HandleFileDirectoryFunc 处理文件或目录的元信息, 返回值控制是否退出递归
 	HandleFileDirectoryFunc func(depth int, fdPath string, fd *FileDirectory, pcsError pcserror.Error) bool
 
+	// DirectoryLister 获取目录下的文件和目录列表
+	DirectoryLister interface {
+		FilesDirectoriesList(path string, options *OrderOptions) (FileDirectoryList, pcserror.Error)
+	}
+
 	// FileDirectory 文件或目录的元信息
 	FileDirectory struct {
 		FsID     int64  // fs_id
@@ -257,6 +263,71 @@ func (pcs *BaiduPCS) FilesDirectoriesRecurseList(path string, options *OrderOpti
 	return data
 }
 
+// FilesDirectoriesConcurrentRecurseList 并发递归获取目录下的文件和目录列表, 最多同时发起 parallel 个请求.
+// 返回的目录信息中, Children 为子目录信息, 获取子目录列表的错误交给 handleError 处理, 返回 false 时停止获取
+func (pcs *BaiduPCS) FilesDirectoriesConcurrentRecurseList(path string, options *OrderOptions, parallel int, handleError func(fdPath string, pcsError pcserror.Error) bool) (root *FileDirectory, pcsError pcserror.Error) {
+	root, pcsError = pcs.FilesDirectoriesMeta(path)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	ConcurrentRecurseList(pcs, root, options, parallel, handleError)
+	return root, nil
+}
+
+// ConcurrentRecurseList 使用 lister 并发递归获取目录 root 下的文件和目录列表, 保存在 Children 中,
+// 同一目录下的文件保持 lister 返回的顺序. handleError 返回 false 时不再发起新的请求
+func ConcurrentRecurseList(lister DirectoryLister, root *FileDirectory, options *OrderOptions, parallel int, handleError func(fdPath string, pcsError pcserror.Error) bool) {
+	if !root.Isdir {
+		return
+	}
+
+	if parallel < 1 {
+		parallel = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		canceled bool
+		sem      = make(chan struct{}, parallel)
+		list     func(fd *FileDirectory)
+	)
+	list = func(fd *FileDirectory) {
+		defer wg.Done()
+		sem <- struct{}{}
+		defer func() { <-sem }()
+
+		mu.Lock()
+		c := canceled
+		mu.Unlock()
+		if c {
+			return
+		}
+
+		fdl, pcsError := lister.FilesDirectoriesList(fd.Path, options)
+		if pcsError != nil {
+			mu.Lock()
+			if handleError != nil && !canceled && !handleError(fd.Path, pcsError) {
+				canceled = true
+			}
+			mu.Unlock()
+			return
+		}
+
+		fd.Children = fdl
+		for _, sub := range fdl {
+			sub.Parent = fd
+			if sub.Isdir {
+				wg.Add(1)
+				go list(sub)
+			}
+		}
+	}
+
+	wg.Add(1)
+	list(root)
+	wg.Wait()
+}
+
 // fixMD5 尝试修复MD5字段
 // 服务器返回的MD5字段不一定正确了, 即是BlockList只有一个md5
 // MD5字段使用BlockList中的md5
@@ -0,0 +1,160 @@
+package baidupcs_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"math/rand"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLister 模拟网盘目录列表, 随机延时返回
+type fakeLister struct {
+	mu     sync.Mutex
+	dirs   map[string][]string // 目录下的文件名, 以 / 结尾的为目录
+	errs   map[string]pcserror.Error
+	listed []string
+}
+
+func (fl *fakeLister) FilesDirectoriesList(p string, options *baidupcs.OrderOptions) (baidupcs.FileDirectoryList, pcserror.Error) {
+	fl.mu.Lock()
+	fl.listed = append(fl.listed, p)
+	fl.mu.Unlock()
+	time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+
+	if err, ok := fl.errs[p]; ok {
+		return nil, err
+	}
+	names, ok := fl.dirs[p]
+	if !ok {
+		return nil, pcserror.NewPCSErrorInfo(baidupcs.OperationFilesDirectoriesList)
+	}
+	fdl := make(baidupcs.FileDirectoryList, 0, len(names))
+	for _, name := range names {
+		isdir := name[len(name)-1] == '/'
+		if isdir {
+			name = name[:len(name)-1]
+		}
+		fdl = append(fdl, &baidupcs.FileDirectory{
+			Path:     path.Join(p, name),
+			Filename: name,
+			Isdir:    isdir,
+		})
+	}
+	return fdl, nil
+}
+
+func newFakeLister() *fakeLister {
+	return &fakeLister{
+		dirs: map[string][]string{
+			"/":       {"a/", "b/", "c/", "1.txt"},
+			"/a":      {"z.txt", "y.txt", "x/"},
+			"/a/x":    {"3.txt", "2.txt", "1.txt"},
+			"/b":      {"b2/", "b1/"},
+			"/b/b1":   {"1.txt"},
+			"/b/b2":   {},
+			"/c":      {"c1/"},
+			"/c/c1":   {"d/"},
+			"/c/c1/d": {"1.txt"},
+		},
+		errs: map[string]pcserror.Error{},
+	}
+}
+
+// walk 按深度优先列出所有路径
+func walk(fd *baidupcs.FileDirectory) (paths []string) {
+	paths = append(paths, fd.Path)
+	for _, sub := range fd.Children {
+		if sub.Parent != fd {
+			paths = append(paths, "bad parent: "+sub.Path)
+		}
+		paths = append(paths, walk(sub)...)
+	}
+	return
+}
+
+func TestConcurrentRecurseListOrder(t *testing.T) {
+	want := []string{
+		"/", "/a", "/a/z.txt", "/a/y.txt", "/a/x", "/a/x/3.txt", "/a/x/2.txt", "/a/x/1.txt",
+		"/b", "/b/b2", "/b/b1", "/b/b1/1.txt", "/c", "/c/c1", "/c/c1/d", "/c/c1/d/1.txt", "/1.txt",
+	}
+	for _, parallel := range []int{0, 1, 3, 10} {
+		fl := newFakeLister()
+		root := &baidupcs.FileDirectory{Path: "/", Isdir: true}
+		baidupcs.ConcurrentRecurseList(fl, root, nil, parallel, func(fdPath string, pcsError pcserror.Error) bool {
+			t.Errorf("unexpected error: %s, %s", fdPath, pcsError)
+			return false
+		})
+
+		got := walk(root)
+		if len(got) != len(want) {
+			t.Fatalf("parallel %d: got %v, want %v", parallel, got, want)
+		}
+		for k := range want {
+			if got[k] != want[k] {
+				t.Fatalf("parallel %d: got %v, want %v", parallel, got, want)
+			}
+		}
+		if len(fl.listed) != len(fl.dirs) {
+			t.Fatalf("parallel %d: listed %v", parallel, fl.listed)
+		}
+	}
+}
+
+func TestConcurrentRecurseListError(t *testing.T) {
+	fl := newFakeLister()
+	fl.errs["/a"] = pcserror.NewPCSErrorInfo(baidupcs.OperationFilesDirectoriesList)
+	fl.errs["/c/c1"] = pcserror.NewPCSErrorInfo(baidupcs.OperationFilesDirectoriesList)
+
+	var (
+		mu     sync.Mutex
+		failed = map[string]bool{}
+		root   = &baidupcs.FileDirectory{Path: "/", Isdir: true}
+	)
+	baidupcs.ConcurrentRecurseList(fl, root, nil, 3, func(fdPath string, pcsError pcserror.Error) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if pcsError != fl.errs[fdPath] {
+			t.Errorf("%s: unexpected error %v", fdPath, pcsError)
+		}
+		failed[fdPath] = true
+		return true
+	})
+
+	if len(failed) != 2 || !failed["/a"] || !failed["/c/c1"] {
+		t.Fatalf("failed dirs: %v", failed)
+	}
+	// 出错的目录没有子目录信息, 其他目录继续获取
+	want := []string{"/", "/a", "/b", "/b/b2", "/b/b1", "/b/b1/1.txt", "/c", "/c/c1", "/1.txt"}
+	got := walk(root)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k := range want {
+		if got[k] != want[k] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConcurrentRecurseListCancel(t *testing.T) {
+	fl := newFakeLister()
+	fl.errs["/a"] = pcserror.NewPCSErrorInfo(baidupcs.OperationFilesDirectoriesList)
+
+	calls := 0
+	root := &baidupcs.FileDirectory{Path: "/", Isdir: true}
+	// parallel 为 1 时请求依次发起, 取消之后不应再有请求
+	baidupcs.ConcurrentRecurseList(fl, root, nil, 1, func(fdPath string, pcsError pcserror.Error) bool {
+		calls++
+		return false
+	})
+
+	if calls != 1 {
+		t.Fatalf("handleError called %d times, want 1", calls)
+	}
+	if last := fl.listed[len(fl.listed)-1]; last != "/a" {
+		t.Fatalf("listed %v after cancel", fl.listed)
+	}
+}
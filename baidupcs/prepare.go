@@ -810,7 +810,7 @@ func (pcs *BaiduPCS) PrepareRecycleList(page int) (dataReadCloser io.ReadCloser,
 	pcs.lazyInit()
 
 	panURL := pcs.generatePanURL("recycle/list", map[string]string{
-		"num":  "100",
+		"num":  strconv.Itoa(MaxBatchSize),
 		"page": strconv.Itoa(page),
 	})
 
@@ -35,6 +35,9 @@ type (
 		ExpireType      int     `json:"expiredType"`     // 过期类型
 		ExpireTime      int64   `json:"expiredTime"`     // 过期时间
 		ViewCount       int     `json:"vCnt"`            // 浏览次数
+		SaveCount       int     `json:"tCnt"`            // 转存次数
+		DownloadCount   int     `json:"dCnt"`            // 下载次数
+		Ctime           int64   `json:"ctime"`           // 创建时间
 		Valid           string  // 是否过期
 	}
 
@@ -0,0 +1,111 @@
+package baidupcs
+
+import (
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/requester"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+type (
+	// ShareFileListOption 列出分享链接中的文件的参数, ShareID, ShareUK, BDStoken 从分享页面获取
+	ShareFileListOption struct {
+		FeatureStr string // 分享链接的特征码, 即 /s/ 后面的部分
+		ShareID    string
+		ShareUK    string
+		BDStoken   string
+		Dir        string // 分享者网盘中的目录路径, 为空时列出分享的根目录
+		Page       int
+		Num        int
+	}
+)
+
+var (
+	// ErrShareFileListInvalidJSON 分享文件列表数据格式错误
+	ErrShareFileListInvalidJSON = errors.New("分享文件列表数据格式错误")
+	// ErrShareFeatureStrInvalid 分享链接的特征码无效
+	ErrShareFeatureStrInvalid = errors.New("分享链接的特征码无效")
+	// ErrShareFileListNoShareID 列出分享中的子目录需要 ShareID 和 ShareUK
+	ErrShareFileListNoShareID = errors.New("列出分享中的子目录需要 shareid 和 uk")
+)
+
+// ShareFileList 列出分享链接中的文件, 私密分享需要先通过提取码验证.
+// 返回的路径为分享者网盘中的路径
+func (pcs *BaiduPCS) ShareFileList(opt *ShareFileListOption) (fdl FileDirectoryList, pcsError pcserror.Error) {
+	errInfo := pcserror.NewPanErrorInfo(OperationShareFileList)
+	// 特征码的第一个字符为分享类型, 其余部分为 shorturl
+	if len(opt.FeatureStr) < 2 {
+		errInfo.ErrType = pcserror.ErrTypeOthers
+		errInfo.Err = ErrShareFeatureStrInvalid
+		return nil, errInfo
+	}
+	if opt.Dir != "" && (opt.ShareID == "" || opt.ShareUK == "") {
+		errInfo.ErrType = pcserror.ErrTypeOthers
+		errInfo.Err = ErrShareFileListNoShareID
+		return nil, errInfo
+	}
+
+	params := map[string]string{
+		"bdstoken": opt.BDStoken,
+		"web":      "5",
+		"app_id":   PanAppID,
+		"channel":  "chunlei",
+		"page":     strconv.Itoa(opt.Page),
+		"num":      strconv.Itoa(opt.Num),
+	}
+	if opt.Dir == "" {
+		params["root"] = "1"
+		params["shorturl"] = opt.FeatureStr[1:]
+	} else {
+		params["dir"] = opt.Dir
+		params["shareid"] = opt.ShareID
+		params["uk"] = opt.ShareUK
+	}
+
+	listURL := pcs.GenerateShareQueryURL("list", params)
+	baiduPCSVerbose.Infof("%s URL: %s\n", OperationShareFileList, listURL)
+
+	dataReadCloser, pcsError := pcs.sendReqReturnReadCloser(reqTypePan, OperationShareFileList, http.MethodGet, listURL.String(), nil, map[string]string{
+		"User-Agent": requester.UserAgent,
+		"Referer":    "https://pan.baidu.com/s/" + opt.FeatureStr,
+	})
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	defer dataReadCloser.Close()
+
+	body, err := ioutil.ReadAll(dataReadCloser)
+	if err != nil {
+		errInfo.SetNetError(err)
+		return nil, errInfo
+	}
+	if !gjson.ValidBytes(body) {
+		errInfo.SetJSONError(ErrShareFileListInvalidJSON)
+		return nil, errInfo
+	}
+
+	result := gjson.ParseBytes(body)
+	if errno := result.Get("errno").Int(); errno != 0 {
+		errInfo.SetRemoteError()
+		errInfo.ErrNo = int(errno)
+		return nil, errInfo
+	}
+
+	for _, item := range result.Get("list").Array() {
+		fdl = append(fdl, &FileDirectory{
+			FsID:     item.Get("fs_id").Int(),
+			Path:     item.Get("path").String(),
+			Filename: item.Get("server_filename").String(),
+			Ctime:    item.Get("server_ctime").Int(),
+			Mtime:    item.Get("server_mtime").Int(),
+			MD5:      DecryptMD5(item.Get("md5").String()),
+			Size:     item.Get("size").Int(),
+			Isdir:    item.Get("isdir").Int() == 1,
+		})
+	}
+	return fdl, nil
+}
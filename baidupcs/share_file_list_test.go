@@ -0,0 +1,25 @@
+package baidupcs_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"testing"
+)
+
+func TestShareFileListInvalidOption(t *testing.T) {
+	// 参数无效时不发起请求
+	pcs := &baidupcs.BaiduPCS{}
+	for _, opt := range []*baidupcs.ShareFileListOption{
+		{FeatureStr: ""},
+		{FeatureStr: "1"},
+	} {
+		_, pcsError := pcs.ShareFileList(opt)
+		if pcsError == nil || pcsError.GetError() != baidupcs.ErrShareFeatureStrInvalid {
+			t.Fatalf("%q: got %v, want %v", opt.FeatureStr, pcsError, baidupcs.ErrShareFeatureStrInvalid)
+		}
+	}
+
+	_, pcsError := pcs.ShareFileList(&baidupcs.ShareFileListOption{FeatureStr: "1abc", Dir: "/a"})
+	if pcsError == nil || pcsError.GetError() != baidupcs.ErrShareFileListNoShareID {
+		t.Fatalf("got %v, want %v", pcsError, baidupcs.ErrShareFileListNoShareID)
+	}
+}
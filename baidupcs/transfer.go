@@ -16,12 +16,26 @@ import (
 	"github.com/tidwall/gjson"
 )
 
+const (
+	// TransferOnDupSkip 跳过目标目录中已存在的同名文件
+	TransferOnDupSkip = "skip"
+	// TransferOnDupRename 同名文件自动重命名
+	TransferOnDupRename = "rename"
+	// TransferOnDupOverwrite 覆盖同名文件
+	TransferOnDupOverwrite = "overwrite"
+)
+
 type (
 	// ShareOption 分享可选项
 	TransferOption struct {
-		Download bool // 是否直接开始下载
-		Collect  bool // 多文件整合
-		Rname    bool // 随机改文件名
+		Download    bool     // 是否直接开始下载
+		Collect     bool     // 多文件整合
+		Rname       bool     // 随机改文件名
+		SaveTo      string   // 转存到的网盘目录, 为空时转存到当前工作目录
+		Paths       []string // 只转存分享中的这些路径, 相对于分享根目录
+		Patterns    []string // 只转存分享中匹配这些通配符的路径, 相对于分享根目录
+		Interactive bool     // 交互式选择要转存的文件
+		OnDup       string   // 同名文件的处理方式, 为空时转存失败
 	}
 )
 
@@ -206,6 +220,9 @@ func (pcs *BaiduPCS) GenerateRequestQuery(mode string, params map[string]string)
 				res["ErrNo"] = "4"
 				res["ErrMsg"] = fmt.Sprintf("转存文件数%d超过当前用户上限, 当前用户单次最大转存数%d", targetFileNums, targetFileNumsLimit)
 				res["limit"] = fmt.Sprintf("%d", targetFileNumsLimit)
+			} else if _errno == -10 {
+				res["ErrNo"] = "10"
+				res["ErrMsg"] = "网盘容量不足"
 			} else if _errno == -30 {
 				res["ErrNo"] = "9"
 				res["ErrMsg"] = fmt.Sprintf("当前目录下已有%s同名文件/文件夹", file)
@@ -214,6 +231,9 @@ func (pcs *BaiduPCS) GenerateRequestQuery(mode string, params map[string]string)
 			}
 		} else if mode == "POST" && errno == 4 {
 			res["ErrMsg"] = fmt.Sprintf("文件重复")
+		} else if mode == "POST" && errno == -10 {
+			res["ErrNo"] = "10"
+			res["ErrMsg"] = "网盘容量不足"
 		}
 		return
 	}
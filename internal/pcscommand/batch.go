@@ -0,0 +1,31 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"fmt"
+	"io"
+)
+
+// forEachBatch 将 n 个元素按 baidupcs.MaxBatchSize 分批, 依次处理 [start, end) 之间的元素
+func forEachBatch(n int, handle func(start, end int)) {
+	for start := 0; start < n; start += baidupcs.MaxBatchSize {
+		end := start + baidupcs.MaxBatchSize
+		if end > n {
+			end = n
+		}
+		handle(start, end)
+	}
+}
+
+// removeInBatches 分批删除网盘文件/目录, 删除的文件/目录可在网盘文件回收站找回.
+// 错误信息输出到 w, 返回删除失败的路径
+func removeInBatches(pcs *baidupcs.BaiduPCS, paths []string, w io.Writer) (failed []string) {
+	forEachBatch(len(paths), func(start, end int) {
+		pcsError := pcs.Remove(paths[start:end]...)
+		if pcsError != nil {
+			fmt.Fprintf(w, "删除失败: %s\n", pcsError)
+			failed = append(failed, paths[start:end]...)
+		}
+	})
+	return
+}
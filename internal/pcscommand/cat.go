@@ -0,0 +1,295 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcscat"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/requester"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHeadTailLines head 和 tail 默认输出的行数
+	DefaultHeadTailLines = 10
+	// DefaultTailFollowInterval tail -f 默认的轮询间隔
+	DefaultTailFollowInterval = 5 * time.Second
+
+	// tailChunkSize tail 按行输出时, 每次从文件末尾向前读取的大小
+	tailChunkSize = 64 * 1024
+)
+
+var (
+	// ErrFollowGunzip 不支持同时使用 -f 和 -z
+	ErrFollowGunzip = errors.New("-f 不支持解压 gzip 文件")
+)
+
+type (
+	// CatOptions cat 可选参数
+	CatOptions struct {
+		Gunzip bool // 解压 gzip 文件
+	}
+
+	// HeadTailOptions head 和 tail 可选参数, Bytes 大于 0 时按字节输出, 否则按行输出
+	HeadTailOptions struct {
+		Lines    int
+		Bytes    int64
+		Gunzip   bool          // 解压 gzip 文件, 行数和字节数按解压后的数据计算
+		Follow   bool          // 仅 tail, 持续输出文件新增的内容
+		Interval time.Duration // 仅 tail, 持续输出时检查文件大小的间隔
+	}
+
+	// remoteFile 通过下载链接读取的网盘文件
+	remoteFile struct {
+		pcs    *baidupcs.BaiduPCS
+		fd     *baidupcs.FileDirectory
+		dlink  string
+		client *requester.HTTPClient
+	}
+
+	readCloser struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+// openRemoteFile 获取网盘文件的信息和下载链接
+func openRemoteFile(pcspath string) (*remoteFile, error) {
+	err := matchPathByShellPatternOnce(&pcspath)
+	if err != nil {
+		return nil, err
+	}
+
+	rf := &remoteFile{pcs: GetBaiduPCS()}
+	err = rf.refresh(pcspath)
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// refresh 重新获取文件的信息和下载链接
+func (rf *remoteFile) refresh(pcspath string) error {
+	fd, pcsError := rf.pcs.FilesDirectoriesMeta(pcspath)
+	if pcsError != nil {
+		return pcsError
+	}
+	if fd.Isdir {
+		return fmt.Errorf("%s 是一个目录", pcspath)
+	}
+
+	dlink, err := pcsdownload.GetDownloadLink(rf.pcs, fd)
+	if err != nil {
+		return fmt.Errorf("获取下载链接失败: %s", err)
+	}
+	pcsCommandVerbose.Infof("获取到下载链接: %s\n", dlink)
+
+	rf.fd, rf.dlink = fd, dlink
+	rf.client = pcsdownload.NewPanDownloadClient(rf.pcs, dlink)
+	return nil
+}
+
+// rangeReader 读取 [begin, end] 范围的数据, end 小于 0 时读取到文件末尾
+func (rf *remoteFile) rangeReader(begin, end int64) (io.ReadCloser, error) {
+	if begin < 0 {
+		begin = 0
+	}
+	if end < 0 || end >= rf.fd.Size {
+		end = rf.fd.Size - 1
+	}
+	if begin > end {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+
+	body, err := pcsdownload.RangeRequest(rf.client, rf.dlink, begin, end)
+	if err != nil {
+		return nil, err
+	}
+	// 服务器不支持 Range 时返回整个文件
+	return readCloser{io.LimitReader(body, end-begin+1), body}, nil
+}
+
+// copyRange 将 [begin, end] 范围的数据输出到 w, gunzip 为 true 时解压
+func (rf *remoteFile) copyRange(w io.Writer, begin, end int64, gunzip bool) error {
+	body, err := rf.rangeReader(begin, end)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var r io.Reader = body
+	if gunzip {
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return fmt.Errorf("解压 gzip 失败: %s", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	_, err = io.Copy(w, r)
+	if err == pcscat.ErrLimitReached {
+		return nil
+	}
+	return err
+}
+
+// RunCat 执行输出网盘文件的内容
+func RunCat(paths []string, opt *CatOptions) {
+	if opt == nil {
+		opt = &CatOptions{}
+	}
+
+	for _, p := range paths {
+		rf, err := openRemoteFile(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cat: %s: %s\n", p, err)
+			continue
+		}
+		err = rf.copyRange(os.Stdout, 0, -1, opt.Gunzip)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cat: %s: %s\n", rf.fd.Path, err)
+		}
+	}
+}
+
+// RunHead 执行输出网盘文件开头的内容
+func RunHead(pcspath string, opt *HeadTailOptions) {
+	if opt == nil {
+		opt = &HeadTailOptions{Lines: DefaultHeadTailLines}
+	}
+
+	rf, err := openRemoteFile(pcspath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "head: %s: %s\n", pcspath, err)
+		return
+	}
+
+	end := int64(-1)
+	if opt.Bytes > 0 && !opt.Gunzip {
+		end = opt.Bytes - 1
+	}
+	err = rf.copyRange(&pcscat.LimitWriter{W: os.Stdout, Lines: opt.Lines, Bytes: opt.Bytes}, 0, end, opt.Gunzip)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "head: %s: %s\n", rf.fd.Path, err)
+	}
+}
+
+// RunTail 执行输出网盘文件末尾的内容
+func RunTail(pcspath string, opt *HeadTailOptions) {
+	if opt == nil {
+		opt = &HeadTailOptions{Lines: DefaultHeadTailLines}
+	}
+	if opt.Follow && opt.Gunzip {
+		fmt.Fprintf(os.Stderr, "tail: %s\n", ErrFollowGunzip)
+		return
+	}
+
+	rf, err := openRemoteFile(pcspath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tail: %s: %s\n", pcspath, err)
+		return
+	}
+
+	switch {
+	case opt.Gunzip:
+		// 压缩的数据无法从末尾读取, 只能读取整个文件
+		tw := &pcscat.TailWriter{Lines: opt.Lines, Bytes: opt.Bytes}
+		err = rf.copyRange(tw, 0, -1, true)
+		if err == nil {
+			_, err = os.Stdout.Write(tw.Tail())
+		}
+	case opt.Bytes > 0:
+		err = rf.copyRange(os.Stdout, rf.fd.Size-opt.Bytes, -1, false)
+	default:
+		err = rf.tailLines(opt.Lines)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tail: %s: %s\n", rf.fd.Path, err)
+		return
+	}
+
+	if opt.Follow {
+		rf.follow(opt.Interval)
+	}
+}
+
+// tailLines 从文件末尾向前分段读取, 直到包含 n 行
+func (rf *remoteFile) tailLines(n int) error {
+	var (
+		data []byte
+		end  = rf.fd.Size
+	)
+	for end > 0 {
+		begin := end - tailChunkSize
+		if begin < 0 {
+			begin = 0
+		}
+		body, err := rf.rangeReader(begin, end-1)
+		if err != nil {
+			return err
+		}
+		chunk, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+
+		data = append(chunk, data...)
+		if offset, ok := pcscat.LastLinesOffset(data, n); ok {
+			data = data[offset:]
+			break
+		}
+		end = begin
+	}
+
+	_, err := os.Stdout.Write(data)
+	return err
+}
+
+// follow 定时检查文件大小, 输出新增的内容, 文件变小时从头输出
+func (rf *remoteFile) follow(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultTailFollowInterval
+	}
+
+	var (
+		pcspath = rf.fd.Path
+		offset  = rf.fd.Size
+	)
+	for {
+		time.Sleep(interval)
+
+		fd, pcsError := rf.pcs.FilesDirectoriesMeta(pcspath)
+		if pcsError != nil {
+			fmt.Fprintf(os.Stderr, "tail: %s: %s\n", pcspath, pcsError)
+			continue
+		}
+		if fd.Size == offset {
+			continue
+		}
+		if fd.Size < offset {
+			fmt.Fprintf(os.Stderr, "tail: %s: 文件被截断\n", pcspath)
+			offset = 0
+		}
+
+		// 文件内容变化后需要新的下载链接
+		err := rf.refresh(pcspath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tail: %s: %s\n", pcspath, err)
+			continue
+		}
+		err = rf.copyRange(os.Stdout, offset, -1, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tail: %s: %s\n", pcspath, err)
+			continue
+		}
+		offset = rf.fd.Size
+	}
+}
@@ -0,0 +1,228 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultChangesInterval 持续获取文件变更的默认间隔
+	DefaultChangesInterval = 30 * time.Second
+)
+
+const (
+	changeAdded    = "added"
+	changeModified = "modified"
+	changeDeleted  = "deleted"
+	changeReset    = "reset"
+)
+
+type (
+	// ChangesOptions changes 可选参数
+	ChangesOptions struct {
+		NDJSON   bool          // 以 NDJSON 格式输出, 每行一个变更
+		Follow   bool          // 持续获取文件变更
+		Interval time.Duration // 持续获取的间隔
+		Init     bool          // 只记录当前的 cursor, 不输出变更
+		Reset    bool          // 清除已记录的 cursor
+		Path     string        // 只输出该目录下的变更
+	}
+
+	changeEvent struct {
+		Type  string `json:"type"`
+		Path  string `json:"path,omitempty"`
+		FsID  int64  `json:"fs_id,omitempty"`
+		Isdir bool   `json:"isdir,omitempty"`
+		Size  int64  `json:"size,omitempty"`
+		MD5   string `json:"md5,omitempty"`
+		Mtime int64  `json:"mtime,omitempty"`
+	}
+)
+
+// changeTypeName 变更类型的中文名称
+func changeTypeName(typ string) string {
+	switch typ {
+	case changeAdded:
+		return "新增"
+	case changeModified:
+		return "修改"
+	case changeDeleted:
+		return "删除"
+	}
+	return typ
+}
+
+// diffEvents 将文件变更转换为事件, 过滤不在 prefix 下的变更
+func diffEvents(diff *baidupcs.FileDiff, prefix string) (events []*changeEvent) {
+	if diff.Reset {
+		events = append(events, &changeEvent{Type: changeReset})
+	}
+	for _, list := range []struct {
+		typ string
+		fdl baidupcs.FileDirectoryList
+	}{
+		{changeAdded, diff.Added},
+		{changeModified, diff.Modified},
+		{changeDeleted, diff.Deleted},
+	} {
+		for _, fd := range list.fdl {
+			if prefix != baidupcs.PathSeparator && fd.Path != prefix && !strings.HasPrefix(fd.Path, prefix+baidupcs.PathSeparator) {
+				continue
+			}
+			events = append(events, &changeEvent{
+				Type:  list.typ,
+				Path:  fd.Path,
+				FsID:  fd.FsID,
+				Isdir: fd.Isdir,
+				Size:  fd.Size,
+				MD5:   fd.MD5,
+				Mtime: fd.Mtime,
+			})
+		}
+	}
+	return
+}
+
+// RunChanges 执行获取上次调用以来网盘中的文件变更, cursor 按帐号保存在配置中
+func RunChanges(opt *ChangesOptions) {
+	if opt == nil {
+		opt = &ChangesOptions{}
+	}
+	if opt.Interval <= 0 {
+		opt.Interval = DefaultChangesInterval
+	}
+
+	// NDJSON 输出时, 提示信息输出到标准错误
+	var msg io.Writer = os.Stdout
+	if opt.NDJSON {
+		msg = os.Stderr
+	}
+
+	au := GetActiveUser()
+	if opt.Reset {
+		au.DiffCursor = ""
+		if err := pcsconfig.Config.Save(); err != nil {
+			fmt.Fprintf(msg, "保存配置错误: %s\n", err)
+			return
+		}
+		fmt.Fprintln(msg, "已清除文件变更的 cursor")
+		if !opt.Init && !opt.Follow {
+			return
+		}
+	}
+
+	prefix := baidupcs.PathSeparator
+	if opt.Path != "" {
+		prefix = path.Clean(au.PathJoin(opt.Path))
+	}
+
+	if au.DiffCursor == "" && !opt.Init {
+		fmt.Fprintln(msg, "首次获取, 将列出网盘中的全部文件, 可使用 --init 只记录当前状态")
+	}
+
+	var (
+		pcs        = GetBaiduPCS()
+		encoder    = json.NewEncoder(os.Stdout)
+		saveCursor = func(cursor string) bool {
+			if cursor == au.DiffCursor {
+				return true
+			}
+			au.DiffCursor = cursor
+			if err := pcsconfig.Config.Save(); err != nil {
+				fmt.Fprintf(msg, "保存配置错误: %s\n", err)
+				return false
+			}
+			return true
+		}
+	)
+	for {
+		var (
+			events []*changeEvent
+			failed bool
+			cursor = au.DiffCursor
+		)
+		for {
+			diff, pcsError := pcs.FilesDirectoriesDiff(cursor)
+			if pcsError != nil {
+				fmt.Fprintln(msg, pcsError)
+				failed = true
+				break
+			}
+
+			if !opt.Init {
+				pageEvents := diffEvents(diff, prefix)
+				if opt.NDJSON {
+					for _, e := range pageEvents {
+						encoder.Encode(e)
+					}
+				} else {
+					events = append(events, pageEvents...)
+				}
+			}
+
+			// 输出后再保存 cursor, 中断时不会丢失变更.
+			// 以表格输出时, 变更在全部获取后才输出, cursor 也在输出后保存
+			cursor = diff.Cursor
+			if (opt.NDJSON || opt.Init) && !saveCursor(cursor) {
+				return
+			}
+			if !diff.HasMore {
+				break
+			}
+		}
+
+		switch {
+		case opt.Init && !failed:
+			fmt.Fprintln(msg, "已记录当前状态, 下次将只获取之后的变更")
+			opt.Init = false
+		case !opt.NDJSON && len(events) > 0:
+			tb := pcstable.NewTable(os.Stdout)
+			tb.SetHeader([]string{"变更", "类型", "文件大小", "修改日期", "路径"})
+			var added, modified, deleted int
+			for _, e := range events {
+				switch e.Type {
+				case changeReset:
+					fmt.Println("cursor 已失效, 以下为网盘中的全部文件")
+					continue
+				case changeAdded:
+					added++
+				case changeModified:
+					modified++
+				case changeDeleted:
+					deleted++
+				}
+				fdType, size, mtime := "文件", converter.ConvertFileSize(e.Size, 2), "-"
+				if e.Isdir {
+					fdType, size = "目录", "-"
+				}
+				if e.Mtime > 0 {
+					mtime = pcstime.FormatTime(e.Mtime)
+				}
+				tb.Append([]string{changeTypeName(e.Type), fdType, size, mtime, e.Path})
+			}
+			tb.Render()
+			fmt.Printf("新增: %d, 修改: %d, 删除: %d\n", added, modified, deleted)
+		case opt.Init, opt.NDJSON:
+		case !opt.Follow && !failed:
+			fmt.Println("没有文件变更")
+		}
+		if !saveCursor(cursor) {
+			return
+		}
+
+		if !opt.Follow {
+			return
+		}
+		time.Sleep(opt.Interval)
+	}
+}
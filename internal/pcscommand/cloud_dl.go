@@ -2,38 +2,46 @@ package pcscommand
 
 import (
 	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/pcsoutput"
 	"fmt"
 )
 
 // RunCloudDlAddTask 执行添加离线下载任务
 func RunCloudDlAddTask(sourceURLs []string, savePath string) {
-	var (
-		err error
-		pcs = GetBaiduPCS()
-	)
-	err = matchPathByShellPatternOnce(&savePath)
+	err := matchPathByShellPatternOnce(&savePath)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+	addCloudDlTasks(GetBaiduPCS(), sourceURLs, savePath)
+}
 
-	var taskid int64
+// addCloudDlTasks 添加离线下载任务到网盘目录 savePath, 返回添加成功的任务ID, 和添加失败的任务数
+func addCloudDlTasks(pcs *baidupcs.BaiduPCS, sourceURLs []string, savePath string) (taskIDs []int64, failed int) {
 	for k := range sourceURLs {
-		taskid, err = pcs.CloudDlAddTask(sourceURLs[k], savePath+baidupcs.PathSeparator)
+		taskid, err := pcs.CloudDlAddTask(sourceURLs[k], savePath+baidupcs.PathSeparator)
 		if err != nil {
 			fmt.Printf("[%d] %s, 地址: %s\n", k+1, err, sourceURLs[k])
+			failed++
 			continue
 		}
 
 		fmt.Printf("[%d] 添加离线任务成功, 任务ID(task_id): %d, 源地址: %s, 保存路径: %s\n", k+1, taskid, sourceURLs[k], savePath)
+		taskIDs = append(taskIDs, taskid)
 	}
+	return taskIDs, failed
 }
 
 // RunCloudDlQueryTask 精确查询离线下载任务
 func RunCloudDlQueryTask(taskIDs []int64) {
 	cl, err := GetBaiduPCS().CloudDlQueryTask(taskIDs)
 	if err != nil {
-		fmt.Printf("%s\n", err)
+		printCommandError("offlinedl query", err)
+		return
+	}
+
+	if pcsoutput.IsStructured() {
+		printRecords("offlinedl query", newCloudDlRecords(cl))
 		return
 	}
 
@@ -44,7 +52,12 @@ func RunCloudDlQueryTask(taskIDs []int64) {
 func RunCloudDlListTask() {
 	cl, err := GetBaiduPCS().CloudDlListTask()
 	if err != nil {
-		fmt.Printf("%s\n", err)
+		printCommandError("offlinedl list", err)
+		return
+	}
+
+	if pcsoutput.IsStructured() {
+		printRecords("offlinedl list", newCloudDlRecords(cl))
 		return
 	}
 
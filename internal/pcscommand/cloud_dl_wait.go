@@ -0,0 +1,299 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/pcsutil/converter"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// CloudDlExitOK 离线下载成功
+	CloudDlExitOK = 0
+	// CloudDlExitError 添加或查询任务失败. 任务失败时退出码与任务状态码相同:
+	// 2系统错误, 3资源不存在, 4下载超时, 5资源存在但下载失败 (如版权限制), 6存储空间不足, 7任务取消
+	CloudDlExitError = 1
+	// CloudDlExitTimeout 等待超时
+	CloudDlExitTimeout = 8
+	// CloudDlExitDownloadFailed 下载到本地失败
+	CloudDlExitDownloadFailed = 9
+
+	// cloudDlStatusSuccess 任务状态: 下载成功
+	cloudDlStatusSuccess = 0
+	// cloudDlStatusRunning 任务状态: 下载进行中
+	cloudDlStatusRunning = 1
+
+	// cloudDlMinPollInterval 查询任务状态的初始间隔
+	cloudDlMinPollInterval = 2 * time.Second
+	// cloudDlMaxPollInterval 查询任务状态的最大间隔
+	cloudDlMaxPollInterval = 30 * time.Second
+	// cloudDlMaxQueryErrors 连续查询失败的最大次数, 超过时放弃等待
+	cloudDlMaxQueryErrors = 5
+)
+
+type (
+	// CloudDlWaitOptions 等待离线下载完成的可选参数
+	CloudDlWaitOptions struct {
+		Timeout    time.Duration // 最长等待时间, 0 为不限制
+		DownloadTo string        // 完成后下载到的本地目录, 为空时不下载
+		Keep       bool          // 下载到本地后保留网盘中的文件
+	}
+
+	// cloudDlWatchTask 等待中的离线下载任务
+	cloudDlWatchTask struct {
+		info       *baidupcs.CloudDlTaskInfo
+		lastSize   int64
+		lastTime   time.Time
+		lastStatus int
+	}
+)
+
+// cloudDlExitCode 任务状态对应的退出码
+func cloudDlExitCode(status int) int {
+	switch {
+	case status == cloudDlStatusSuccess:
+		return CloudDlExitOK
+	case status >= 2 && status <= 7:
+		return status
+	}
+	return CloudDlExitError
+}
+
+// cloudDlResultPath 任务完成后文件在网盘中的路径
+func cloudDlResultPath(info *baidupcs.CloudDlTaskInfo, saveDir string) string {
+	target := path.Clean(info.SavePath)
+	if target == path.Clean(saveDir) && info.TaskName != "" {
+		target = path.Join(target, info.TaskName)
+	}
+	return target
+}
+
+// cloudDlCheckLocal 检查下载到本地目录 localDir 的文件大小与网盘中 target 下的文件 fdl 是否一致
+func cloudDlCheckLocal(fdl baidupcs.FileDirectoryList, target, localDir string) error {
+	for _, fd := range fdl {
+		if fd.Isdir {
+			continue
+		}
+		rel := path.Base(target)
+		if fd.Path != target {
+			rel = path.Join(rel, strings.TrimPrefix(fd.Path, target+baidupcs.PathSeparator))
+		}
+		localPath := filepath.Join(localDir, filepath.FromSlash(rel))
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return err
+		}
+		if info.Size() != fd.Size {
+			return fmt.Errorf("本地文件大小与网盘不一致: %s, 本地 %d, 网盘 %d", localPath, info.Size(), fd.Size)
+		}
+	}
+	return nil
+}
+
+// cloudDlVerifyDownload 获取网盘中 target 下的全部文件, 检查下载到本地的文件大小是否一致
+func cloudDlVerifyDownload(pcs *baidupcs.BaiduPCS, target, localDir string) (err error) {
+	var fdl baidupcs.FileDirectoryList
+	pcs.FilesDirectoriesRecurseList(target, baidupcs.DefaultOrderOptions, func(depth int, _ string, fd *baidupcs.FileDirectory, pcsError pcserror.Error) bool {
+		if pcsError != nil {
+			err = pcsError
+			return false
+		}
+		fdl = append(fdl, fd)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return cloudDlCheckLocal(fdl, target, localDir)
+}
+
+// progress 输出任务进度, 速度根据两次查询之间完成的大小计算
+func (wt *cloudDlWatchTask) progress(now time.Time) string {
+	info := wt.info
+	var rate int64
+	if !wt.lastTime.IsZero() && info.FinishedSize > wt.lastSize {
+		if elapsed := now.Sub(wt.lastTime).Seconds(); elapsed > 0 {
+			rate = int64(float64(info.FinishedSize-wt.lastSize) / elapsed)
+		}
+	}
+	wt.lastSize, wt.lastTime = info.FinishedSize, now
+
+	percent := ""
+	if info.FileSize > 0 {
+		percent = fmt.Sprintf(" %.2f%%", float64(info.FinishedSize)*100/float64(info.FileSize))
+	}
+	return fmt.Sprintf("[%d] %s ↓ %s/%s%s %s/s %s", info.TaskID, info.TaskName,
+		converter.ConvertFileSize(info.FinishedSize, 2), converter.ConvertFileSize(info.FileSize, 2), percent,
+		converter.ConvertFileSize(rate, 2), info.StatusText)
+}
+
+// RunCloudDlAddTaskWait 执行添加离线下载任务, 并等待任务完成, 可下载到本地后删除网盘中的文件.
+// 返回退出码, 多个任务失败时为第一个失败的任务的退出码
+func RunCloudDlAddTaskWait(sourceURLs []string, savePath string, opt *CloudDlWaitOptions) (exitCode int) {
+	if opt == nil {
+		opt = &CloudDlWaitOptions{}
+	}
+
+	pcs := GetBaiduPCS()
+	err := matchPathByShellPatternOnce(&savePath)
+	if err != nil {
+		fmt.Println(err)
+		return CloudDlExitError
+	}
+	taskIDs, failed := addCloudDlTasks(pcs, sourceURLs, savePath)
+	if failed > 0 {
+		exitCode = CloudDlExitError
+	}
+	if len(taskIDs) == 0 {
+		return CloudDlExitError
+	}
+
+	var (
+		tasks       = make(map[int64]*cloudDlWatchTask, len(taskIDs))
+		pending     = taskIDs
+		finished    []*baidupcs.CloudDlTaskInfo
+		interval    = cloudDlMinPollInterval
+		deadline    time.Time
+		queryErrors int // 连续查询失败的次数
+	)
+	if opt.Timeout > 0 {
+		deadline = time.Now().Add(opt.Timeout)
+	}
+	for _, id := range taskIDs {
+		tasks[id] = &cloudDlWatchTask{lastStatus: -1}
+	}
+
+	for len(pending) > 0 {
+		cl, pcsError := pcs.CloudDlQueryTask(pending)
+		if pcsError != nil {
+			fmt.Printf("%s失败: %s\n", baidupcs.OperationCloudDlQueryTask, pcsError)
+			queryErrors++
+			if queryErrors >= cloudDlMaxQueryErrors {
+				fmt.Printf("连续 %d 次查询失败, 放弃等待, 仍有 %d 个任务未完成, 可使用 offlinedl query 查询\n", queryErrors, len(pending))
+				if exitCode == CloudDlExitOK {
+					exitCode = CloudDlExitError
+				}
+				break
+			}
+		} else {
+			queryErrors = 0
+		}
+
+		var (
+			now      = time.Now()
+			changed  = false
+			running  []int64
+			returned = make(map[int64]bool, len(cl))
+		)
+		for _, info := range cl {
+			wt := tasks[info.TaskID]
+			if wt == nil {
+				continue
+			}
+			returned[info.TaskID] = true
+			if info.Result == 1 {
+				fmt.Printf("[%d] 任务不存在\n", info.TaskID)
+				if exitCode == CloudDlExitOK {
+					exitCode = CloudDlExitError
+				}
+				continue
+			}
+
+			if info.FinishedSize != wt.lastSize || info.Status != wt.lastStatus {
+				changed = true
+			}
+			wt.info, wt.lastStatus = info, info.Status
+			fmt.Println(wt.progress(now))
+
+			switch info.Status {
+			case cloudDlStatusRunning:
+				running = append(running, info.TaskID)
+			case cloudDlStatusSuccess:
+				finished = append(finished, info)
+			default:
+				fmt.Printf("[%d] 离线下载失败: %s, 源地址: %s\n", info.TaskID, info.StatusText, info.SourceURL)
+				if exitCode == CloudDlExitOK {
+					exitCode = cloudDlExitCode(info.Status)
+				}
+			}
+		}
+		// 查询失败或没有返回的任务继续等待
+		for _, id := range pending {
+			if !returned[id] {
+				running = append(running, id)
+			}
+		}
+		pending = running
+		if len(pending) == 0 {
+			break
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			fmt.Printf("等待超时, 仍有 %d 个任务未完成, 可使用 offlinedl query 查询\n", len(pending))
+			if exitCode == CloudDlExitOK {
+				exitCode = CloudDlExitTimeout
+			}
+			break
+		}
+
+		// 有进度时使用最短间隔, 否则逐渐增加间隔
+		if changed {
+			interval = cloudDlMinPollInterval
+		} else if interval *= 2; interval > cloudDlMaxPollInterval {
+			interval = cloudDlMaxPollInterval
+		}
+		time.Sleep(interval)
+	}
+
+	for _, info := range finished {
+		target := cloudDlResultPath(info, savePath)
+		fmt.Printf("[%d] 离线下载成功, 保存路径: %s\n", info.TaskID, target)
+		if opt.DownloadTo == "" {
+			continue
+		}
+		// 任务名称为空时无法确定下载的文件, 不下载整个保存目录
+		if target == path.Clean(savePath) {
+			fmt.Printf("[%d] 无法确定离线下载的文件名, 请手动下载: %s\n", info.TaskID, target)
+			if exitCode == CloudDlExitOK {
+				exitCode = CloudDlExitDownloadFailed
+			}
+			continue
+		}
+
+		failedFiles, err := downloadPaths([]string{target}, &DownloadOptions{
+			SaveTo: opt.DownloadTo,
+		})
+		if err != nil || failedFiles > 0 {
+			fmt.Printf("[%d] 下载到本地失败, 保留网盘中的文件: %s\n", info.TaskID, target)
+			if exitCode == CloudDlExitOK {
+				exitCode = CloudDlExitDownloadFailed
+			}
+			continue
+		}
+		if opt.Keep {
+			continue
+		}
+
+		// 确认本地文件完整后再删除网盘中的文件
+		err = cloudDlVerifyDownload(pcs, target, opt.DownloadTo)
+		if err != nil {
+			fmt.Printf("[%d] 检查本地文件失败, 保留网盘中的文件: %s, %s\n", info.TaskID, target, err)
+			if exitCode == CloudDlExitOK {
+				exitCode = CloudDlExitDownloadFailed
+			}
+			continue
+		}
+		pcsError := pcs.Remove(target)
+		if pcsError != nil {
+			fmt.Printf("[%d] 删除网盘中的文件失败: %s, %s\n", info.TaskID, target, pcsError)
+			continue
+		}
+		fmt.Printf("[%d] 已删除网盘中的文件: %s\n", info.TaskID, target)
+	}
+	return exitCode
+}
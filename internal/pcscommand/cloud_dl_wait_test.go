@@ -0,0 +1,113 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloudDlExitCode(t *testing.T) {
+	cases := map[int]int{
+		0:  CloudDlExitOK,
+		1:  CloudDlExitError, // 进行中不是失败状态
+		2:  2,
+		5:  5,
+		7:  7,
+		8:  CloudDlExitError,
+		-1: CloudDlExitError,
+	}
+	for status, want := range cases {
+		if got := cloudDlExitCode(status); got != want {
+			t.Fatalf("cloudDlExitCode(%d) = %d, want %d", status, got, want)
+		}
+	}
+}
+
+func TestCloudDlResultPath(t *testing.T) {
+	cases := []struct {
+		savePath, taskName, saveDir string
+		want                        string
+	}{
+		{"/dl", "a.iso", "/dl", "/dl/a.iso"},
+		{"/dl/", "a.iso", "/dl", "/dl/a.iso"},
+		{"/dl/a.iso", "a.iso", "/dl", "/dl/a.iso"}, // 返回的保存路径已包含文件名
+		{"/dl", "", "/dl", "/dl"},                  // 任务名称为空
+		{"/", "a.iso", "/", "/a.iso"},
+	}
+	for _, c := range cases {
+		info := &baidupcs.CloudDlTaskInfo{SavePath: c.savePath, TaskName: c.taskName}
+		if got := cloudDlResultPath(info, c.saveDir); got != c.want {
+			t.Fatalf("cloudDlResultPath(%q, %q, %q) = %s, want %s", c.savePath, c.taskName, c.saveDir, got, c.want)
+		}
+	}
+}
+
+func TestCloudDlProgress(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	wt := &cloudDlWatchTask{info: &baidupcs.CloudDlTaskInfo{
+		TaskID:       1,
+		TaskName:     "a.iso",
+		FileSize:     4096,
+		FinishedSize: 1024,
+		StatusText:   "下载进行中",
+	}}
+	// 第一次查询没有速度
+	s := wt.progress(now)
+	if !strings.Contains(s, "[1] a.iso") || !strings.Contains(s, "25.00%") || !strings.Contains(s, " 0B/s") {
+		t.Fatalf("progress = %s", s)
+	}
+
+	wt.info.FinishedSize = 3072
+	s = wt.progress(now.Add(2 * time.Second))
+	if !strings.Contains(s, "75.00%") || !strings.Contains(s, "1.00KB/s") {
+		t.Fatalf("progress = %s", s)
+	}
+	if wt.lastSize != 3072 || !wt.lastTime.Equal(now.Add(2*time.Second)) {
+		t.Fatalf("lastSize %d, lastTime %s", wt.lastSize, wt.lastTime)
+	}
+
+	// 文件大小未知时不输出百分比
+	wt.info.FileSize = 0
+	if s = wt.progress(now.Add(3 * time.Second)); strings.Contains(s, "%") {
+		t.Fatalf("progress = %s", s)
+	}
+}
+
+func TestCloudDlCheckLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clouddl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "d", "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "d", "1.txt"), []byte("1"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "d", "sub", "2.txt"), []byte("22"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "a.iso"), []byte("abc"), 0644)
+
+	fdl := baidupcs.FileDirectoryList{
+		{Path: "/dl/d", Isdir: true},
+		{Path: "/dl/d/1.txt", Size: 1},
+		{Path: "/dl/d/sub", Isdir: true},
+		{Path: "/dl/d/sub/2.txt", Size: 2},
+	}
+	if err = cloudDlCheckLocal(fdl, "/dl/d", dir); err != nil {
+		t.Fatalf("directory: %s", err)
+	}
+	if err = cloudDlCheckLocal(baidupcs.FileDirectoryList{{Path: "/dl/a.iso", Size: 3}}, "/dl/a.iso", dir); err != nil {
+		t.Fatalf("file: %s", err)
+	}
+
+	// 大小不一致或本地文件不存在
+	fdl[3].Size = 3
+	if err = cloudDlCheckLocal(fdl, "/dl/d", dir); err == nil {
+		t.Fatalf("expected size mismatch")
+	}
+	if err = cloudDlCheckLocal(baidupcs.FileDirectoryList{{Path: "/dl/b.iso", Size: 3}}, "/dl/b.iso", dir); err == nil {
+		t.Fatalf("expected missing file")
+	}
+}
@@ -0,0 +1,320 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/requester/uploader"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type (
+	// CrossUserCopyOptions 跨帐号拷贝可选参数
+	CrossUserCopyOptions struct {
+		FromUID  uint64 // 源帐号, 为 0 时使用当前帐号
+		ToUID    uint64 // 目标帐号, 为 0 时使用当前帐号
+		NoShare  bool   // 不尝试临时分享转存
+		Parallel int    // 流式传输的上传并发量
+		Policy   string // 同名文件处理策略
+	}
+
+	// crossUserCopy 跨帐号拷贝
+	crossUserCopy struct {
+		from, to       *pcsconfig.Baidu
+		srcPCS, dstPCS *baidupcs.BaiduPCS
+		parallel       int
+		policy         string
+	}
+)
+
+// getBaiduUserByUID 在已登录帐号中查找 uid, uid 为 0 时返回当前帐号
+func getBaiduUserByUID(uid uint64) (*pcsconfig.Baidu, error) {
+	if uid == 0 {
+		return GetActiveUser(), nil
+	}
+	user, err := pcsconfig.Config.GetBaiduUser(&pcsconfig.BaiduBase{UID: uid})
+	if err != nil {
+		return nil, fmt.Errorf("未找到已登录的帐号 uid: %d, %s", uid, err)
+	}
+	return user, nil
+}
+
+// RunCopyCrossUser 执行在两个已登录帐号之间拷贝文件/目录, 最后一个路径为目标帐号中的目录.
+// 依次尝试临时分享转存, 秒传, 最后在两个帐号之间流式传输, 数据不经过本地磁盘
+func RunCopyCrossUser(opt *CrossUserCopyOptions, paths ...string) {
+	if opt == nil {
+		opt = &CrossUserCopyOptions{}
+	}
+	if opt.Policy != baidupcs.SkipPolicy && opt.Policy != baidupcs.OverWritePolicy && opt.Policy != baidupcs.RsyncPolicy {
+		opt.Policy = pcsconfig.Config.UPolicy
+	}
+	if len(paths) < 2 {
+		fmt.Println("跨帐号拷贝: 请指定源路径和目标目录")
+		return
+	}
+
+	from, err := getBaiduUserByUID(opt.FromUID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	to, err := getBaiduUserByUID(opt.ToUID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if from.UID == to.UID {
+		fmt.Println("源帐号与目标帐号相同, 请使用 cp 命令拷贝")
+		return
+	}
+
+	cu := &crossUserCopy{
+		from:     from,
+		to:       to,
+		srcPCS:   from.BaiduPCS(),
+		dstPCS:   to.BaiduPCS(),
+		parallel: opt.Parallel,
+		policy:   opt.Policy,
+	}
+	if cu.parallel < 1 {
+		cu.parallel = pcsconfig.Config.MaxUploadParallel
+	}
+
+	srcs, err := cu.matchSrcPaths(paths[:len(paths)-1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dstDir := to.PathJoin(paths[len(paths)-1])
+	err = cu.ensureDstDir(dstDir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("从帐号 %s (%d) 拷贝到帐号 %s (%d) 的目录: %s\n", from.Name, from.UID, to.Name, to.UID, dstDir)
+
+	if !opt.NoShare {
+		saved, err := cu.copyByShare(srcs, dstDir)
+		if err == nil {
+			fmt.Println("通过临时分享转存成功:")
+			for _, p := range saved {
+				fmt.Println(p)
+			}
+			return
+		}
+		fmt.Printf("临时分享转存失败, 改为逐个文件秒传或流式传输: %s\n", err)
+	}
+
+	var (
+		total   int
+		skipped int
+		failed  int
+	)
+	for _, src := range srcs {
+		n, s, f := cu.copyByFile(src, dstDir)
+		total += n
+		skipped += s
+		failed += f
+	}
+	fmt.Printf("拷贝完成, 文件总数: %d, 跳过: %d, 失败: %d\n", total, skipped, failed)
+}
+
+// matchSrcPaths 在源帐号中通配符匹配路径
+func (cu *crossUserCopy) matchSrcPaths(patterns []string) (srcs []string, err error) {
+	for _, pattern := range patterns {
+		paths, pcsError := cu.srcPCS.MatchPathByShellPattern(cu.from.PathJoin(pattern))
+		if pcsError != nil {
+			return nil, pcsError
+		}
+		srcs = append(srcs, paths...)
+	}
+	if len(srcs) == 0 {
+		return nil, ErrShellPatternNoHit
+	}
+	return srcs, nil
+}
+
+// ensureDstDir 检查目标目录, 不存在时创建
+func (cu *crossUserCopy) ensureDstDir(dstDir string) error {
+	fd, pcsError := cu.dstPCS.FilesDirectoriesMeta(dstDir)
+	if pcsError == nil {
+		if !fd.Isdir {
+			return fmt.Errorf("目标路径不是目录: %s", dstDir)
+		}
+		return nil
+	}
+	if pcsError.GetErrType() != pcserror.ErrTypeRemoteError {
+		return pcsError
+	}
+	pcsError = cu.dstPCS.Mkdir(dstDir)
+	if pcsError != nil {
+		return fmt.Errorf("创建目标目录失败: %s", pcsError)
+	}
+	return nil
+}
+
+// copyByShare 源帐号创建临时私密分享, 目标帐号转存, 完成或中断时取消分享.
+// 除覆盖策略外, 存在同名文件时转存失败, 改为逐个文件拷贝
+func (cu *crossUserCopy) copyByShare(srcs []string, dstDir string) (saved []string, err error) {
+	shared, pcsError := cu.srcPCS.ShareSet(srcs, &baidupcs.ShareOption{Period: 1})
+	if pcsError != nil {
+		return nil, pcsError
+	}
+
+	var (
+		cancelOnce  sync.Once
+		cancelShare = func() {
+			cancelOnce.Do(func() {
+				pcsError := cu.srcPCS.ShareCancel([]int64{shared.ShareID})
+				if pcsError != nil {
+					fmt.Printf("取消临时分享失败, shareID: %d, %s\n", shared.ShareID, pcsError)
+				}
+			})
+		}
+		sigChan = make(chan os.Signal, 1)
+		done    = make(chan struct{})
+	)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			fmt.Println("\n正在取消临时分享...")
+			cancelShare()
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+	defer func() {
+		signal.Stop(sigChan)
+		close(done)
+		cancelShare()
+	}()
+
+	var opt *baidupcs.TransferOption
+	if cu.policy == baidupcs.OverWritePolicy {
+		opt = &baidupcs.TransferOption{OnDup: baidupcs.TransferOnDupOverwrite}
+	}
+	_, saved, err = shareTransfer(cu.dstPCS, shared.Link, shared.Pwd, dstDir, opt)
+	return
+}
+
+// copyByFile 递归拷贝 src 下的文件, 返回文件数, 跳过数和失败数
+func (cu *crossUserCopy) copyByFile(src, dstDir string) (total, skipped, failed int) {
+	var (
+		srcParent = path.Dir(src)
+		files     baidupcs.FileDirectoryList
+	)
+	cu.srcPCS.FilesDirectoriesRecurseList(src, baidupcs.DefaultOrderOptions, func(depth int, _ string, fd *baidupcs.FileDirectory, pcsError pcserror.Error) bool {
+		if pcsError != nil {
+			fmt.Printf("获取文件列表失败: %s\n", pcsError)
+			return true
+		}
+		target := path.Join(dstDir, strings.TrimPrefix(fd.Path, srcParent))
+		if fd.Isdir {
+			// 保留空目录
+			pcsError = cu.dstPCS.Mkdir(target)
+			if pcsError != nil && pcsError.GetRemoteErrCode() != 31061 { // 31061: 目录已存在
+				fmt.Printf("创建目录失败: %s, %s\n", target, pcsError)
+			}
+			return true
+		}
+		files = append(files, fd)
+		return true
+	})
+
+	for _, fd := range files {
+		total++
+		target := path.Join(dstDir, strings.TrimPrefix(fd.Path, srcParent))
+
+		// 按策略处理同名文件
+		pcsError := cu.dstPCS.CheckIsdir(baidupcs.OperationRapidUpload, target, cu.policy, fd.Size)
+		if pcsError != nil {
+			switch pcsError.GetRemoteErrCode() {
+			case 114514, 1919810: // 自定义错误码, 目标已存在
+				skipped++
+				fmt.Printf("[%d] 目标已存在, 跳过: %s\n", total, target)
+			default:
+				failed++
+				fmt.Printf("[%d] 拷贝失败: %s, %s\n", total, fd.Path, pcsError)
+			}
+			continue
+		}
+
+		err := cu.copyFile(fd, target)
+		if err != nil {
+			failed++
+			fmt.Printf("[%d] 拷贝失败: %s, %s\n", total, fd.Path, err)
+			continue
+		}
+		fmt.Printf("[%d] 拷贝成功: %s -> %s\n", total, fd.Path, target)
+	}
+	return
+}
+
+// copyFile 先尝试使用源文件的 md5 和 slice md5 秒传, 失败时流式传输. 空文件直接创建
+func (cu *crossUserCopy) copyFile(fd *baidupcs.FileDirectory, target string) error {
+	if fd.Size == 0 {
+		return cu.createEmptyFile(target)
+	}
+
+	rinfo, pcsError := cu.srcPCS.GetRapidUploadInfoByFileInfo(fd)
+	if pcsError == nil {
+		pcsError = cu.dstPCS.RapidUploadNoCheckDir(target, rinfo.ContentMD5, rinfo.SliceMD5, rinfo.ContentCrc32, rinfo.ContentLength)
+		if pcsError == nil {
+			return nil
+		}
+	}
+	pcsCommandVerbose.Infof("秒传失败, 改为流式传输: %s, %s\n", fd.Path, pcsError)
+
+	return cu.streamFile(fd, target)
+}
+
+// createEmptyFile 在目标帐号创建空文件
+func (cu *crossUserCopy) createEmptyFile(target string) error {
+	pcsError, jsonData := cu.dstPCS.RapidUpload(target, cu.policy, "", baidupcs.EmptyContentMD5, baidupcs.EmptyContentMD5, "", "0", 0, 0, 0, time.Now().Unix(), []string{baidupcs.EmptyContentMD5})
+	if pcsError != nil {
+		return pcsError
+	}
+	if jsonData.ReturnType != 2 {
+		return errors.New("创建空文件失败")
+	}
+	return nil
+}
+
+// streamFile 从源帐号分段读取, 同时分片上传到目标帐号
+func (cu *crossUserCopy) streamFile(fd *baidupcs.FileDirectory, target string) error {
+	return cu.srcPCS.DownloadFile(fd.Path, func(downloadURL string, jar http.CookieJar) error {
+		client := pcsconfig.Config.PCSHTTPClient()
+		client.SetCookiejar(jar)
+		client.SetKeepAlive(true)
+		client.SetTimeout(10 * time.Minute)
+
+		rr := pcsdownload.NewRemoteReaderAt(client, downloadURL, fd.Size, cu.parallel)
+		defer rr.Close()
+
+		err := pcsupload.UploadReaderAt(cu.dstPCS, target, cu.policy, rr, cu.parallel, func(status uploader.Status, _ <-chan struct{}) {
+			fmt.Printf("\r↑ %s/%s %s/s in %s ............",
+				converter.ConvertFileSize(status.Uploaded(), 2),
+				converter.ConvertFileSize(status.TotalSize(), 2),
+				converter.ConvertFileSize(status.SpeedsPerSecond(), 2),
+				status.TimeElapsed(),
+			)
+		})
+		fmt.Println()
+		return err
+	})
+}
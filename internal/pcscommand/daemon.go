@@ -0,0 +1,428 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdaemon"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcsutil"
+	"BaiduPCS-Go/pcsutil/checksum"
+	"BaiduPCS-Go/pcsutil/jsonrpc"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultDaemonAddr 默认的后台服务监听地址
+	DefaultDaemonAddr = "127.0.0.1:6900"
+	// DefaultAria2Addr 默认的 aria2 RPC 监听地址, 与 aria2 一致
+	DefaultAria2Addr = "127.0.0.1:6800"
+
+	// daemonMaxRequestSize 请求体的最大长度
+	daemonMaxRequestSize = 1 << 20
+)
+
+type (
+	// DaemonOptions 后台服务可选项
+	DaemonOptions struct {
+		Addr                string
+		Token               string // 必须设置, 请求需要带上 Authorization: Bearer <Token>, aria2 RPC 的请求需要带上 token:<Token>
+		Aria2Addr           string // 不为空时, 在该地址提供 aria2 RPC
+		Aria2AllowOriginAll bool   // 允许浏览器中的 aria2 前端跨域访问, 对应 aria2 的 --rpc-allow-origin-all
+		Version             string // aria2.getVersion 返回的版本
+		Load                int    // 同时进行的任务数
+		MaxRetry            int
+		Download            *DownloadOptions
+		Upload              *UploadOptions
+	}
+
+	// daemon 后台服务
+	daemon struct {
+		pcs            *baidupcs.BaiduPCS
+		tm             *pcsdaemon.TaskManager
+		opt            *DaemonOptions
+		uploadDatabase *pcsupload.UploadingDatabase
+	}
+
+	// daemonParams 后台服务 JSON-RPC 方法的参数
+	daemonParams struct {
+		ID       string   `json:"id"`
+		Type     string   `json:"type"`     // 任务类型, download 或 upload
+		Paths    []string `json:"paths"`    // 下载时为网盘路径, 上传时为本地路径
+		SaveTo   string   `json:"save_to"`  // 下载时为本地目录, 上传时为网盘目录
+		Priority int      `json:"priority"` // 优先级, 数值越大越先执行
+	}
+)
+
+var (
+	errDaemonIDEmpty = jsonrpc.NewInvalidParamsError(errors.New("id is empty"))
+)
+
+// RunDaemon 启动后台服务, 常驻执行下载和上传任务, 通过 JSON-RPC 控制
+func RunDaemon(opt *DaemonOptions) {
+	if opt == nil {
+		opt = &DaemonOptions{}
+	}
+	if opt.Token == "" {
+		fmt.Printf("未设置 token, 请使用 --token 设置访问令牌\n")
+		return
+	}
+	if opt.Addr == "" {
+		opt.Addr = DefaultDaemonAddr
+	}
+	if opt.Load <= 0 {
+		opt.Load = pcsconfig.Config.MaxDownloadLoad
+	}
+	if opt.MaxRetry < 0 {
+		opt.MaxRetry = pcsdownload.DefaultDownloadMaxRetry
+	}
+	if opt.Download == nil {
+		opt.Download = &DownloadOptions{}
+	}
+	if opt.Download.Parallel < 1 {
+		opt.Download.Parallel = pcsconfig.Config.MaxParallel
+	}
+	if !opt.Download.NoCheck {
+		opt.Download.NoCheck = pcsconfig.Config.NoCheck
+	}
+	if runtime.GOOS == "windows" {
+		opt.Download.IsExecutedPermission = false
+	}
+	if opt.Upload == nil {
+		opt.Upload = &UploadOptions{}
+	}
+	if opt.Upload.Parallel <= 0 {
+		opt.Upload.Parallel = pcsconfig.Config.MaxUploadParallel
+	}
+	if opt.Upload.Policy != baidupcs.SkipPolicy && opt.Upload.Policy != baidupcs.OverWritePolicy && opt.Upload.Policy != baidupcs.RsyncPolicy {
+		opt.Upload.Policy = pcsconfig.Config.UPolicy
+	}
+	opt.Upload.NoFilenameCheck = pcsconfig.Config.IgnoreIllegal
+
+	// 打开上传状态
+	uploadDatabase, err := pcsupload.NewUploadingDatabase()
+	if err != nil {
+		fmt.Printf("打开上传未完成数据库错误: %s\n", err)
+		return
+	}
+	defer uploadDatabase.Close()
+
+	d := &daemon{
+		pcs:            GetBaiduPCS(),
+		tm:             pcsdaemon.NewTaskManager(opt.Load, opt.MaxRetry),
+		opt:            opt,
+		uploadDatabase: uploadDatabase,
+	}
+
+	server := jsonrpc.NewServer()
+	d.register(server)
+
+	mux := http.NewServeMux()
+	mux.Handle("/jsonrpc", d.auth(requireJSON(limitRequestSize(server))))
+
+	httpServer := &http.Server{
+		Addr:    opt.Addr,
+		Handler: mux,
+	}
+
+	var aria2Server *http.Server
+	if opt.Aria2Addr != "" {
+		aria2RPC := jsonrpc.NewServer()
+		pcsdaemon.NewAria2(d.tm, opt.Token, opt.Version, d.addURI).Register(aria2RPC)
+
+		aria2Mux := http.NewServeMux()
+		var aria2Handler http.Handler = limitRequestSize(aria2RPC)
+		if opt.Aria2AllowOriginAll {
+			aria2Handler = allowCORS(aria2Handler)
+		}
+		aria2Mux.Handle("/jsonrpc", aria2Handler)
+		aria2Server = &http.Server{
+			Addr:    opt.Aria2Addr,
+			Handler: aria2Mux,
+		}
+		go func() {
+			fmt.Printf("aria2 RPC 已启动: http://%s/jsonrpc\n", opt.Aria2Addr)
+			err := aria2Server.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				fmt.Printf("aria2 RPC 错误: %s\n", err)
+				httpServer.Close()
+			}
+		}()
+	}
+
+	// 退出时取消所有任务
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		fmt.Printf("正在停止后台服务...\n")
+		httpServer.Close()
+	}()
+
+	serveDone := make(chan struct{})
+	go func() {
+		d.tm.Serve()
+		close(serveDone)
+	}()
+
+	fmt.Printf("后台服务已启动: http://%s/jsonrpc, 同时进行的任务数: %d\n", opt.Addr, opt.Load)
+
+	err = httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Printf("后台服务错误: %s\n", err)
+	}
+	if aria2Server != nil {
+		aria2Server.Close()
+	}
+
+	d.tm.Stop()
+	<-serveDone
+}
+
+// auth 检查 token
+func (d *daemon) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if d.opt.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(d.opt.Token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireJSON 只接受 Content-Type 为 application/json 的 POST 请求, 避免浏览器跨站提交表单
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitRequestSize 限制请求体的长度
+func limitRequestSize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, daemonMaxRequestSize)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowCORS 允许浏览器中的 aria2 前端跨域访问, 请求仍需带上 token
+func allowCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// register 注册 JSON-RPC 方法
+func (d *daemon) register(server *jsonrpc.Server) {
+	server.Register("task.add", d.withParams(func(p *daemonParams) (interface{}, error) {
+		var (
+			ids []string
+			err error
+		)
+		switch p.Type {
+		case "", string(pcsdaemon.TaskTypeDownload):
+			ids, err = d.addDownload(p.Paths, p.SaveTo, p.Priority)
+		case string(pcsdaemon.TaskTypeUpload):
+			ids, err = d.addUpload(p.Paths, p.SaveTo, p.Priority)
+		default:
+			return nil, jsonrpc.NewInvalidParamsError(fmt.Errorf("unknown task type: %s", p.Type))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]string{"ids": ids}, nil
+	}))
+	server.Register("task.list", func(json.RawMessage) (interface{}, error) {
+		return d.tm.List(), nil
+	})
+	server.Register("task.status", d.withID(func(p *daemonParams) (interface{}, error) {
+		return d.tm.Get(p.ID)
+	}))
+	server.Register("task.pause", d.withID(func(p *daemonParams) (interface{}, error) {
+		return "OK", d.tm.Pause(p.ID)
+	}))
+	server.Register("task.resume", d.withID(func(p *daemonParams) (interface{}, error) {
+		return "OK", d.tm.Resume(p.ID)
+	}))
+	server.Register("task.cancel", d.withID(func(p *daemonParams) (interface{}, error) {
+		return "OK", d.tm.Cancel(p.ID)
+	}))
+	server.Register("task.setPriority", d.withID(func(p *daemonParams) (interface{}, error) {
+		return "OK", d.tm.SetPriority(p.ID, p.Priority)
+	}))
+	server.Register("task.purge", func(json.RawMessage) (interface{}, error) {
+		return map[string]int{"removed": d.tm.Purge()}, nil
+	})
+}
+
+// withParams 解析参数
+func (d *daemon) withParams(f func(p *daemonParams) (interface{}, error)) jsonrpc.HandlerFunc {
+	return func(params json.RawMessage) (interface{}, error) {
+		p := &daemonParams{}
+		if len(params) > 0 {
+			err := json.Unmarshal(params, p)
+			if err != nil {
+				return nil, jsonrpc.NewInvalidParamsError(err)
+			}
+		}
+		return f(p)
+	}
+}
+
+// withID 解析参数, 参数中必须有任务id
+func (d *daemon) withID(f func(p *daemonParams) (interface{}, error)) jsonrpc.HandlerFunc {
+	return d.withParams(func(p *daemonParams) (interface{}, error) {
+		if p.ID == "" {
+			return nil, errDaemonIDEmpty
+		}
+		return f(p)
+	})
+}
+
+// addDownload 加入下载任务, 目录中的每个文件为一个任务
+func (d *daemon) addDownload(paths []string, saveTo string, priority int) (ids []string, err error) {
+	paths, err = matchPathByShellPattern(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := pcsconfig.Config.Cipher()
+	if err != nil && err != pcsconfig.ErrEncryptPassphraseNotSet {
+		return nil, err
+	}
+
+	options := *d.opt.Download
+	if saveTo != "" {
+		options.SaveTo = saveTo
+	}
+	cfg := newDownloaderConfig(false)
+	cfg.MaxParallel = pcsconfig.AverageParallel(options.Parallel, d.opt.Load)
+
+	for _, p := range paths {
+		var walkErr error
+		d.pcs.FilesDirectoriesRecurseList(p, baidupcs.DefaultOrderOptions, func(depth int, _ string, fd *baidupcs.FileDirectory, pcsError pcserror.Error) bool {
+			if pcsError != nil {
+				walkErr = pcsError
+				return false
+			}
+			if fd.Isdir {
+				return true
+			}
+			task := d.tm.AddDownload(newDownloadTaskUnit(d.pcs, cfg, fd, &options, cipher), priority)
+			fmt.Printf("[%s] 加入下载队列: %s\n", task.ID, fd.Path)
+			ids = append(ids, task.ID)
+			return true
+		})
+		if walkErr != nil {
+			return ids, walkErr
+		}
+	}
+	return ids, nil
+}
+
+// addURI 处理 aria2.addUri, uri 为网盘路径或分享链接.
+// 分享链接先转存到当前工作目录, 再下载转存的文件
+func (d *daemon) addURI(uri, dir string) (ids []string, err error) {
+	if !isShareLink(uri) {
+		return d.addDownload([]string{uri}, dir, 0)
+	}
+
+	savedName, paths, err := shareTransfer(d.pcs, uri, "", GetActiveUser().Workdir, &baidupcs.TransferOption{Collect: true})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s成功, 保存了%s到当前目录\n", baidupcs.OperationShareFileSavetoLocal, savedName)
+
+	// 刚转存的文件可能还不能获取到, 稍后重试
+	for i := 0; i < 5; i++ {
+		ids, err = d.addDownload(paths, dir, 0)
+		if len(ids) > 0 {
+			return ids, nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return ids, err
+}
+
+// addUpload 加入上传任务, 目录中的每个文件为一个任务
+func (d *daemon) addUpload(localPaths []string, savePath string, priority int) (ids []string, err error) {
+	if savePath == "" {
+		return nil, jsonrpc.NewInvalidParamsError(errors.New("save_to is empty"))
+	}
+	err = matchPathByShellPatternOnce(&savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, localPath := range localPaths {
+		walkedFiles, err := pcsutil.WalkDir(localPath, "")
+		if err != nil {
+			return ids, err
+		}
+
+		localPathDir := filepath.Dir(localPath)
+		if os.PathSeparator == '\\' {
+			localPathDir = pcsutil.ConvertToUnixPathSeparator(localPathDir)
+		}
+		// 避免去除文件名开头的"."
+		if localPathDir == "." {
+			localPathDir = ""
+		}
+
+		for _, file := range walkedFiles {
+			if os.PathSeparator == '\\' {
+				file = pcsutil.ConvertToUnixPathSeparator(file)
+			}
+			if !d.opt.Upload.NoFilenameCheck && !pcsutil.ChPathLegal(file) {
+				fmt.Printf("[0] %s 文件路径含有非法字符，已跳过!\n", file)
+				continue
+			}
+
+			task := d.tm.AddUpload(&pcsupload.UploadTaskUnit{
+				LocalFileChecksum: checksum.NewLocalFileChecksum(file, int(baidupcs.SliceMD5Size)),
+				SavePath:          path.Clean(savePath + baidupcs.PathSeparator + strings.TrimPrefix(file, localPathDir)),
+				PCS:               d.pcs,
+				UploadingDatabase: d.uploadDatabase,
+				Parallel:          d.opt.Upload.Parallel,
+				PrintFormat:       pcsupload.DefaultPrintFormat,
+				NoRapidUpload:     d.opt.Upload.NoRapidUpload,
+				NoSplitFile:       d.opt.Upload.NoSplitFile,
+				UploadStatistic:   &pcsupload.UploadStatistic{},
+				Policy:            d.opt.Upload.Policy,
+			}, priority)
+			fmt.Printf("[%s] 加入上传队列: %s\n", task.ID, file)
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids, nil
+}
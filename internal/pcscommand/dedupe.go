@@ -0,0 +1,206 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdedupe"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type (
+	// DedupeOptions dedupe 可选参数
+	DedupeOptions struct {
+		Keep     pcsdedupe.KeepPolicy // 保留策略, 为空时只列出重复文件
+		MinSize  int64                // 忽略小于该大小的文件
+		Parallel int                  // 获取目录列表的并发数
+		Yes      bool                 // 删除时不需要确认
+	}
+)
+
+// flattenFiles 将目录树展开为文件列表
+func flattenFiles(fdl baidupcs.FileDirectoryList) (files baidupcs.FileDirectoryList) {
+	for _, fd := range fdl {
+		if fd.Isdir {
+			files = append(files, flattenFiles(fd.Children)...)
+			continue
+		}
+		files = append(files, fd)
+	}
+	return
+}
+
+// RunDedupe 执行查找重复文件, 设置了保留策略时删除重复的文件
+func RunDedupe(pcspaths []string, opt *DedupeOptions) {
+	if opt == nil {
+		opt = &DedupeOptions{}
+	}
+	if opt.Parallel < 1 {
+		opt.Parallel = DefaultDuParallel
+	}
+	if len(pcspaths) == 0 {
+		pcspaths = []string{baidupcs.PathSeparator}
+	}
+
+	pcspaths, err := matchPathByShellPattern(pcspaths...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var (
+		pcs   = GetBaiduPCS()
+		files baidupcs.FileDirectoryList
+		seen  = map[string]bool{}
+	)
+	for _, pcspath := range pcspaths {
+		fmt.Printf("正在获取文件列表: %s\n", pcspath)
+		root, pcsError := pcs.FilesDirectoriesConcurrentRecurseList(pcspath, baidupcs.DefaultOrderOptions, opt.Parallel, func(fdPath string, pcsError pcserror.Error) bool {
+			fmt.Printf("获取目录列表失败, 跳过: %s, %s\n", fdPath, pcsError)
+			return true
+		})
+		if pcsError != nil {
+			fmt.Println(pcsError)
+			continue
+		}
+		for _, fd := range flattenFiles(baidupcs.FileDirectoryList{root}) {
+			// 忽略重叠的目录中重复列出的文件
+			if !seen[fd.Path] {
+				seen[fd.Path] = true
+				files = append(files, fd)
+			}
+		}
+	}
+
+	groups := pcsdedupe.FindGroups(files, opt.MinSize)
+	if len(groups) == 0 {
+		fmt.Printf("共 %d 个文件, 没有重复的文件\n", len(files))
+		return
+	}
+
+	var wasted, duplicates int64
+	for _, g := range groups {
+		wasted += g.Wasted()
+		duplicates += int64(len(g.Files) - 1)
+	}
+
+	if opt.Keep == "" {
+		tb := pcstable.NewTable(os.Stdout)
+		tb.SetHeader([]string{"#", "md5", "文件大小", "浪费空间", "上传时间", "路径"})
+		for k, g := range groups {
+			for i, fd := range g.Files {
+				if i == 0 {
+					tb.Append([]string{strconv.Itoa(k), g.MD5, converter.ConvertFileSize(g.Size, 2), converter.ConvertFileSize(g.Wasted(), 2), pcstime.FormatTime(fd.Ctime), fd.Path})
+					continue
+				}
+				tb.Append([]string{"", "", "", "", pcstime.FormatTime(fd.Ctime), fd.Path})
+			}
+		}
+		tb.Render()
+		fmt.Printf("\n共 %d 个文件, %d 组重复文件, %d 个多余的文件, 浪费空间: %s\n", len(files), len(groups), duplicates, converter.ConvertFileSize(wasted, 2))
+		return
+	}
+
+	var (
+		removes []string
+		reader  = bufio.NewReader(os.Stdin)
+	)
+	if opt.Keep == pcsdedupe.KeepInteractive {
+		for k, g := range groups {
+			remove, ok := dedupeSelect(reader, k, len(groups), g)
+			if !ok {
+				break
+			}
+			for _, fd := range remove {
+				removes = append(removes, fd.Path)
+			}
+		}
+	} else {
+		tb := pcstable.NewTable(os.Stdout)
+		tb.SetHeader([]string{"#", "保留", "删除"})
+		for k, g := range groups {
+			keep, remove, err := g.Keep(opt.Keep)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			for i, fd := range remove {
+				if i == 0 {
+					tb.Append([]string{strconv.Itoa(k), keep.Path, fd.Path})
+				} else {
+					tb.Append([]string{"", "", fd.Path})
+				}
+				removes = append(removes, fd.Path)
+			}
+		}
+		tb.Render()
+	}
+
+	if len(removes) == 0 {
+		fmt.Println("没有需要删除的文件")
+		return
+	}
+
+	if !opt.Yes {
+		fmt.Printf("确认删除 %d 个重复的文件 ? (y/n) > ", len(removes))
+		confirm, _ := reader.ReadString('\n')
+		confirm = strings.TrimSpace(confirm)
+		if confirm != "y" && confirm != "Y" {
+			fmt.Println("已取消删除")
+			return
+		}
+	}
+
+	removed := len(removes) - len(removeInBatches(pcs, removes, os.Stdout))
+	fmt.Printf("已删除 %d 个重复的文件, 可在网盘文件回收站找回\n", removed)
+}
+
+// dedupeSelect 交互式选择一组重复文件中保留的文件, 返回需要删除的文件, ok 为 false 时结束选择
+func dedupeSelect(reader *bufio.Reader, k, total int, g *pcsdedupe.Group) (remove baidupcs.FileDirectoryList, ok bool) {
+	fmt.Printf("\n[%d/%d] md5: %s, 文件大小: %s\n", k+1, total, g.MD5, converter.ConvertFileSize(g.Size, 2))
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "上传时间", "路径"})
+	for i, fd := range g.Files {
+		tb.Append([]string{strconv.Itoa(i), pcstime.FormatTime(fd.Ctime), fd.Path})
+	}
+	tb.Render()
+
+	for {
+		fmt.Print("输入保留的文件序号, 多个以逗号分隔, 回车保留全部, q 结束选择 > ")
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err != nil && line == "" {
+			return nil, false
+		}
+		switch line {
+		case "":
+			return nil, true
+		case "q", "Q":
+			return nil, false
+		}
+
+		var (
+			keeps []int
+			valid = true
+		)
+		for _, s := range strings.Split(line, ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || i < 0 || i >= len(g.Files) {
+				valid = false
+				break
+			}
+			keeps = append(keeps, i)
+		}
+		if !valid {
+			fmt.Println("序号错误, 请重新输入")
+			continue
+		}
+		return g.Except(keeps...), true
+	}
+}
@@ -7,32 +7,37 @@ import (
 	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
 	"BaiduPCS-Go/pcstable"
 	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/pcsutil/taskframework"
 	"BaiduPCS-Go/requester/downloader"
 	"BaiduPCS-Go/requester/transfer"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
+	"time"
 )
 
 type (
 	//DownloadOptions 下载可选参数
 	DownloadOptions struct {
-		IsTest               bool
-		IsPrintStatus        bool
-		IsExecutedPermission bool
-		IsOverwrite          bool
-		DownloadMode         pcsdownload.DownloadMode
-		SaveTo               string
-		Parallel             int
-		Load                 int
-		MaxRetry             int
-		NoCheck              bool
-		ModifyMTime          bool
-		FullPath             bool
-		LinkPrefer           int
+		IsTest               bool                        `json:"test,omitempty"`
+		IsPrintStatus        bool                        `json:"status,omitempty"`
+		IsExecutedPermission bool                        `json:"x,omitempty"`
+		IsOverwrite          bool                        `json:"ow,omitempty"`
+		DownloadMode         pcsdownload.DownloadMode    `json:"mode"`
+		SaveTo               string                      `json:"saveto,omitempty"`
+		Parallel             int                         `json:"p"`
+		Load                 int                         `json:"l"`
+		MaxRetry             int                         `json:"retry"`
+		NoCheck              bool                        `json:"nocheck,omitempty"`
+		ModifyMTime          bool                        `json:"mtime,omitempty"`
+		FullPath             bool                        `json:"fullpath,omitempty"`
+		LinkPrefer           int                         `json:"dindex,omitempty"`
+		Filter               *pcsdownload.DownloadFilter `json:"filter,omitempty"` // 下载目录时的过滤条件
 	}
 
 	// LocateDownloadOption 获取下载链接可选参数
@@ -48,8 +53,64 @@ func downloadPrintFormat(load int) string {
 	return "[%s] ↓ %s/%s %s/s in %s, left %s ...\n"
 }
 
-// RunDownload 执行下载网盘内文件
-func RunDownload(paths []string, options *DownloadOptions) {
+// newDownloaderConfig 根据程序配置生成下载配置
+func newDownloaderConfig(isTest bool) *downloader.Config {
+	return &downloader.Config{
+		Mode:                       transfer.RangeGenMode_BlockSize,
+		CacheSize:                  pcsconfig.Config.CacheSize,
+		BlockSize:                  baidupcs.InitRangeSize,
+		MaxRate:                    pcsconfig.Config.MaxDownloadRate,
+		InstanceStateStorageFormat: downloader.InstanceStateStorageFormatProto3,
+		IsTest:                     isTest,
+		TryHTTP:                    !pcsconfig.Config.EnableHTTPS,
+	}
+}
+
+// newDownloadTaskUnit 初始化下载任务单元, 设置保存路径.
+// 设置了加密口令时, 自动解密加密的文件和文件名
+func newDownloadTaskUnit(pcs *baidupcs.BaiduPCS, cfg *downloader.Config, fd *baidupcs.FileDirectory, options *DownloadOptions, cipher *e2ee.Cipher) *pcsdownload.DownloadTaskUnit {
+	newCfg := *cfg
+	unit := &pcsdownload.DownloadTaskUnit{
+		Cfg:                  &newCfg, // 复制一份新的cfg
+		PCS:                  pcs,
+		VerbosePrinter:       pcsCommandVerbose,
+		PrintFormat:          pcsdownload.DefaultPrintFormat,
+		DownloadStatistic:    &pcsdownload.DownloadStatistic{},
+		IsPrintStatus:        options.IsPrintStatus,
+		IsExecutedPermission: options.IsExecutedPermission,
+		IsOverwrite:          options.IsOverwrite,
+		NoCheck:              options.NoCheck,
+		DlinkPrefer:          options.LinkPrefer,
+		DownloadMode:         options.DownloadMode,
+		ModifyMTime:          options.ModifyMTime,
+		PcsPath:              fd.Path,
+		FileInfo:             fd,
+	}
+
+	// 设置储存的路径
+	vPath := fd.Path
+	if !options.FullPath {
+		vPath = filepath.Join(fd.PreBase, filepath.Base(fd.Path))
+	}
+	if cipher != nil {
+		if _, encrypted := cipher.DecryptFileName(fd.Filename); encrypted && !fd.Isdir {
+			if _, sizeErr := e2ee.PlainSize(fd.Size); sizeErr == nil {
+				unit.Cipher = cipher
+			}
+		}
+		vPath = cipher.DecryptPath(vPath, string(os.PathSeparator))
+	}
+	if options.SaveTo != "" {
+		unit.SavePath = filepath.Join(options.SaveTo, vPath)
+	} else {
+		// 使用默认的保存路径
+		unit.SavePath = GetActiveUser().GetSavePath(vPath)
+	}
+	return unit
+}
+
+// initDownloadOptions 设置下载可选参数的默认值
+func initDownloadOptions(options *DownloadOptions) *DownloadOptions {
 	if options == nil {
 		options = &DownloadOptions{}
 	}
@@ -71,26 +132,29 @@ func RunDownload(paths []string, options *DownloadOptions) {
 		options.IsExecutedPermission = false
 	}
 
-	// 设置下载配置
-	cfg := &downloader.Config{
-		Mode:                       transfer.RangeGenMode_BlockSize,
-		CacheSize:                  pcsconfig.Config.CacheSize,
-		BlockSize:                  baidupcs.InitRangeSize,
-		MaxRate:                    pcsconfig.Config.MaxDownloadRate,
-		InstanceStateStorageFormat: downloader.InstanceStateStorageFormatProto3,
-		IsTest:                     options.IsTest,
-		TryHTTP:                    !pcsconfig.Config.EnableHTTPS,
-	}
-
 	// 设置下载最大并发量
 	if options.Parallel < 1 {
 		options.Parallel = pcsconfig.Config.MaxParallel
 	}
+	return options
+}
 
-	paths, err := matchPathByShellPattern(paths...)
+// RunDownload 执行下载网盘内文件
+func RunDownload(paths []string, options *DownloadOptions) {
+	downloadPaths(paths, options)
+}
+
+// downloadPaths 下载网盘内文件, 返回下载失败的文件数, 获取文件列表失败的路径也计入失败数
+func downloadPaths(paths []string, options *DownloadOptions) (failed int, err error) {
+	options = initDownloadOptions(options)
+
+	// 设置下载配置
+	cfg := newDownloaderConfig(options.IsTest)
+
+	paths, err = matchPathByShellPattern(paths...)
 	if err != nil {
 		fmt.Println(err)
-		return
+		return 0, err
 	}
 
 	fmt.Print("\n")
@@ -102,13 +166,47 @@ func RunDownload(paths []string, options *DownloadOptions) {
 	)
 
 	// 预测要下载的文件数量
+	var (
+		filter        = options.Filter
+		filterEnabled = !filter.IsEmpty()
+		skipCount     = 0
+		listFailed    = 0 // 获取文件列表失败的数量
+	)
 	file_dir_list := make([]*baidupcs.FileDirectory, 0, 10)
 	for k := range paths {
+		var (
+			rootPrefix   = strings.TrimSuffix(paths[k], baidupcs.PathSeparator) + baidupcs.PathSeparator
+			excludedDirs []string // 被排除的目录
+		)
 		pcs.FilesDirectoriesRecurseList(paths[k], baidupcs.DefaultOrderOptions, func(depth int, _ string, fd *baidupcs.FileDirectory, pcsError pcserror.Error) bool {
 			if pcsError != nil {
-				pcsCommandVerbose.Warnf("%s\n", pcsError)
+				fmt.Printf("获取文件列表失败: %s\n", pcsError)
+				listFailed++
 				return true
 			}
+			if filterEnabled {
+				// 在规划下载时过滤, 跳过的文件不加入下载队列
+				relPath := path.Base(fd.Path)
+				if depth > 0 {
+					relPath = strings.TrimPrefix(fd.Path, rootPrefix)
+				}
+				for _, dir := range excludedDirs {
+					if strings.HasPrefix(fd.Path, dir) {
+						return true
+					}
+				}
+				if fd.Isdir {
+					if depth > 0 && filter.ExcludeDir(relPath) {
+						excludedDirs = append(excludedDirs, fd.Path+baidupcs.PathSeparator)
+					}
+					// 只建立有文件下载的目录
+					return true
+				}
+				if !filter.MatchFile(relPath, fd.Size, fd.Mtime) {
+					skipCount++
+					return true
+				}
+			}
 			file_dir_list = append(file_dir_list, fd)
 			// 忽略统计文件夹数量
 			if !fd.Isdir {
@@ -120,6 +218,26 @@ func RunDownload(paths []string, options *DownloadOptions) {
 			return true
 		})
 	}
+	if filterEnabled {
+		fmt.Printf("[0] 过滤条件跳过了 %d 个文件, 待下载 %d 个文件\n", skipCount, len(file_dir_list))
+	}
+
+	// 记录下载任务, 中断后可以继续下载
+	var (
+		jd  *pcsdownload.DownloadJobDatabase
+		job *pcsdownload.DownloadJob
+	)
+	if !options.IsTest && len(file_dir_list) > 0 {
+		jd, err = pcsdownload.NewDownloadJobDatabase()
+		if err == nil {
+			job, err = jd.NewJob(GetActiveUser().UID, paths, options)
+		}
+		if err != nil {
+			pcsCommandVerbose.Warnf("记录下载任务失败: %s\n", err)
+			jd, job = nil, nil
+		}
+	}
+
 	// 修改Load, 设置MaxParallel
 	if loadCount > 0 {
 		options.Load = loadCount
@@ -136,46 +254,61 @@ func RunDownload(paths []string, options *DownloadOptions) {
 		statistic = &pcsdownload.DownloadStatistic{}
 	)
 
+	// 设置了加密口令时, 自动解密加密的文件和文件名
+	cipher, err := pcsconfig.Config.Cipher()
+	if err != nil && err != pcsconfig.ErrEncryptPassphraseNotSet {
+		fmt.Printf("初始化解密错误: %s\n", err)
+		return 0, err
+	}
+
 	// 处理队列, 小文件优先下载
 	sort.Slice(file_dir_list, func(i, j int) bool {
 		return file_dir_list[i].Size < file_dir_list[j].Size
 	})
 	for _, v := range file_dir_list {
-		newCfg := *cfg
-		unit := pcsdownload.DownloadTaskUnit{
-			Cfg:                  &newCfg, // 复制一份新的cfg
-			PCS:                  pcs,
-			VerbosePrinter:       pcsCommandVerbose,
-			PrintFormat:          downloadPrintFormat(options.Load),
-			ParentTaskExecutor:   &executor,
-			DownloadStatistic:    statistic,
-			IsPrintStatus:        options.IsPrintStatus,
-			IsExecutedPermission: options.IsExecutedPermission,
-			IsOverwrite:          options.IsOverwrite,
-			NoCheck:              options.NoCheck,
-			DlinkPrefer:          options.LinkPrefer,
-			DownloadMode:         options.DownloadMode,
-			ModifyMTime:          options.ModifyMTime,
-			PcsPath:              v.Path,
-			FileInfo:             v,
+		unit := newDownloadTaskUnit(pcs, cfg, v, options, cipher)
+		unit.PrintFormat = downloadPrintFormat(options.Load)
+		unit.ParentTaskExecutor = &executor
+		unit.DownloadStatistic = statistic
+		if job != nil {
+			unit.Job = job
+			unit.JobFile = job.AddFile(v.Path, unit.SavePath, v.Size, v.Isdir)
 		}
 		// 设置下载并发数
 		executor.SetParallel(loadCount)
-		// 设置储存的路径
-		vPath := v.Path
-		if !options.FullPath {
-			vPath = filepath.Join(v.PreBase, filepath.Base(v.Path))
-		}
-		if options.SaveTo != "" {
-			unit.SavePath = filepath.Join(options.SaveTo, vPath)
-		} else {
-			// 使用默认的保存路径
-			unit.SavePath = GetActiveUser().GetSavePath(vPath)
-		}
-		info := executor.Append(&unit, options.MaxRetry)
+		info := executor.Append(unit, options.MaxRetry)
 		fmt.Printf("[%s] 加入下载队列: %s\n", info.Id(), v.Path)
 	}
 
+	return executeDownload(&executor, statistic, jd, job) + listFailed, nil
+}
+
+// executeDownload 执行下载队列, 输出失败的文件列表, 返回失败的文件数.
+// job 不为nil时, 定时保存下载任务的进度, 全部下载成功后删除下载任务
+func executeDownload(executor *taskframework.TaskExecutor, statistic *pcsdownload.DownloadStatistic, jd *pcsdownload.DownloadJobDatabase, job *pcsdownload.DownloadJob) (failed int) {
+	if job != nil {
+		err := job.Save()
+		if err != nil {
+			pcsCommandVerbose.Warnf("保存下载任务失败: %s\n", err)
+		}
+		fmt.Printf("[0] 下载任务: %s, 中断后可使用 download --resume-job %s 继续下载\n", job.ID, job.ID)
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(3 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					job.Save()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
 	// 开始计时
 	statistic.StartTimer()
 
@@ -186,7 +319,8 @@ func RunDownload(paths []string, options *DownloadOptions) {
 
 	// 输出失败的文件列表
 	failedList := executor.FailedDeque()
-	if failedList.Size() != 0 {
+	failed = failedList.Size()
+	if failed != 0 {
 		fmt.Printf("以下文件下载失败: \n")
 		tb := pcstable.NewTable(os.Stdout)
 		for e := failedList.Shift(); e != nil; e = failedList.Shift() {
@@ -195,4 +329,18 @@ func RunDownload(paths []string, options *DownloadOptions) {
 		}
 		tb.Render()
 	}
+
+	if job == nil {
+		return
+	}
+	if job.Done() {
+		jd.Delete(job.ID)
+		return
+	}
+	fmt.Printf("下载任务 %s 未全部完成, 可使用 download --resume-job %s 重试\n", job.ID, job.ID)
+	err := job.Save()
+	if err != nil {
+		pcsCommandVerbose.Warnf("保存下载任务失败: %s\n", err)
+	}
+	return
 }
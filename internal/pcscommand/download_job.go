@@ -0,0 +1,154 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// RunDownloadJobList 列出当前帐号未完成的下载任务
+func RunDownloadJobList() {
+	jd, err := pcsdownload.NewDownloadJobDatabase()
+	if err != nil {
+		fmt.Printf("打开下载任务数据库失败: %s\n", err)
+		return
+	}
+
+	jobs := jd.List(GetActiveUser().UID)
+	if len(jobs) == 0 {
+		fmt.Println("没有未完成的下载任务")
+		return
+	}
+
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"ID", "路径", "文件数", "成功", "失败", "总大小", "创建时间", "更新时间"})
+	for _, job := range jobs {
+		_, succeeded, failed := job.Count()
+		var size int64
+		for _, file := range job.Files {
+			size += file.Size
+		}
+		tb.Append([]string{job.ID, strings.Join(job.Paths, ", "), strconv.Itoa(len(job.Files)), strconv.Itoa(succeeded), strconv.Itoa(failed), converter.ConvertFileSize(size, 2), pcstime.FormatTime(job.CreateTime), pcstime.FormatTime(job.UpdateTime)})
+	}
+	tb.Render()
+}
+
+// RunDownloadJobDiscard 丢弃下载任务, 不删除已下载的文件
+func RunDownloadJobDiscard(ids []string) {
+	jd, err := pcsdownload.NewDownloadJobDatabase()
+	if err != nil {
+		fmt.Printf("打开下载任务数据库失败: %s\n", err)
+		return
+	}
+
+	for _, id := range ids {
+		err = jd.Delete(id)
+		if err != nil {
+			fmt.Printf("丢弃下载任务 %s 失败: %s\n", id, err)
+			continue
+		}
+		fmt.Printf("已丢弃下载任务: %s\n", id)
+	}
+}
+
+// RunDownloadResumeJob 继续下载中断的下载任务, 使用任务保存的下载选项,
+// 只下载未完成和下载失败的文件
+func RunDownloadResumeJob(id string) {
+	jd, err := pcsdownload.NewDownloadJobDatabase()
+	if err != nil {
+		fmt.Printf("打开下载任务数据库失败: %s\n", err)
+		return
+	}
+
+	job, err := jd.Get(id)
+	if err != nil {
+		fmt.Printf("继续下载任务 %s 失败: %s\n", id, err)
+		return
+	}
+
+	activeUser := GetActiveUser()
+	if job.UID != activeUser.UID {
+		fmt.Printf("下载任务 %s 属于其他帐号 (uid: %d), 请先切换帐号\n", id, job.UID)
+		return
+	}
+
+	options := &DownloadOptions{}
+	err = json.Unmarshal(job.Options, options)
+	if err != nil {
+		fmt.Printf("解析下载任务 %s 的下载选项失败: %s\n", id, err)
+		return
+	}
+	options = initDownloadOptions(options)
+
+	// 设置了加密口令时, 自动解密加密的文件和文件名
+	cipher, err := pcsconfig.Config.Cipher()
+	if err != nil && err != pcsconfig.ErrEncryptPassphraseNotSet {
+		fmt.Printf("初始化解密错误: %s\n", err)
+		return
+	}
+
+	job.ResetFailed()
+
+	var (
+		pcs       = GetBaiduPCS()
+		cfg       = newDownloaderConfig(options.IsTest)
+		loadCount = 0
+		files     = make([]*pcsdownload.DownloadJobFile, 0, len(job.Files))
+	)
+	for _, file := range job.Files {
+		if file.Status == pcsdownload.JobFileSucceeded {
+			continue
+		}
+		files = append(files, file)
+		if !file.Isdir && loadCount < options.Load {
+			loadCount++
+		}
+	}
+
+	fmt.Print("\n")
+	fmt.Printf("[0] 提示: 继续下载任务 %s, 剩余文件数: %d, 当前下载最大并发量为: %d, 下载缓存为: %d\n", job.ID, len(files), options.Parallel, cfg.CacheSize)
+
+	if loadCount > 0 {
+		options.Load = loadCount
+		cfg.MaxParallel = pcsconfig.AverageParallel(options.Parallel, loadCount)
+	} else {
+		cfg.MaxParallel = options.Parallel
+	}
+
+	var (
+		executor = taskframework.TaskExecutor{
+			IsFailedDeque: true, // 统计失败的列表
+		}
+		statistic = &pcsdownload.DownloadStatistic{}
+	)
+	executor.SetParallel(loadCount)
+	for _, file := range files {
+		fd := &baidupcs.FileDirectory{
+			Path:     file.Path,
+			Filename: path.Base(file.Path),
+			Size:     file.Size,
+			Isdir:    file.Isdir,
+		}
+		unit := newDownloadTaskUnit(pcs, cfg, fd, options, cipher)
+		unit.SavePath = file.SavePath
+		unit.FileInfo = nil // 重新获取文件信息
+		unit.PrintFormat = downloadPrintFormat(options.Load)
+		unit.ParentTaskExecutor = &executor
+		unit.DownloadStatistic = statistic
+		unit.Job, unit.JobFile = job, file
+		info := executor.Append(unit, options.MaxRetry)
+		fmt.Printf("[%s] 加入下载队列: %s\n", info.Id(), file.Path)
+	}
+
+	executeDownload(&executor, statistic, jd, job)
+}
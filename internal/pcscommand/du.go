@@ -0,0 +1,207 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultDuParallel du 默认的并发请求数
+	DefaultDuParallel = 8
+)
+
+type (
+	// DuOptions du 可选参数
+	DuOptions struct {
+		Depth    int    // 输出的最大深度, 起始目录为0
+		Human    bool   // 以易读的单位输出大小
+		All      bool   // 同时输出文件
+		Sort     string // 排序方式, size, name, count
+		Ext      bool   // 按扩展名统计
+		JSON     bool   // 以 JSON 格式输出
+		Parallel int    // 并发请求数
+	}
+
+	// duEntry 文件或目录的统计
+	duEntry struct {
+		Path  string `json:"path"`
+		Isdir bool   `json:"isdir"`
+		Size  int64  `json:"size"`
+		Files int64  `json:"files"`
+		Dirs  int64  `json:"dirs"`
+	}
+
+	// duExt 扩展名的统计
+	duExt struct {
+		Ext   string `json:"ext"`
+		Files int64  `json:"files"`
+		Size  int64  `json:"size"`
+	}
+
+	// duResult du 的统计结果
+	duResult struct {
+		duEntry
+		Entries    []*duEntry `json:"entries"`
+		Extensions []*duExt   `json:"extensions,omitempty"`
+		Errors     []string   `json:"errors,omitempty"`
+	}
+)
+
+// newDuEntry 统计文件或目录
+func newDuEntry(fd *baidupcs.FileDirectory) *duEntry {
+	entry := &duEntry{
+		Path:  fd.Path,
+		Isdir: fd.Isdir,
+		Size:  fd.Size,
+	}
+	if fd.Isdir {
+		entry.Size = fd.Children.TotalSize()
+		entry.Files, entry.Dirs = fd.Children.Count()
+	} else {
+		entry.Files = 1
+	}
+	return entry
+}
+
+// RunDu 执行统计网盘目录占用的空间
+func RunDu(pcspath string, opt *DuOptions) {
+	if opt == nil {
+		opt = &DuOptions{Depth: 1}
+	}
+	if opt.Parallel < 1 {
+		opt.Parallel = DefaultDuParallel
+	}
+
+	err := matchPathByShellPatternOnce(&pcspath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	result := &duResult{}
+	root, pcsError := GetBaiduPCS().FilesDirectoriesConcurrentRecurseList(pcspath, baidupcs.DefaultOrderOptions, opt.Parallel, func(fdPath string, pcsError pcserror.Error) bool {
+		result.Errors = append(result.Errors, fdPath+": "+pcsError.Error())
+		return true
+	})
+	if pcsError != nil {
+		fmt.Println(pcsError)
+		return
+	}
+
+	result.duEntry = *newDuEntry(root)
+
+	// 按深度收集
+	var (
+		exts    = map[string]*duExt{}
+		collect func(fd *baidupcs.FileDirectory, depth int)
+	)
+	collect = func(fd *baidupcs.FileDirectory, depth int) {
+		for _, sub := range fd.Children {
+			if opt.Ext && !sub.Isdir {
+				ext := strings.ToLower(path.Ext(sub.Filename))
+				if ext == "" {
+					ext = "(无)"
+				}
+				e, ok := exts[ext]
+				if !ok {
+					e = &duExt{Ext: ext}
+					exts[ext] = e
+				}
+				e.Files++
+				e.Size += sub.Size
+			}
+			if depth <= opt.Depth && (sub.Isdir || opt.All) {
+				result.Entries = append(result.Entries, newDuEntry(sub))
+			}
+			if sub.Isdir {
+				collect(sub, depth+1)
+			}
+		}
+	}
+	collect(root, 1)
+
+	switch opt.Sort {
+	case "name":
+		sort.Slice(result.Entries, func(i, j int) bool {
+			return result.Entries[i].Path < result.Entries[j].Path
+		})
+	case "count":
+		sort.SliceStable(result.Entries, func(i, j int) bool {
+			return result.Entries[i].Files > result.Entries[j].Files
+		})
+	default:
+		sort.SliceStable(result.Entries, func(i, j int) bool {
+			return result.Entries[i].Size > result.Entries[j].Size
+		})
+	}
+
+	for _, e := range exts {
+		result.Extensions = append(result.Extensions, e)
+	}
+	sort.Slice(result.Extensions, func(i, j int) bool {
+		if result.Extensions[i].Size == result.Extensions[j].Size {
+			return result.Extensions[i].Ext < result.Extensions[j].Ext
+		}
+		return result.Extensions[i].Size > result.Extensions[j].Size
+	})
+
+	if opt.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		return
+	}
+
+	var (
+		formatSize = func(size int64) string {
+			if opt.Human {
+				return converter.ConvertFileSize(size, 2)
+			}
+			return strconv.FormatInt(size, 10)
+		}
+		percent = func(size int64) string {
+			if result.Size <= 0 {
+				return "-"
+			}
+			return strconv.FormatFloat(100*float64(size)/float64(result.Size), 'f', 2, 64) + "%"
+		}
+	)
+
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "大小", "占比", "文件数", "目录数", "路径"})
+	for k, e := range result.Entries {
+		p := e.Path
+		if e.Isdir {
+			p += baidupcs.PathSeparator
+		}
+		tb.Append([]string{strconv.Itoa(k), formatSize(e.Size), percent(e.Size), strconv.FormatInt(e.Files, 10), strconv.FormatInt(e.Dirs, 10), p})
+	}
+	tb.Append([]string{"", formatSize(result.Size), percent(result.Size), strconv.FormatInt(result.Files, 10), strconv.FormatInt(result.Dirs, 10), "总计: " + result.Path})
+	tb.Render()
+
+	if opt.Ext {
+		fmt.Println()
+		tb = pcstable.NewTable(os.Stdout)
+		tb.SetHeader([]string{"#", "扩展名", "大小", "占比", "文件数"})
+		for k, e := range result.Extensions {
+			tb.Append([]string{strconv.Itoa(k), e.Ext, formatSize(e.Size), percent(e.Size), strconv.FormatInt(e.Files, 10)})
+		}
+		tb.Render()
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Printf("\n以下目录获取失败, 统计结果不完整:\n")
+		for _, e := range result.Errors {
+			fmt.Println(e)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsfind"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type (
+	// FindOptions find 可选参数
+	FindOptions struct {
+		Yes     bool // 删除时不需要确认
+		Offline bool // 从离线索引查找
+	}
+
+	// findJSON -json 输出的文件信息
+	findJSON struct {
+		FsID     int64  `json:"fs_id"`
+		Path     string `json:"path"`
+		Filename string `json:"filename"`
+		Isdir    bool   `json:"isdir"`
+		Size     int64  `json:"size"`
+		MD5      string `json:"md5,omitempty"`
+		Ctime    int64  `json:"ctime"`
+		Mtime    int64  `json:"mtime"`
+	}
+)
+
+// RunFind 执行查找网盘文件, args 为起始目录和表达式
+func RunFind(args []string, opt *FindOptions) {
+	if opt == nil {
+		opt = &FindOptions{}
+	}
+
+	dirs, expr, err := pcsfind.Parse(args, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "解析表达式失败: %s\n", err)
+		return
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	lister, err := newFileLister(opt.Offline)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	var matched []string
+	for _, dir := range dirs {
+		paths, err := lister.MatchPathByShellPattern(GetActiveUser().PathJoin(dir))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		matched = append(matched, paths...)
+	}
+
+	var (
+		encoder  = json.NewEncoder(os.Stdout)
+		toDelete []string
+		do       = func(action pcsfind.Action, fd *baidupcs.FileDirectory) {
+			switch action {
+			case pcsfind.ActionPrint:
+				fmt.Println(fd.Path)
+			case pcsfind.ActionPrint0:
+				fmt.Print(fd.Path + "\x00")
+			case pcsfind.ActionJSON:
+				encoder.Encode(&findJSON{
+					FsID:     fd.FsID,
+					Path:     fd.Path,
+					Filename: fd.Filename,
+					Isdir:    fd.Isdir,
+					Size:     fd.Size,
+					MD5:      fd.MD5,
+					Ctime:    fd.Ctime,
+					Mtime:    fd.Mtime,
+				})
+			case pcsfind.ActionDelete:
+				toDelete = append(toDelete, fd.Path)
+			}
+		}
+	)
+
+	for _, dir := range matched {
+		fd, err := lister.FilesDirectoriesMeta(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", dir, err)
+			continue
+		}
+		findWalk(lister, fd, 0, expr, do)
+	}
+
+	if len(toDelete) > 0 {
+		findRemove(GetBaiduPCS(), toDelete, opt.Yes)
+	}
+}
+
+// findWalk 遍历目录, 对每个文件和目录求值
+func findWalk(lister fileLister, fd *baidupcs.FileDirectory, depth int, expr *pcsfind.Expression, do pcsfind.ActionFunc) {
+	expr.Eval(fd, depth, do)
+	if !fd.Isdir || !expr.Descend(depth) {
+		return
+	}
+
+	fdl, err := lister.FilesDirectoriesList(fd.Path, baidupcs.DefaultOrderOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fd.Path, err)
+		return
+	}
+	for _, sub := range fdl {
+		findWalk(lister, sub, depth+1, expr, do)
+	}
+}
+
+// findRemove 删除 -delete 匹配的文件和目录, 已被删除的目录下的文件不再重复删除
+func findRemove(pcs *baidupcs.BaiduPCS, paths []string, yes bool) {
+	// 上级目录总是排在下级之前, 已选中的目录之下的路径不再删除
+	sort.Strings(paths)
+	var (
+		selected = make(map[string]bool, len(paths))
+		removes  = make([]string, 0, len(paths))
+	)
+	for _, p := range paths {
+		p = path.Clean(p)
+		if selected[p] || pcsutil.IsUnderDirs(p, selected) {
+			continue
+		}
+		selected[p] = true
+		removes = append(removes, p)
+	}
+
+	tb := pcstable.NewTable(os.Stderr)
+	tb.SetHeader([]string{"#", "文件/目录"})
+	for k := range removes {
+		tb.Append([]string{strconv.Itoa(k), removes[k]})
+	}
+	tb.Render()
+
+	if !yes {
+		var confirm string
+		fmt.Fprintf(os.Stderr, "确认删除以上 %d 个文件/目录 ? (y/n) > ", len(removes))
+		_, err := fmt.Scanln(&confirm)
+		if err != nil || (confirm != "y" && confirm != "Y") {
+			fmt.Fprintln(os.Stderr, "已取消删除")
+			return
+		}
+	}
+
+	removed := len(removes) - len(removeInBatches(pcs, removes, os.Stderr))
+	fmt.Fprintf(os.Stderr, "已删除 %d 个文件/目录, 可在网盘文件回收站找回\n", removed)
+}
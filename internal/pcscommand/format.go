@@ -0,0 +1,183 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/pcsoutput"
+	"fmt"
+	"time"
+)
+
+type (
+	// fileRecord 文件/目录的机器可读输出
+	fileRecord struct {
+		FsID     int64  `json:"fs_id"`
+		AppID    int64  `json:"app_id"`
+		Path     string `json:"path"`
+		Filename string `json:"filename"`
+		Isdir    bool   `json:"isdir"`
+		Size     int64  `json:"size"`
+		MD5      string `json:"md5"`
+		Ctime    int64  `json:"ctime"`
+		Mtime    int64  `json:"mtime"`
+	}
+
+	// treeRecord 树形图的机器可读输出, depth 从 0 开始
+	treeRecord struct {
+		Depth int `json:"depth"`
+		fileRecord
+	}
+
+	// shareRecord 分享记录的机器可读输出, expire_time 为 0 表示永久有效
+	shareRecord struct {
+		ShareID     int64   `json:"share_id"`
+		Shortlink   string  `json:"shortlink"`
+		Passwd      string  `json:"passwd"`
+		Public      bool    `json:"public"`
+		TypicalPath string  `json:"typical_path"`
+		Expired     bool    `json:"expired"`
+		ExpireTime  int64   `json:"expire_time"`
+		ViewCount   int     `json:"view_count"`
+		FsIDs       []int64 `json:"fs_ids"`
+	}
+
+	// recycleRecord 回收站文件的机器可读输出, mtime 即删除时间
+	recycleRecord struct {
+		FsID     int64  `json:"fs_id"`
+		Path     string `json:"path"`
+		Filename string `json:"filename"`
+		Isdir    bool   `json:"isdir"`
+		Size     int64  `json:"size"`
+		MD5      string `json:"md5"`
+		Ctime    int64  `json:"ctime"`
+		Mtime    int64  `json:"mtime"`
+		LeftTime int    `json:"left_time"`
+	}
+
+	// cloudDlRecord 离线下载任务的机器可读输出
+	cloudDlRecord struct {
+		TaskID       int64                       `json:"task_id"`
+		TaskName     string                      `json:"task_name"`
+		Status       int                         `json:"status"`
+		StatusText   string                      `json:"status_text"`
+		FileSize     int64                       `json:"file_size"`
+		FinishedSize int64                       `json:"finished_size"`
+		CreateTime   int64                       `json:"create_time"`
+		StartTime    int64                       `json:"start_time"`
+		FinishTime   int64                       `json:"finish_time"`
+		SavePath     string                      `json:"save_path"`
+		SourceURL    string                      `json:"source_url"`
+		FileList     []*baidupcs.CloudDlFileInfo `json:"file_list"`
+	}
+
+	// quotaRecord 空间配额的机器可读输出
+	quotaRecord struct {
+		UID      uint64 `json:"uid"`
+		Username string `json:"username"`
+		Quota    int64  `json:"quota"`
+		Used     int64  `json:"used"`
+	}
+)
+
+func newFileRecord(fd *baidupcs.FileDirectory) fileRecord {
+	return fileRecord{
+		FsID:     fd.FsID,
+		AppID:    fd.AppID,
+		Path:     fd.Path,
+		Filename: fd.Filename,
+		Isdir:    fd.Isdir,
+		Size:     fd.Size,
+		MD5:      fd.MD5,
+		Ctime:    fd.Ctime,
+		Mtime:    fd.Mtime,
+	}
+}
+
+func newFileRecords(fdl baidupcs.FileDirectoryList) []fileRecord {
+	records := make([]fileRecord, 0, len(fdl))
+	for _, fd := range fdl {
+		if fd == nil {
+			continue
+		}
+		records = append(records, newFileRecord(fd))
+	}
+	return records
+}
+
+func newShareRecord(record *baidupcs.ShareRecordInfo, now time.Time) shareRecord {
+	sr := shareRecord{
+		ShareID:     record.ShareID,
+		Shortlink:   record.Shortlink,
+		Passwd:      record.Passwd,
+		Public:      record.Public != 0,
+		TypicalPath: record.TypicalPath,
+		Expired:     record.ExpireType == -1,
+		ViewCount:   record.ViewCount,
+		FsIDs:       record.FsIds,
+	}
+	if !sr.Expired && record.ExpireTime != 0 {
+		sr.ExpireTime = now.Unix() + record.ExpireTime
+	}
+	return sr
+}
+
+func newRecycleRecords(fdl baidupcs.RecycleFDInfoList) []recycleRecord {
+	records := make([]recycleRecord, 0, len(fdl))
+	for _, info := range fdl {
+		if info == nil {
+			continue
+		}
+		records = append(records, recycleRecord{
+			FsID:     info.FsID,
+			Path:     info.Path,
+			Filename: info.Filename,
+			Isdir:    info.Isdir == 1,
+			Size:     info.Size,
+			MD5:      info.MD5,
+			Ctime:    info.Ctime,
+			Mtime:    info.Mtime,
+			LeftTime: info.LeftTime,
+		})
+	}
+	return records
+}
+
+func newCloudDlRecords(cl baidupcs.CloudDlTaskList) []cloudDlRecord {
+	records := make([]cloudDlRecord, 0, len(cl))
+	for _, task := range cl {
+		if task == nil {
+			continue
+		}
+		records = append(records, cloudDlRecord{
+			TaskID:       task.TaskID,
+			TaskName:     task.TaskName,
+			Status:       task.Status,
+			StatusText:   task.StatusText,
+			FileSize:     task.FileSize,
+			FinishedSize: task.FinishedSize,
+			CreateTime:   task.CreateTime,
+			StartTime:    task.StartTime,
+			FinishTime:   task.FinishTime,
+			SavePath:     task.SavePath,
+			SourceURL:    task.SourceURL,
+			FileList:     task.FileList,
+		})
+	}
+	return records
+}
+
+// printRecords 按 --format 指定的格式输出
+func printRecords(command string, v interface{}) {
+	err := pcsoutput.Print(v)
+	if err != nil {
+		pcsoutput.PrintError(command, err)
+	}
+}
+
+// printCommandError 输出错误, 使用机器可读的输出格式时以 JSON 对象输出到标准错误
+func printCommandError(command string, err error) {
+	if pcsoutput.IsStructured() {
+		pcsoutput.PrintError(command, err)
+		return
+	}
+	fmt.Println(err)
+}
@@ -0,0 +1,271 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsindex"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// indexDiffRetry 获取文件变更失败时的重试次数
+	indexDiffRetry = 3
+)
+
+var (
+	// ErrIndexNotBuilt 未建立离线索引
+	ErrIndexNotBuilt = errors.New("未建立离线索引, 请先运行 index update")
+
+	indexCache struct {
+		sync.Mutex
+		filename string
+		modTime  time.Time
+		idx      *pcsindex.Index
+	}
+)
+
+type (
+	// IndexUpdateOptions 更新离线索引可选参数
+	IndexUpdateOptions struct {
+		Relist bool // 不使用文件变更, 重新列出修改日期有变化的目录
+		Full   bool // 重新列出全部目录
+	}
+
+	// fileLister 获取文件信息和目录列表, 数据来自网盘或离线索引
+	fileLister interface {
+		FilesDirectoriesMeta(pcspath string) (*baidupcs.FileDirectory, error)
+		FilesDirectoriesList(pcspath string, options *baidupcs.OrderOptions) (baidupcs.FileDirectoryList, error)
+		Search(targetPath, keyword string, recursive bool) (baidupcs.FileDirectoryList, error)
+		MatchPathByShellPattern(pattern string) ([]string, error)
+	}
+
+	// pcsFileLister 从网盘获取
+	pcsFileLister struct {
+		pcs *baidupcs.BaiduPCS
+	}
+)
+
+// indexFilePath 离线索引文件的路径, 每个帐号一个文件
+func indexFilePath(uid uint64) string {
+	return filepath.Join(pcsconfig.GetConfigDir(), "pcs_index_"+strconv.FormatUint(uid, 10)+".json.gz")
+}
+
+// loadIndex 读取当前帐号的离线索引, 索引文件未修改时使用已读取的索引
+func loadIndex() (*pcsindex.Index, error) {
+	filename := indexFilePath(GetActiveUser().UID)
+	info, err := os.Stat(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrIndexNotBuilt
+		}
+		return nil, err
+	}
+
+	indexCache.Lock()
+	defer indexCache.Unlock()
+	if indexCache.idx != nil && indexCache.filename == filename && indexCache.modTime.Equal(info.ModTime()) {
+		return indexCache.idx, nil
+	}
+
+	idx, err := pcsindex.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取离线索引错误: %s", err)
+	}
+	indexCache.filename, indexCache.modTime, indexCache.idx = filename, info.ModTime(), idx
+	return idx, nil
+}
+
+// newFileLister offline 为 true 时从离线索引获取, 否则从网盘获取
+func newFileLister(offline bool) (fileLister, error) {
+	if !offline {
+		return &pcsFileLister{pcs: GetBaiduPCS()}, nil
+	}
+	idx, err := loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (pl *pcsFileLister) FilesDirectoriesMeta(pcspath string) (*baidupcs.FileDirectory, error) {
+	fd, pcsError := pl.pcs.FilesDirectoriesMeta(pcspath)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	return fd, nil
+}
+
+func (pl *pcsFileLister) FilesDirectoriesList(pcspath string, options *baidupcs.OrderOptions) (baidupcs.FileDirectoryList, error) {
+	fdl, pcsError := pl.pcs.FilesDirectoriesList(pcspath, options)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	return fdl, nil
+}
+
+func (pl *pcsFileLister) Search(targetPath, keyword string, recursive bool) (baidupcs.FileDirectoryList, error) {
+	fdl, pcsError := pl.pcs.Search(targetPath, keyword, recursive)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	return fdl, nil
+}
+
+func (pl *pcsFileLister) MatchPathByShellPattern(pattern string) ([]string, error) {
+	paths, pcsError := pl.pcs.MatchPathByShellPattern(pattern)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	return paths, nil
+}
+
+// matchListerPathOnce 使用 lister 通配符匹配路径, 只允许匹配到一个路径
+func matchListerPathOnce(lister fileLister, pattern *string) error {
+	paths, err := lister.MatchPathByShellPattern(GetActiveUser().PathJoin(*pattern))
+	if err != nil {
+		return err
+	}
+	switch len(paths) {
+	case 0:
+		return ErrShellPatternNoHit
+	case 1:
+		*pattern = paths[0]
+	default:
+		return ErrShellPatternMultiRes
+	}
+	return nil
+}
+
+// CacheFilesDirectoriesList 获取目录列表, 用于 tab 补全.
+// 设置了 completion_index 且已建立离线索引时从索引获取, 不访问网络
+func CacheFilesDirectoriesList(pcspath string) (baidupcs.FileDirectoryList, error) {
+	if pcsconfig.Config.CompletionIndex {
+		idx, err := loadIndex()
+		if err == nil {
+			return idx.FilesDirectoriesList(pcspath, baidupcs.DefaultOrderOptions)
+		}
+	}
+
+	fdl, pcsError := pcsconfig.Config.ActiveUserBaiduPCS().CacheFilesDirectoriesList(pcspath, baidupcs.DefaultOrderOptions)
+	if pcsError != nil {
+		return nil, pcsError
+	}
+	return fdl, nil
+}
+
+// RunIndexUpdate 执行建立或更新离线索引
+func RunIndexUpdate(opt *IndexUpdateOptions) {
+	if opt == nil {
+		opt = &IndexUpdateOptions{}
+	}
+
+	au := GetActiveUser()
+	idx, err := loadIndex()
+	switch err {
+	case nil:
+	case ErrIndexNotBuilt:
+		idx = pcsindex.New(au.UID)
+		fmt.Println("正在建立离线索引, 文件较多时需要较长时间...")
+	default:
+		fmt.Printf("%s, 重新建立离线索引\n", err)
+		idx = pcsindex.New(au.UID)
+	}
+
+	var (
+		result                   *pcsindex.UpdateResult
+		added, modified, deleted int
+	)
+	for retry := 0; ; retry++ {
+		result = idx.Update(GetBaiduPCS(), opt.Relist, opt.Full, func(dir string, err error) {
+			fmt.Printf("获取目录列表失败, 跳过: %s, %s\n", dir, err)
+		})
+		added += result.Added
+		modified += result.Modified
+		deleted += result.Deleted
+		if result.DiffErr == nil || result.Relist || retry >= indexDiffRetry {
+			break
+		}
+		fmt.Printf("获取文件变更失败, %d秒后重试: %s\n", retry+1, result.DiffErr)
+		time.Sleep(time.Duration(retry+1) * time.Second)
+	}
+	switch {
+	case result.DiffErr == nil:
+	case result.Relist:
+		fmt.Printf("获取文件变更失败, 已改为重新列目录: %s\n", result.DiffErr)
+	default:
+		// 保留已获取的变更和 cursor, 下次更新时继续
+		fmt.Printf("获取文件变更失败, 请稍后重试, 或使用 --relist 重新列目录: %s\n", result.DiffErr)
+	}
+
+	err = idx.Save(indexFilePath(au.UID))
+	if err != nil {
+		fmt.Printf("保存离线索引错误: %s\n", err)
+		return
+	}
+
+	if result.Relist {
+		fmt.Printf("已通过重新列目录更新离线索引, 共列出 %d 个目录\n", result.Listed)
+	}
+	files, dirs, _ := idx.Count()
+	fmt.Printf("新增: %d, 修改: %d, 删除: %d, 文件总数: %d, 目录总数: %d\n", added, modified, deleted, files, dirs)
+}
+
+// RunIndexStatus 执行显示离线索引的状态
+func RunIndexStatus() {
+	filename := indexFilePath(GetActiveUser().UID)
+	idx, err := loadIndex()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var fileSize int64
+	if info, err := os.Stat(filename); err == nil {
+		fileSize = info.Size()
+	}
+
+	mode := "文件变更 (cursor)"
+	if idx.Cursor == "" {
+		mode = "重新列目录"
+	}
+
+	files, dirs, size := idx.Count()
+	tb := pcstable.NewTable(os.Stdout)
+	tb.AppendBulk([][]string{
+		{"文件总数", strconv.FormatInt(files, 10)},
+		{"目录总数", strconv.FormatInt(dirs, 10)},
+		{"文件总大小", converter.ConvertFileSize(size, 2)},
+		{"更新时间", pcstime.FormatTime(idx.UpdateTime)},
+		{"更新方式", mode},
+		{"索引文件", filename + " (" + converter.ConvertFileSize(fileSize, 2) + ")"},
+	})
+	tb.Render()
+}
+
+// RunIndexClear 执行删除离线索引
+func RunIndexClear() {
+	filename := indexFilePath(GetActiveUser().UID)
+	err := os.Remove(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println(ErrIndexNotBuilt)
+			return
+		}
+		fmt.Printf("删除离线索引错误: %s\n", err)
+		return
+	}
+
+	indexCache.Lock()
+	indexCache.idx = nil
+	indexCache.Unlock()
+	fmt.Println("已删除离线索引")
+}
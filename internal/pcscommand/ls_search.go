@@ -2,8 +2,11 @@ package pcscommand
 
 import (
 	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/pcsoutput"
 	"BaiduPCS-Go/pcstable"
 	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/pcsutil/pcstime"
 	"fmt"
 	"os"
@@ -15,13 +18,16 @@ import (
 type (
 	// LsOptions 列目录可选项
 	LsOptions struct {
-		Total bool
+		Total   bool
+		Decrypt bool // 显示解密后的文件名
+		Offline bool // 从离线索引获取
 	}
 
 	// SearchOptions 搜索可选项
 	SearchOptions struct {
 		Total   bool
 		Recurse bool
+		Offline bool // 从离线索引搜索
 	}
 )
 
@@ -32,43 +38,85 @@ const (
 
 // RunLs 执行列目录
 func RunLs(pcspath string, lsOptions *LsOptions, orderOptions *baidupcs.OrderOptions) {
-	err := matchPathByShellPatternOnce(&pcspath)
+	if lsOptions == nil {
+		lsOptions = &LsOptions{}
+	}
+
+	lister, err := newFileLister(lsOptions.Offline)
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("ls", err)
 		return
 	}
 
-	files, err := GetBaiduPCS().FilesDirectoriesList(pcspath, orderOptions)
+	err = matchListerPathOnce(lister, &pcspath)
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("ls", err)
 		return
 	}
 
-	fmt.Printf("\n当前目录: %s\n----\n", pcspath)
+	files, err := lister.FilesDirectoriesList(pcspath, orderOptions)
+	if err != nil {
+		printCommandError("ls", err)
+		return
+	}
 
-	if lsOptions == nil {
-		lsOptions = &LsOptions{}
+	if lsOptions.Decrypt {
+		cipher, err := pcsconfig.Config.Cipher()
+		if err != nil {
+			printCommandError("ls", fmt.Errorf("初始化解密错误: %s", err))
+			return
+		}
+		for _, file := range files {
+			name, encrypted := cipher.DecryptFileName(file.Filename)
+			if !encrypted {
+				continue
+			}
+			file.Filename = name
+			if !file.Isdir {
+				// 显示解密后的大小
+				if size, err := e2ee.PlainSize(file.Size); err == nil {
+					file.Size = size
+				}
+			}
+		}
 	}
 
+	if pcsoutput.IsStructured() {
+		printRecords("ls", newFileRecords(files))
+		return
+	}
+
+	fmt.Printf("\n当前目录: %s\n----\n", pcspath)
 	renderTable(opLs, lsOptions.Total, pcspath, files)
 	return
 }
 
 // RunSearch 执行搜索
 func RunSearch(targetPath, keyword string, opt *SearchOptions) {
-	err := matchPathByShellPatternOnce(&targetPath)
+	if opt == nil {
+		opt = &SearchOptions{}
+	}
+
+	lister, err := newFileLister(opt.Offline)
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("search", err)
 		return
 	}
 
-	if opt == nil {
-		opt = &SearchOptions{}
+	err = matchListerPathOnce(lister, &targetPath)
+	if err != nil {
+		printCommandError("search", err)
+		return
 	}
 
-	files, err := GetBaiduPCS().Search(targetPath, keyword, opt.Recurse)
+	files, err := lister.Search(targetPath, keyword, opt.Recurse)
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("search", err)
+		return
+	}
+
+	if pcsoutput.IsStructured() {
+		printRecords("search", newFileRecords(files))
 		return
 	}
 
@@ -1,6 +1,7 @@
 package pcscommand
 
 import (
+	"BaiduPCS-Go/pcsoutput"
 	"fmt"
 )
 
@@ -8,7 +9,21 @@ import (
 func RunGetMeta(targetPaths ...string) {
 	targetPaths, err := matchPathByShellPattern(targetPaths...)
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("meta", err)
+		return
+	}
+
+	if pcsoutput.IsStructured() {
+		records := make([]fileRecord, 0, len(targetPaths))
+		for _, targetPath := range targetPaths {
+			data, err := GetBaiduPCS().FilesDirectoriesMeta(targetPath)
+			if err != nil {
+				pcsoutput.PrintError("meta", err)
+				continue
+			}
+			records = append(records, newFileRecord(data))
+		}
+		printRecords("meta", records)
 		return
 	}
 
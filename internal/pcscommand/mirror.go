@@ -0,0 +1,230 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcssync"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"BaiduPCS-Go/requester/downloader"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type (
+	// MirrorOptions 镜像可选项
+	MirrorOptions struct {
+		Parallel     int
+		Load         int
+		MaxRetry     int
+		NoCheck      bool
+		DownloadMode pcsdownload.DownloadMode
+		DryRun       bool // 只输出镜像计划
+		CheckMD5     bool // 大小一致时比较md5
+		Delete       bool // 删除本地中网盘不存在的文件
+	}
+)
+
+const (
+	// mirrorTempSuffix 镜像下载时的临时文件后缀, 下载成功后重命名为目标文件
+	mirrorTempSuffix = ".BaiduPCS-Go-mirror"
+)
+
+type (
+	// mirrorDownloadTaskUnit 镜像使用的下载任务单元, 先下载到临时文件, 成功后替换本地已存在的文件
+	mirrorDownloadTaskUnit struct {
+		*pcsdownload.DownloadTaskUnit
+		targetPath string // 最终保存的路径
+	}
+)
+
+// newMirrorDownloadTaskUnit 镜像使用的下载任务单元, 覆盖本地已存在的文件
+func newMirrorDownloadTaskUnit(pcs *baidupcs.BaiduPCS, executor *taskframework.TaskExecutor, cfg *downloader.Config, statistic *pcsdownload.DownloadStatistic, fd *baidupcs.FileDirectory, savePath string, load int, opt *MirrorOptions) *mirrorDownloadTaskUnit {
+	tempPath := savePath + mirrorTempSuffix
+	// 没有下载状态的临时文件无法续传, 删除以免残留数据
+	if _, err := os.Stat(tempPath + pcsdownload.DownloadSuffix); err != nil {
+		os.Remove(tempPath)
+	}
+
+	newCfg := *cfg
+	return &mirrorDownloadTaskUnit{
+		DownloadTaskUnit: &pcsdownload.DownloadTaskUnit{
+			Cfg:                &newCfg, // 复制一份新的cfg
+			PCS:                pcs,
+			VerbosePrinter:     pcsCommandVerbose,
+			PrintFormat:        downloadPrintFormat(load),
+			ParentTaskExecutor: executor,
+			DownloadStatistic:  statistic,
+			IsOverwrite:        true,
+			NoCheck:            opt.NoCheck,
+			DownloadMode:       opt.DownloadMode,
+			ModifyMTime:        true, // 保持与网盘一致, 供下次比较
+			PcsPath:            fd.Path,
+			SavePath:           tempPath,
+			FileInfo:           fd,
+		},
+		targetPath: savePath,
+	}
+}
+
+// Run 下载到临时文件, 成功后重命名为目标文件, 失败时保留本地原有的文件
+func (mu *mirrorDownloadTaskUnit) Run() (result *taskframework.TaskUnitRunResult) {
+	result = mu.DownloadTaskUnit.Run()
+	if !result.Succeed || mu.Cfg.IsTest || mu.FileInfo == nil || mu.FileInfo.Isdir {
+		return
+	}
+
+	err := os.Rename(mu.SavePath, mu.targetPath)
+	if err != nil {
+		result.Succeed = false
+		result.ResultMessage = "替换本地文件失败"
+		result.Err = err
+	}
+	return
+}
+
+// RunMirror 执行将网盘目录镜像到本地目录, 只下载新增或发生改变的文件
+func RunMirror(pcsDir, localDir string, opt *MirrorOptions) {
+	if opt == nil {
+		opt = &MirrorOptions{}
+	}
+	if opt.Load <= 0 {
+		opt.Load = pcsconfig.Config.MaxDownloadLoad
+	}
+	if opt.MaxRetry < 0 {
+		opt.MaxRetry = pcsdownload.DefaultDownloadMaxRetry
+	}
+	if opt.Parallel < 1 {
+		opt.Parallel = pcsconfig.Config.MaxParallel
+	}
+	if !opt.NoCheck {
+		opt.NoCheck = pcsconfig.Config.NoCheck
+	}
+
+	err := matchPathByShellPatternOnce(&pcsDir)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+
+	var (
+		pcs = GetBaiduPCS()
+	)
+
+	fmt.Printf("正在比较网盘目录 %s 和本地目录 %s, 请稍候...\n", pcsDir, localDir)
+	remote, fds, err := listRemoteEntries(pcs, pcsDir)
+	if err != nil {
+		fmt.Printf("获取网盘目录错误: %s\n", err)
+		return
+	}
+
+	local := pcssync.FileEntries{}
+	if _, err = os.Stat(localDir); err == nil {
+		local, err = listLocalEntries(localDir)
+		if err != nil {
+			fmt.Printf("遍历本地目录错误: %s\n", err)
+			return
+		}
+	}
+
+	plan, err := pcssync.PlanDownload(local, remote, &pcssync.CompareOptions{
+		CheckMD5: opt.CheckMD5,
+		Delete:   opt.Delete,
+		LocalMD5: func(entry *pcssync.FileEntry) (string, error) {
+			return localFileMD5(filepath.Join(localDir, filepath.FromSlash(entry.Path)))
+		},
+	})
+	if err != nil {
+		fmt.Printf("比较文件错误: %s\n", err)
+		return
+	}
+
+	if len(plan) == 0 {
+		fmt.Printf("本地目录和网盘目录一致, 无需更新.\n")
+		return
+	}
+
+	if opt.DryRun {
+		printSyncPlan(plan)
+		fmt.Printf("下载: %d, 更新: %d, 删除: %d, 待下载总大小: %s\n",
+			plan.Count(pcssync.ActionDownload), plan.Count(pcssync.ActionDownloadOverwrite), plan.Count(pcssync.ActionDeleteLocal),
+			converter.ConvertFileSize(plan.TotalSize(pcssync.ActionDownload)+plan.TotalSize(pcssync.ActionDownloadOverwrite), 2))
+		return
+	}
+
+	var (
+		cfg      = newDownloaderConfig(false)
+		executor = taskframework.TaskExecutor{
+			IsFailedDeque: true, // 统计失败的列表
+		}
+		statistic   = &pcsdownload.DownloadStatistic{}
+		downloads   pcssync.Plan
+		deletePaths []string
+	)
+
+	for _, action := range plan {
+		switch action.Type {
+		case pcssync.ActionDownload, pcssync.ActionDownloadOverwrite:
+			downloads = append(downloads, action)
+		case pcssync.ActionDeleteLocal:
+			deletePaths = append(deletePaths, filepath.Join(localDir, filepath.FromSlash(action.Path)))
+		}
+	}
+
+	load := opt.Load
+	if len(downloads) < load {
+		load = len(downloads)
+	}
+	if load > 0 {
+		cfg.MaxParallel = pcsconfig.AverageParallel(opt.Parallel, load)
+	}
+
+	// 小文件优先下载
+	sort.SliceStable(downloads, func(i, j int) bool {
+		return downloads[i].Remote.Size < downloads[j].Remote.Size
+	})
+	for _, action := range downloads {
+		savePath := filepath.Join(localDir, filepath.FromSlash(action.Path))
+		fd := fds[action.Path]
+		info := executor.Append(newMirrorDownloadTaskUnit(pcs, &executor, cfg, statistic, fd, savePath, load, opt), opt.MaxRetry)
+		fmt.Printf("[%s] 加入下载队列 (%s): %s\n", info.Id(), action.Type, fd.Path)
+	}
+
+	if executor.Count() > 0 {
+		executor.SetParallel(load)
+		statistic.StartTimer()
+		executor.Execute()
+		fmt.Printf("\n下载结束, 时间: %s, 数据总量: %s\n", statistic.Elapsed()/1e6*1e6, converter.ConvertFileSize(statistic.TotalSize()))
+	}
+
+	var deleteFailed int
+	for _, p := range deletePaths {
+		err = os.RemoveAll(p)
+		if err != nil {
+			fmt.Printf("删除本地文件失败: %s\n", err)
+			deleteFailed++
+			continue
+		}
+		fmt.Printf("删除本地文件: %s\n", p)
+	}
+
+	var downloadFailed int
+	failedList := executor.FailedDeque()
+	if failedList != nil && failedList.Size() != 0 {
+		downloadFailed = failedList.Size()
+		fmt.Printf("以下文件下载失败: \n")
+		tb := pcstable.NewTable(os.Stdout)
+		for e := failedList.Shift(); e != nil; e = failedList.Shift() {
+			item := e.(*taskframework.TaskInfoItem)
+			tb.Append([]string{item.Info.Id(), item.Unit.(*mirrorDownloadTaskUnit).PcsPath})
+		}
+		tb.Render()
+	}
+
+	fmt.Printf("镜像结束, 新增: %d, 更新: %d, 删除: %d, 下载失败: %d, 删除失败: %d\n",
+		plan.Count(pcssync.ActionDownload), plan.Count(pcssync.ActionDownloadOverwrite), len(deletePaths)-deleteFailed, downloadFailed, deleteFailed)
+}
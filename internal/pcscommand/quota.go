@@ -1,6 +1,7 @@
 package pcscommand
 
 import (
+	"BaiduPCS-Go/pcsoutput"
 	"BaiduPCS-Go/pcsutil/converter"
 	"fmt"
 )
@@ -9,7 +10,12 @@ import (
 func RunGetQuota() {
 	quota, used, err := GetBaiduPCS().QuotaInfo()
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("quota", err)
+		return
+	}
+	if pcsoutput.IsStructured() {
+		au := GetActiveUser()
+		printRecords("quota", quotaRecord{UID: au.UID, Username: au.Name, Quota: quota, Used: used})
 		return
 	}
 	fmt.Printf("用户名: %s, 总空间: %s, 已用空间: %s, 比率: %f%%\n",
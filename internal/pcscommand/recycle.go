@@ -2,16 +2,28 @@ package pcscommand
 
 import (
 	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsrecycle"
+	"BaiduPCS-Go/pcsoutput"
 	"BaiduPCS-Go/pcstable"
 	"BaiduPCS-Go/pcsutil/converter"
 	"BaiduPCS-Go/pcsutil/pcstime"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 )
 
+type (
+	// RecycleMatchOptions 按原路径匹配回收站文件的可选参数
+	RecycleMatchOptions struct {
+		DeletedAfter  time.Time // 删除时间不早于
+		DeletedBefore time.Time // 删除时间早于
+		Yes           bool      // 不需要确认
+	}
+)
+
 // RunRecycleList 执行列出回收站文件列表
 func RunRecycleList(page int) {
 	if page < 1 {
@@ -21,7 +33,12 @@ func RunRecycleList(page int) {
 	pcs := GetBaiduPCS()
 	fdl, err := pcs.RecycleList(page)
 	if err != nil {
-		fmt.Println(err)
+		printCommandError("recycle list", err)
+		return
+	}
+
+	if pcsoutput.IsStructured() {
+		printRecords("recycle list", newRecycleRecords(fdl))
 		return
 	}
 
@@ -85,3 +102,118 @@ func RunRecycleClear() {
 	}
 	fmt.Printf("清空回收站成功, 数量: %d\n", sussNum)
 }
+
+// IsRecycleFsIDList 参数是否全部为 fs_id
+func IsRecycleFsIDList(args []string) bool {
+	for _, arg := range args {
+		if _, err := strconv.ParseInt(arg, 10, 64); err != nil {
+			return false
+		}
+	}
+	return len(args) > 0
+}
+
+// recycleMatch 逐页获取回收站文件列表, 选出原路径匹配 patterns 和删除时间满足条件的文件, 并确认
+func recycleMatch(pcs *baidupcs.BaiduPCS, op string, patterns []string, opt *RecycleMatchOptions) (matched baidupcs.RecycleFDInfoList, ok bool) {
+	if opt == nil {
+		opt = &RecycleMatchOptions{}
+	}
+
+	m := &pcsrecycle.Matcher{
+		DeletedAfter:  opt.DeletedAfter,
+		DeletedBefore: opt.DeletedBefore,
+	}
+	for _, pattern := range patterns {
+		m.Patterns = append(m.Patterns, GetActiveUser().PathJoin(pattern))
+	}
+
+	for page := 1; ; page++ {
+		fdl, err := pcs.RecycleList(page)
+		if err != nil {
+			fmt.Println(err)
+			return nil, false
+		}
+		matched = append(matched, m.Select(fdl)...)
+		if len(fdl) < baidupcs.MaxBatchSize {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		fmt.Println("回收站中没有匹配的文件")
+		return nil, false
+	}
+
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "fs_id", "文件大小", "删除日期", "剩余时间", "路径"})
+	for k, file := range matched {
+		size, fpath := converter.ConvertFileSize(file.Size, 2), file.Path
+		if file.Isdir == 1 {
+			size, fpath = "-", file.Path+baidupcs.PathSeparator
+		}
+		tb.Append([]string{strconv.Itoa(k), strconv.FormatInt(file.FsID, 10), size, pcstime.FormatTime(pcsrecycle.DeletedTime(file).Unix()), strconv.Itoa(file.LeftTime), fpath})
+	}
+	tb.Render()
+
+	if !opt.Yes {
+		var confirm string
+		fmt.Printf("确认%s以上 %d 个文件/目录 ? (y/n) > ", op, len(matched))
+		_, err := fmt.Scanln(&confirm)
+		if err != nil || (confirm != "y" && confirm != "Y") {
+			fmt.Printf("已取消%s\n", op)
+			return nil, false
+		}
+	}
+	return matched, true
+}
+
+// recycleFsIDBatches 将文件列表分批, 返回 fs_id
+func recycleFsIDBatches(fdl baidupcs.RecycleFDInfoList) (batches [][]int64) {
+	forEachBatch(len(fdl), func(start, end int) {
+		fidList := make([]int64, 0, end-start)
+		for _, file := range fdl[start:end] {
+			fidList = append(fidList, file.FsID)
+		}
+		batches = append(batches, fidList)
+	})
+	return
+}
+
+// RunRecycleRestoreByPattern 执行按原路径或通配符还原回收站文件或目录
+func RunRecycleRestoreByPattern(patterns []string, opt *RecycleMatchOptions) {
+	pcs := GetBaiduPCS()
+	matched, ok := recycleMatch(pcs, "还原", patterns, opt)
+	if !ok {
+		return
+	}
+
+	var restored int
+	for _, fidList := range recycleFsIDBatches(matched) {
+		ex, err := pcs.RecycleRestore(fidList...)
+		if err != nil {
+			fmt.Println(err)
+		}
+		restored += len(ex)
+	}
+	fmt.Printf("还原成功 %d 个, 失败 %d 个\n", restored, len(matched)-restored)
+}
+
+// RunRecycleDeleteByPattern 执行按原路径或通配符删除回收站文件或目录
+func RunRecycleDeleteByPattern(patterns []string, opt *RecycleMatchOptions) {
+	pcs := GetBaiduPCS()
+	matched, ok := recycleMatch(pcs, "删除", patterns, opt)
+	if !ok {
+		return
+	}
+
+	var deleted int
+	for _, fidList := range recycleFsIDBatches(matched) {
+		err := pcs.RecycleDelete(fidList...)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		deleted += len(fidList)
+	}
+	fmt.Printf("删除成功 %d 个, 失败 %d 个\n", deleted, len(matched)-deleted)
+}
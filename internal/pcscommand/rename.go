@@ -0,0 +1,143 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsrename"
+	"BaiduPCS-Go/pcstable"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+type (
+	// RenameOptions 批量重命名可选参数
+	RenameOptions struct {
+		Regexp string // 不为空时, 使用正则表达式替换文件名
+		Start  int    // {n} 的起始序号
+		DryRun bool   // 只预览
+		Yes    bool   // 不需要确认
+	}
+)
+
+// RunRename 执行批量重命名, pattern 为通配符, to 为模板或正则表达式的替换内容
+func RunRename(pattern, to string, opt *RenameOptions) {
+	if opt == nil {
+		opt = &RenameOptions{Start: 1}
+	}
+
+	var (
+		renamer pcsrename.Renamer
+		err     error
+	)
+	if opt.Regexp != "" {
+		re, err := regexp.Compile(opt.Regexp)
+		if err != nil {
+			fmt.Printf("正则表达式错误: %s\n", err)
+			return
+		}
+		renamer = &pcsrename.RegexpRenamer{Regexp: re, Replacement: to}
+	} else {
+		renamer, err = pcsrename.ParseTemplate(to)
+		if err != nil {
+			fmt.Printf("模板错误: %s\n", err)
+			return
+		}
+	}
+
+	pcs := GetBaiduPCS()
+	paths, err := pcs.MatchPathByShellPattern(GetActiveUser().PathJoin(pattern))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(paths) == 0 {
+		fmt.Println(ErrShellPatternNoHit)
+		return
+	}
+	sort.Strings(paths)
+
+	// 获取所在目录的文件列表, 用于获取文件信息和检测冲突
+	var (
+		existing = map[string]*baidupcs.FileDirectory{}
+		listed   = map[string]bool{}
+		files    = make(baidupcs.FileDirectoryList, 0, len(paths))
+	)
+	for _, p := range paths {
+		dir := path.Dir(p)
+		if listed[dir] {
+			continue
+		}
+		listed[dir] = true
+		fdl, pcsError := pcs.FilesDirectoriesList(dir, baidupcs.DefaultOrderOptions)
+		if pcsError != nil {
+			fmt.Println(pcsError)
+			return
+		}
+		for _, fd := range fdl {
+			existing[fd.Path] = fd
+		}
+	}
+	for _, p := range paths {
+		fd, ok := existing[p]
+		if !ok {
+			fmt.Printf("获取文件信息失败: %s\n", p)
+			return
+		}
+		files = append(files, fd)
+	}
+
+	plan, err := pcsrename.NewPlan(files, renamer, opt.Start, func(pcspath string) bool {
+		return existing[pcspath] != nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(plan) == 0 {
+		fmt.Println("没有需要重命名的文件")
+		return
+	}
+
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "原路径", "新文件名", "冲突"})
+	for k, r := range plan {
+		tb.Append([]string{strconv.Itoa(k), r.From, path.Base(r.To), r.Conflict})
+	}
+	tb.Render()
+
+	if n := plan.Conflicts(); n > 0 {
+		fmt.Printf("存在 %d 个冲突, 未执行重命名\n", n)
+		return
+	}
+	if opt.DryRun {
+		fmt.Printf("预览: 共 %d 个文件需要重命名\n", len(plan))
+		return
+	}
+
+	if !opt.Yes {
+		var confirm string
+		fmt.Printf("确认重命名以上 %d 个文件/目录 ? (y/n) > ", len(plan))
+		_, err := fmt.Scanln(&confirm)
+		if err != nil || (confirm != "y" && confirm != "Y") {
+			fmt.Println("已取消重命名")
+			return
+		}
+	}
+
+	var (
+		list    = plan.CpMvJSONList()
+		renamed int
+	)
+	forEachBatch(len(list), func(start, end int) {
+		pcsError := pcs.Move(list[start:end]...)
+		if pcsError != nil {
+			fmt.Printf("重命名失败, 第 %d 到 %d 个: %s\n", start, end-1, pcsError)
+			return
+		}
+		renamed += end - start
+	})
+	fmt.Printf("重命名成功 %d 个, 失败 %d 个\n", renamed, len(list)-renamed)
+}
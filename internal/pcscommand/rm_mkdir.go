@@ -15,7 +15,7 @@ func RunRemove(paths ...string) {
 		return
 	}
 
-	pnt := func() {
+	pnt := func(paths []string) {
 		tb := pcstable.NewTable(os.Stdout)
 		tb.SetHeader([]string{"#", "文件/目录"})
 		for k := range paths {
@@ -24,16 +24,15 @@ func RunRemove(paths ...string) {
 		tb.Render()
 	}
 
-	err = GetBaiduPCS().Remove(paths...)
-	if err != nil {
-		fmt.Println(err)
+	failed := removeInBatches(GetBaiduPCS(), paths, os.Stdout)
+	if len(failed) > 0 {
 		fmt.Println("操作失败, 以下文件/目录删除失败: ")
-		pnt()
+		pnt(failed)
 		return
 	}
 
 	fmt.Println("操作成功, 以下文件/目录已删除, 可在网盘文件回收站找回: ")
-	pnt()
+	pnt(paths)
 }
 
 // RunMkdir 执行 创建目录
@@ -0,0 +1,62 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcswebdav"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type (
+	// WebDAVOptions WebDAV 服务可选项
+	WebDAVOptions struct {
+		Addr         string
+		Root         string // 作为 WebDAV 根目录的网盘目录
+		Username     string
+		Password     string
+		CacheExpires time.Duration // 目录列表缓存时间
+	}
+)
+
+// RunServeWebDAV 启动 WebDAV 服务, 提供当前帐号的网盘文件
+func RunServeWebDAV(opt *WebDAVOptions) {
+	if opt == nil {
+		opt = &WebDAVOptions{}
+	}
+	if opt.Root == "" {
+		opt.Root = GetActiveUser().Workdir
+	}
+
+	err := matchPathByShellPatternOnce(&opt.Root)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+
+	// 打开上传状态
+	uploadDatabase, err := pcsupload.NewUploadingDatabase()
+	if err != nil {
+		fmt.Printf("打开上传未完成数据库错误: %s\n", err)
+		return
+	}
+	defer uploadDatabase.Close()
+
+	fs := pcswebdav.NewFileSystem(GetBaiduPCS(), opt.Root, uploadDatabase)
+	fs.UploadParallel = pcsconfig.Config.MaxUploadParallel
+	fs.UploadMaxRetry = DefaultUploadMaxRetry
+	if opt.CacheExpires > 0 {
+		fs.CacheExpires = opt.CacheExpires
+	}
+
+	fmt.Printf("WebDAV 服务已启动: http://%s, 网盘目录: %s\n", opt.Addr, fs.Root)
+	if opt.Username == "" {
+		fmt.Printf("警告: 未设置用户名和密码, 任何人都可以访问\n")
+	}
+
+	err = http.ListenAndServe(opt.Addr, pcswebdav.NewHandler(fs, opt.Username, opt.Password))
+	if err != nil {
+		fmt.Printf("WebDAV 服务错误: %s\n", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/pcsoutput"
 	"BaiduPCS-Go/pcstable"
 )
 
@@ -57,10 +58,34 @@ func RunShareList(page int) {
 	pcs := GetBaiduPCS()
 	records, err := pcs.ShareList(page)
 	if err != nil {
+		if pcsoutput.IsStructured() {
+			pcsoutput.PrintError("share list", err)
+			return
+		}
 		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareList, err)
 		return
 	}
 
+	if pcsoutput.IsStructured() {
+		var (
+			now    = time.Now()
+			output = make([]shareRecord, 0, len(records))
+		)
+		for _, record := range records {
+			if record.Public == 0 && record.ExpireType != -1 {
+				info, pcsError := pcs.ShareSURLInfo(record.ShareID)
+				if pcsError != nil {
+					pcsoutput.PrintError("share list", pcsError)
+				} else {
+					record.Passwd = strings.TrimSpace(info.Pwd)
+				}
+			}
+			output = append(output, newShareRecord(record, now))
+		}
+		printRecords("share list", output)
+		return
+	}
+
 	tb := pcstable.NewTable(os.Stdout)
 	tb.SetHeader([]string{"#", "ShareID", "分享链接", "提取密码", "特征目录", "特征路径", "过期时间", "浏览次数"})
 	for k, record := range records {
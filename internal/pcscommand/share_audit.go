@@ -0,0 +1,299 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsshare"
+	"BaiduPCS-Go/pcsoutput"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// shareListMaxPages 获取全部分享记录时最多获取的页数
+	shareListMaxPages = 1000
+)
+
+var (
+	shareFlagNames = map[string]string{
+		pcsshare.FlagExpired:     "已过期",
+		pcsshare.FlagNoPassword:  "无提取码",
+		pcsshare.FlagPathDeleted: "文件已删除",
+	}
+)
+
+type (
+	// ShareAuditOptions 检查分享可选参数
+	ShareAuditOptions struct {
+		OnlyFlagged bool   // 只输出有问题的分享
+		NoCheckPath bool   // 不检查分享的文件是否已被删除
+		Output      string // 导出到文件, 扩展名为 .json 或 .ndjson 时输出对应格式, 否则输出 csv
+	}
+
+	// shareAuditRecord 分享检查结果, flags 为逗号分隔的问题
+	shareAuditRecord struct {
+		shareRecord
+		Ctime         int64  `json:"ctime"`
+		SaveCount     int    `json:"save_count"`
+		DownloadCount int    `json:"download_count"`
+		Flags         string `json:"flags"`
+	}
+)
+
+// listAllShares 按页获取全部分享记录, 超过 shareListMaxPages 页时返回错误
+func listAllShares(pcs *baidupcs.BaiduPCS) (all baidupcs.ShareRecordInfoList, err error) {
+	seen := map[int64]bool{}
+	for page := 1; ; page++ {
+		if page > shareListMaxPages {
+			return all, fmt.Errorf("分享记录超过 %d 页, 未能获取全部分享", shareListMaxPages)
+		}
+
+		records, pcsError := pcs.ShareList(page)
+		if pcsError != nil {
+			return all, pcsError
+		}
+		added := 0
+		for _, record := range records {
+			if record == nil || seen[record.ShareID] {
+				continue
+			}
+			seen[record.ShareID] = true
+			all = append(all, record)
+			added++
+		}
+		// 没有新的分享记录, 已到最后一页
+		if added == 0 {
+			return all, nil
+		}
+	}
+}
+
+// fillSharePasswd 获取未过期的私密分享的提取码
+func fillSharePasswd(pcs *baidupcs.BaiduPCS, record *baidupcs.ShareRecordInfo) error {
+	if record.Public != 0 || pcsshare.IsExpired(record) {
+		return nil
+	}
+	info, pcsError := pcs.ShareSURLInfo(record.ShareID)
+	if pcsError != nil {
+		return pcsError
+	}
+	record.Passwd = strings.TrimSpace(info.Pwd)
+	return nil
+}
+
+// auditShares 检查分享, checkPath 为 true 时检查分享的文件是否已被删除,
+// 只检查分享记录中的代表路径 (typicalPath), 见 pcsshare.IsPathDeleted
+func auditShares(pcs *baidupcs.BaiduPCS, records baidupcs.ShareRecordInfoList, checkPath bool) []*shareAuditRecord {
+	var (
+		now     = time.Now()
+		metas   = map[string]*baidupcs.FileDirectory{} // 已检查的路径, 文件不存在时为 nil
+		results = make([]*shareAuditRecord, 0, len(records))
+	)
+	for _, record := range records {
+		var flags []string
+		if pcsshare.IsExpired(record) {
+			flags = append(flags, pcsshare.FlagExpired)
+		} else {
+			err := fillSharePasswd(pcs, record)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "shareID %d: 获取分享密码错误: %s\n", record.ShareID, err)
+			} else if pcsshare.HasNoPassword(record) {
+				flags = append(flags, pcsshare.FlagNoPassword)
+			}
+		}
+
+		if checkPath && record.TypicalPath != "" {
+			fd, ok := metas[record.TypicalPath]
+			if !ok {
+				var pcsError pcserror.Error
+				fd, pcsError = pcs.FilesDirectoriesMeta(record.TypicalPath)
+				switch {
+				case pcsError == nil:
+					ok = true
+				case pcsError.GetRemoteErrCode() == 31066: // 31066: 文件不存在
+					fd, ok = nil, true
+				default:
+					fmt.Fprintf(os.Stderr, "shareID %d: 检查文件错误: %s\n", record.ShareID, pcsError)
+				}
+				if ok {
+					metas[record.TypicalPath] = fd
+				}
+			}
+			if ok && pcsshare.IsPathDeleted(record, fd) {
+				flags = append(flags, pcsshare.FlagPathDeleted)
+			}
+		}
+
+		results = append(results, &shareAuditRecord{
+			shareRecord:   newShareRecord(record, now),
+			Ctime:         record.Ctime,
+			SaveCount:     record.SaveCount,
+			DownloadCount: record.DownloadCount,
+			Flags:         strings.Join(flags, ","),
+		})
+	}
+	return results
+}
+
+// shareFlagsText 问题的中文描述
+func shareFlagsText(flags string) string {
+	if flags == "" {
+		return ""
+	}
+	texts := strings.Split(flags, ",")
+	for i, flag := range texts {
+		if name, ok := shareFlagNames[flag]; ok {
+			texts[i] = name
+		}
+	}
+	return strings.Join(texts, ", ")
+}
+
+// shareExpireText 分享的过期时间
+func shareExpireText(sr *shareRecord) string {
+	switch {
+	case sr.Expired:
+		return "已过期"
+	case sr.ExpireTime == 0:
+		return "永久"
+	}
+	return time.Unix(sr.ExpireTime, 0).Format("2006/01/02 15:04:05")
+}
+
+// RunShareAudit 执行检查全部分享, 标记已过期, 无提取码, 文件已被删除的分享
+func RunShareAudit(opt *ShareAuditOptions) {
+	if opt == nil {
+		opt = &ShareAuditOptions{}
+	}
+
+	pcs := GetBaiduPCS()
+	records, err := listAllShares(pcs)
+	if err != nil {
+		printCommandError("share audit", err)
+		return
+	}
+
+	results := auditShares(pcs, records, !opt.NoCheckPath)
+	flagged := 0
+	for _, res := range results {
+		if res.Flags != "" {
+			flagged++
+		}
+	}
+	if opt.OnlyFlagged {
+		filtered := make([]*shareAuditRecord, 0, flagged)
+		for _, res := range results {
+			if res.Flags != "" {
+				filtered = append(filtered, res)
+			}
+		}
+		results = filtered
+	}
+
+	if opt.Output != "" {
+		err = writeShareAuditReport(opt.Output, results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "导出失败: %s\n", err)
+		} else if !pcsoutput.IsStructured() {
+			fmt.Printf("已导出到 %s\n", opt.Output)
+		}
+	}
+
+	if pcsoutput.IsStructured() {
+		printRecords("share audit", results)
+		return
+	}
+
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "ShareID", "分享链接", "提取密码", "特征路径", "创建时间", "过期时间", "浏览", "转存", "下载", "问题"})
+	for k, res := range results {
+		ctime := ""
+		if res.Ctime > 0 {
+			ctime = pcstime.FormatTime(res.Ctime)
+		}
+		tb.Append([]string{strconv.Itoa(k), strconv.FormatInt(res.ShareID, 10), res.Shortlink, res.Passwd, res.TypicalPath, ctime, shareExpireText(&res.shareRecord),
+			strconv.Itoa(res.ViewCount), strconv.Itoa(res.SaveCount), strconv.Itoa(res.DownloadCount), shareFlagsText(res.Flags)})
+	}
+	tb.Render()
+	fmt.Printf("共 %d 个分享, 有问题的分享 %d 个\n", len(records), flagged)
+}
+
+// writeShareAuditReport 导出分享检查结果
+func writeShareAuditReport(filename string, results []*shareAuditRecord) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = pcsoutput.Write(f, reportFormat(filename), results)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RunShareCancelFilter 执行按条件取消分享, yes 为 true 时不需要确认
+func RunShareCancelFilter(filter *pcsshare.CancelFilter, yes bool) {
+	if filter.IsEmpty() {
+		fmt.Printf("%s失败, 没有任何条件\n", baidupcs.OperationShareCancel)
+		return
+	}
+
+	records, err := listAllShares(GetBaiduPCS())
+	if err != nil {
+		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareList, err)
+		return
+	}
+
+	var matched baidupcs.ShareRecordInfoList
+	for _, record := range records {
+		ok, err := filter.Match(record)
+		if err != nil {
+			fmt.Printf("%s失败: %s\n", baidupcs.OperationShareCancel, err)
+			return
+		}
+		if ok {
+			matched = append(matched, record)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Println("没有匹配的分享")
+		return
+	}
+
+	now := time.Now()
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "ShareID", "分享链接", "特征路径", "创建时间", "过期时间"})
+	for k, record := range matched {
+		sr := newShareRecord(record, now)
+		ctime := ""
+		if record.Ctime > 0 {
+			ctime = pcstime.FormatTime(record.Ctime)
+		}
+		tb.Append([]string{strconv.Itoa(k), strconv.FormatInt(record.ShareID, 10), record.Shortlink, record.TypicalPath, ctime, shareExpireText(&sr)})
+	}
+	tb.Render()
+
+	if !yes {
+		var confirm string
+		fmt.Printf("确认取消以上 %d 个分享 ? (y/n) > ", len(matched))
+		_, err := fmt.Scanln(&confirm)
+		if err != nil || (confirm != "y" && confirm != "Y") {
+			fmt.Printf("已取消%s\n", baidupcs.OperationShareCancel)
+			return
+		}
+	}
+
+	shareIDs := make([]int64, 0, len(matched))
+	for _, record := range matched {
+		shareIDs = append(shareIDs, record.ShareID)
+	}
+	forEachBatch(len(shareIDs), func(start, end int) {
+		RunShareCancel(shareIDs[start:end])
+	})
+}
@@ -0,0 +1,161 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/pcsoutput"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+type (
+	// ShareLsOptions 列出分享链接中的文件可选参数
+	ShareLsOptions struct {
+		Recursive bool // 递归列出子目录
+	}
+
+	// shareLister 列出分享链接中的文件
+	shareLister struct {
+		pcs     *baidupcs.BaiduPCS
+		opt     baidupcs.ShareFileListOption
+		rootDir string // 分享的文件在分享者网盘中的父目录
+	}
+)
+
+// ParseShareLsArgs 解析 share ls 的参数 <链接> [提取码] [子目录], 子目录以 / 开头
+func ParseShareLsArgs(args []string) (link, extraCode, subPath string) {
+	if len(args) == 0 {
+		return
+	}
+	link = args[0]
+	switch len(args) {
+	case 1:
+	case 2:
+		if strings.HasPrefix(args[1], baidupcs.PathSeparator) {
+			subPath = args[1]
+		} else {
+			extraCode = args[1]
+		}
+	default:
+		extraCode, subPath = args[1], args[2]
+	}
+	return
+}
+
+// list 列出目录 dir 下的全部文件, dir 为空时列出分享的根目录
+func (sl *shareLister) list(dir string) (fdl baidupcs.FileDirectoryList, err error) {
+	opt := sl.opt
+	opt.Dir, opt.Num = dir, baidupcs.MaxBatchSize
+	for opt.Page = 1; ; opt.Page++ {
+		page, pcsError := sl.pcs.ShareFileList(&opt)
+		if pcsError != nil {
+			return nil, pcsError
+		}
+		fdl = append(fdl, page...)
+		if len(page) < opt.Num {
+			return fdl, nil
+		}
+	}
+}
+
+// walk 递归列出目录, 目录后紧跟其中的文件
+func (sl *shareLister) walk(fdl baidupcs.FileDirectoryList, handleError func(dir string, err error)) (all baidupcs.FileDirectoryList) {
+	for _, fd := range fdl {
+		all = append(all, fd)
+		if !fd.Isdir {
+			continue
+		}
+		sub, err := sl.list(fd.Path)
+		if err != nil {
+			handleError(sl.relPath(fd.Path), err)
+			continue
+		}
+		all = append(all, sl.walk(sub, handleError)...)
+	}
+	return
+}
+
+// relPath 分享者网盘中的路径转换为相对于分享根目录的路径
+func (sl *shareLister) relPath(p string) string {
+	if sl.rootDir == baidupcs.PathSeparator {
+		return p
+	}
+	return path.Join(baidupcs.PathSeparator, strings.TrimPrefix(p, sl.rootDir))
+}
+
+// RunShareLs 执行列出分享链接中的文件, 不转存. subPath 为相对于分享根目录的路径
+func RunShareLs(link, extraCode, subPath string, opt *ShareLsOptions) {
+	if opt == nil {
+		opt = &ShareLsOptions{}
+	}
+
+	pcs := GetBaiduPCS()
+	featureStr, tokens, err := accessShare(pcs, link, extraCode)
+	if err != nil {
+		printCommandError("share ls", err)
+		return
+	}
+
+	sl := &shareLister{
+		pcs: pcs,
+		opt: baidupcs.ShareFileListOption{
+			FeatureStr: featureStr,
+			ShareID:    tokens["shareid"],
+			ShareUK:    tokens["share_uk"],
+			BDStoken:   tokens["bdstoken"],
+		},
+		rootDir: baidupcs.PathSeparator,
+	}
+	fdl, err := sl.list("")
+	if err != nil {
+		printCommandError("share ls", err)
+		return
+	}
+	if len(fdl) > 0 {
+		sl.rootDir = path.Dir(fdl[0].Path)
+	}
+
+	subPath = path.Clean(baidupcs.PathSeparator + subPath)
+	if subPath != baidupcs.PathSeparator {
+		fdl, err = sl.list(path.Join(sl.rootDir, subPath))
+		if err != nil {
+			printCommandError("share ls", fmt.Errorf("%s: %s", subPath, err))
+			return
+		}
+	}
+
+	if opt.Recursive {
+		fdl = sl.walk(fdl, func(dir string, err error) {
+			printCommandError("share ls", fmt.Errorf("%s: %s", dir, err))
+		})
+	}
+	for _, fd := range fdl {
+		fd.Path = sl.relPath(fd.Path)
+	}
+
+	if pcsoutput.IsStructured() {
+		printRecords("share ls", newFileRecords(fdl))
+		return
+	}
+
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "fs_id", "文件大小", "修改日期", "md5", "路径"})
+	tb.SetColumnAlignment([]int{tablewriter.ALIGN_DEFAULT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
+	for k, fd := range fdl {
+		if fd.Isdir {
+			tb.Append([]string{strconv.Itoa(k), strconv.FormatInt(fd.FsID, 10), "-", pcstime.FormatTime(fd.Mtime), "", fd.Path + baidupcs.PathSeparator})
+			continue
+		}
+		tb.Append([]string{strconv.Itoa(k), strconv.FormatInt(fd.FsID, 10), converter.ConvertFileSize(fd.Size, 2), pcstime.FormatTime(fd.Mtime), fd.MD5, fd.Path})
+	}
+	fN, dN := fdl.Count()
+	tb.Append([]string{"", "", "总: " + converter.ConvertFileSize(fdl.TotalSize(), 2), "", "", fmt.Sprintf("文件总数: %d, 目录总数: %d", fN, dN)})
+	tb.Render()
+}
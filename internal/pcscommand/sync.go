@@ -0,0 +1,285 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcssync"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcstable"
+	"BaiduPCS-Go/pcsutil"
+	"BaiduPCS-Go/pcsutil/checksum"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSyncMaxDelete 双向同步一次最多删除的文件数
+	DefaultSyncMaxDelete = 100
+)
+
+type (
+	// SyncOptions 同步可选项
+	SyncOptions struct {
+		Parallel      int
+		MaxRetry      int
+		Load          int
+		NoRapidUpload bool
+		NoSplitFile   bool
+		DryRun        bool   // 只输出同步计划
+		CheckMD5      bool   // 大小一致时比较md5
+		Delete        bool   // 删除网盘中本地不存在的文件
+		TwoWay        bool   // 双向同步
+		Conflict      string // 双向同步的冲突处理策略
+		MaxDelete     int    // 双向同步一次最多删除的文件数, 超过时取消同步, 0 为不限制
+	}
+)
+
+// listLocalEntries 遍历本地目录, 返回相对路径的文件信息
+func listLocalEntries(localDir string) (entries pcssync.FileEntries, err error) {
+	entries = pcssync.FileEntries{}
+	err = filepath.Walk(localDir, func(walkPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, walkPath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		entries.Add(&pcssync.FileEntry{
+			Path:  filepath.ToSlash(rel),
+			Size:  info.Size(),
+			Mtime: info.ModTime().Unix(),
+			IsDir: info.IsDir(),
+		})
+		return nil
+	})
+	return
+}
+
+// listRemoteEntries 递归获取网盘目录, 返回相对路径的文件信息, 目录不存在时返回空
+func listRemoteEntries(pcs *baidupcs.BaiduPCS, pcsDir string) (entries pcssync.FileEntries, fds map[string]*baidupcs.FileDirectory, err error) {
+	entries = pcssync.FileEntries{}
+	fds = map[string]*baidupcs.FileDirectory{}
+	pcs.FilesDirectoriesRecurseList(pcsDir, baidupcs.DefaultOrderOptions, func(depth int, _ string, fd *baidupcs.FileDirectory, pcsError pcserror.Error) bool {
+		if pcsError != nil {
+			if depth == 0 && pcsError.GetErrType() == pcserror.ErrTypeRemoteError && pcsError.GetRemoteErrCode() == 31066 {
+				// 目录不存在
+				return false
+			}
+			err = pcsError
+			return false
+		}
+		if fd.Path == pcsDir {
+			return true
+		}
+		rel := strings.TrimPrefix(fd.Path, strings.TrimSuffix(pcsDir, baidupcs.PathSeparator)+baidupcs.PathSeparator)
+		fds[rel] = fd
+		entries.Add(&pcssync.FileEntry{
+			Path:  rel,
+			Size:  fd.Size,
+			Mtime: fd.Mtime,
+			MD5:   fd.MD5,
+			FsID:  fd.FsID,
+			IsDir: fd.Isdir,
+		})
+		return true
+	})
+	return
+}
+
+// localFileMD5 计算本地文件的md5
+func localFileMD5(localPath string) (string, error) {
+	lfc := checksum.NewLocalFileChecksum(localPath, int(baidupcs.SliceMD5Size))
+	err := lfc.OpenPath()
+	if err != nil {
+		return "", err
+	}
+	defer lfc.Close()
+
+	err = lfc.Sum(checksum.CHECKSUM_MD5)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(lfc.MD5), nil
+}
+
+// printSyncPlan 输出同步计划
+func printSyncPlan(plan pcssync.Plan) {
+	tb := pcstable.NewTable(os.Stdout)
+	tb.SetHeader([]string{"#", "动作", "路径", "大小", "原因"})
+	for k, action := range plan {
+		var size int64
+		switch {
+		case action.Local != nil:
+			size = action.Local.Size
+		case action.Remote != nil:
+			size = action.Remote.Size
+		}
+		tb.Append([]string{strconv.Itoa(k + 1), action.Type.String(), action.Path, converter.ConvertFileSize(size, 2), action.Reason})
+	}
+	tb.Render()
+}
+
+// newSyncUploadTaskUnit 同步使用的上传任务单元, 覆盖网盘中已存在的文件
+func newSyncUploadTaskUnit(pcs *baidupcs.BaiduPCS, uploadDatabase *pcsupload.UploadingDatabase, statistic *pcsupload.UploadStatistic, localPath, savePath string, opt *SyncOptions) *pcsupload.UploadTaskUnit {
+	return &pcsupload.UploadTaskUnit{
+		LocalFileChecksum: checksum.NewLocalFileChecksum(localPath, int(baidupcs.SliceMD5Size)),
+		SavePath:          savePath,
+		PCS:               pcs,
+		UploadingDatabase: uploadDatabase,
+		Parallel:          opt.Parallel,
+		PrintFormat:       uploadPrintFormat(opt.Load),
+		NoRapidUpload:     opt.NoRapidUpload,
+		NoSplitFile:       opt.NoSplitFile,
+		UploadStatistic:   statistic,
+		Policy:            baidupcs.OverWritePolicy,
+	}
+}
+
+// RunSync 执行将本地目录单向同步到网盘目录
+func RunSync(localDir, pcsDir string, opt *SyncOptions) {
+	if opt == nil {
+		opt = &SyncOptions{}
+	}
+	if opt.Parallel <= 0 {
+		opt.Parallel = pcsconfig.Config.MaxUploadParallel
+	}
+	if opt.MaxRetry < 0 {
+		opt.MaxRetry = DefaultUploadMaxRetry
+	}
+	if opt.Load <= 0 {
+		opt.Load = pcsconfig.Config.MaxUploadLoad
+	}
+
+	fi, err := os.Stat(localDir)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+	if !fi.IsDir() {
+		fmt.Printf("本地路径 %s 不是目录\n", localDir)
+		return
+	}
+
+	var (
+		pcs = GetBaiduPCS()
+	)
+	pcsDir = GetActiveUser().PathJoin(pcsDir)
+
+	if opt.TwoWay {
+		if opt.Delete {
+			fmt.Printf("双向同步会同步两边的删除, 不能与 --delete 同时使用\n")
+			return
+		}
+		runTwoWaySync(pcs, localDir, pcsDir, opt)
+		return
+	}
+
+	fmt.Printf("正在比较本地目录 %s 和网盘目录 %s, 请稍候...\n", localDir, pcsDir)
+	local, err := listLocalEntries(localDir)
+	if err != nil {
+		fmt.Printf("遍历本地目录错误: %s\n", err)
+		return
+	}
+	remote, _, err := listRemoteEntries(pcs, pcsDir)
+	if err != nil {
+		fmt.Printf("获取网盘目录错误: %s\n", err)
+		return
+	}
+
+	plan, err := pcssync.PlanUpload(local, remote, &pcssync.CompareOptions{
+		CheckMD5: opt.CheckMD5,
+		Delete:   opt.Delete,
+		LocalMD5: func(entry *pcssync.FileEntry) (string, error) {
+			return localFileMD5(filepath.Join(localDir, filepath.FromSlash(entry.Path)))
+		},
+	})
+	if err != nil {
+		fmt.Printf("比较文件错误: %s\n", err)
+		return
+	}
+
+	if len(plan) == 0 {
+		fmt.Printf("本地目录和网盘目录一致, 无需同步.\n")
+		return
+	}
+
+	if opt.DryRun {
+		printSyncPlan(plan)
+		fmt.Printf("上传: %d, 覆盖: %d, 删除: %d, 待上传总大小: %s\n",
+			plan.Count(pcssync.ActionUpload), plan.Count(pcssync.ActionOverwrite), plan.Count(pcssync.ActionDeleteRemote),
+			converter.ConvertFileSize(plan.TotalSize(pcssync.ActionUpload)+plan.TotalSize(pcssync.ActionOverwrite), 2))
+		return
+	}
+
+	// 打开上传状态
+	uploadDatabase, err := pcsupload.NewUploadingDatabase()
+	if err != nil {
+		fmt.Printf("打开上传未完成数据库错误: %s\n", err)
+		return
+	}
+	defer uploadDatabase.Close()
+
+	var (
+		executor = &taskframework.TaskExecutor{
+			IsFailedDeque: true, // 失败统计
+		}
+		statistic   = &pcsupload.UploadStatistic{}
+		removePaths []string
+	)
+
+	for _, action := range plan {
+		switch action.Type {
+		case pcssync.ActionUpload, pcssync.ActionOverwrite:
+			localPath := filepath.Join(localDir, filepath.FromSlash(action.Path))
+			if !pcsconfig.Config.IgnoreIllegal && !pcsutil.ChPathLegal(localPath) {
+				fmt.Printf("[0] %s 文件路径含有非法字符，已跳过!\n", localPath)
+				continue
+			}
+			info := executor.Append(newSyncUploadTaskUnit(pcs, uploadDatabase, statistic, localPath, path.Join(pcsDir, action.Path), opt), opt.MaxRetry)
+			fmt.Printf("[%s] 加入上传队列 (%s): %s\n", info.Id(), action.Type, localPath)
+		case pcssync.ActionDeleteRemote:
+			removePaths = append(removePaths, path.Join(pcsDir, action.Path))
+		}
+	}
+
+	if executor.Count() > 0 {
+		statistic.StartTimer()
+		executor.SetParallel(opt.Load)
+		executor.Execute()
+		fmt.Printf("\n上传结束, 时间: %s, 总大小: %s\n", statistic.Elapsed()/1e6*1e6, converter.ConvertFileSize(statistic.TotalSize()))
+	}
+
+	var removeFailed int
+	if len(removePaths) > 0 {
+		removeFailed = len(removeInBatches(pcs, removePaths, os.Stdout))
+		fmt.Printf("删除网盘文件 %d 个, 失败 %d 个\n", len(removePaths)-removeFailed, removeFailed)
+	}
+
+	var uploadFailed int
+	failedList := executor.FailedDeque()
+	if failedList != nil && failedList.Size() != 0 {
+		uploadFailed = failedList.Size()
+		fmt.Printf("以下文件上传失败: \n")
+		tb := pcstable.NewTable(os.Stdout)
+		for e := failedList.Shift(); e != nil; e = failedList.Shift() {
+			item := e.(*taskframework.TaskInfoItem)
+			tb.Append([]string{item.Info.Id(), item.Unit.(*pcsupload.UploadTaskUnit).LocalFileChecksum.Path})
+		}
+		tb.Render()
+	}
+
+	fmt.Printf("同步结束, 上传: %d, 覆盖: %d, 删除: %d, 上传失败: %d, 删除失败: %d\n",
+		plan.Count(pcssync.ActionUpload), plan.Count(pcssync.ActionOverwrite), len(removePaths)-removeFailed, uploadFailed, removeFailed)
+}
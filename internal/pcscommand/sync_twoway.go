@@ -0,0 +1,259 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcssync"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// runTwoWaySync 执行本地目录和网盘目录的双向同步, 同步状态保存在配置目录
+func runTwoWaySync(pcs *baidupcs.BaiduPCS, localDir, pcsDir string, opt *SyncOptions) {
+	policy, err := pcssync.ParseConflictPolicy(opt.Conflict)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+
+	stateDatabase, err := pcssync.NewSyncStateDatabase()
+	if err != nil {
+		fmt.Printf("打开同步状态数据库错误: %s\n", err)
+		return
+	}
+	defer stateDatabase.Close()
+
+	pair := stateDatabase.Pair(GetActiveUser().UID, localDir, pcsDir)
+	if len(pair.Entries) == 0 {
+		fmt.Printf("未找到同步记录, 将进行首次双向同步\n")
+	}
+
+	fmt.Printf("正在比较本地目录 %s 和网盘目录 %s, 请稍候...\n", localDir, pcsDir)
+	local, err := listLocalEntries(localDir)
+	if err != nil {
+		fmt.Printf("遍历本地目录错误: %s\n", err)
+		return
+	}
+	remote, fds, err := listRemoteEntries(pcs, pcsDir)
+	if err != nil {
+		fmt.Printf("获取网盘目录错误: %s\n", err)
+		return
+	}
+	// 网盘目录不存在时, 所有文件都会被视为网盘已删除
+	if len(pair.Entries) > 0 && len(remote) == 0 {
+		_, pcsError := pcs.FilesDirectoriesMeta(pcsDir)
+		if pcsError != nil {
+			fmt.Printf("获取网盘目录错误: %s, 网盘目录可能已被移动或删除, 为避免删除本地文件, 已取消同步\n", pcsError)
+			return
+		}
+	}
+	// 本地目录为空 (如未挂载) 时, 所有文件都会被视为本地已删除
+	if len(pair.Entries) > 0 && len(local) == 0 {
+		fmt.Printf("本地目录 %s 为空, 可能未挂载或已被移动, 为避免删除网盘文件, 已取消同步\n", localDir)
+		return
+	}
+
+	plan, err := pcssync.PlanBidirectional(local, remote, pair.Entries, &pcssync.BisyncOptions{
+		CheckMD5: opt.CheckMD5,
+		Policy:   policy,
+		LocalMD5: func(entry *pcssync.FileEntry) (string, error) {
+			return localFileMD5(filepath.Join(localDir, filepath.FromSlash(entry.Path)))
+		},
+	})
+	if err != nil {
+		fmt.Printf("比较文件错误: %s\n", err)
+		return
+	}
+
+	deleteCount := plan.DeleteCount(local, remote)
+	tooManyDeletes := opt.MaxDelete > 0 && deleteCount > opt.MaxDelete
+	if opt.DryRun {
+		if len(plan) == 0 {
+			fmt.Printf("本地目录和网盘目录一致, 无需同步.\n")
+			return
+		}
+		printSyncPlan(plan)
+		if tooManyDeletes {
+			fmt.Printf("警告: 将删除 %d 个文件, 超过限制 %d, 执行时将取消同步\n", deleteCount, opt.MaxDelete)
+		}
+		return
+	}
+	if tooManyDeletes {
+		fmt.Printf("将删除 %d 个文件, 超过限制 %d, 已取消同步. 请使用 --dry-run 确认后, 通过 --max-delete 调整限制\n", deleteCount, opt.MaxDelete)
+		return
+	}
+
+	var (
+		failed  = map[string]bool{} // 执行失败的相对路径, 保留旧的同步状态
+		toLocal = func(rel string) string {
+			return filepath.Join(localDir, filepath.FromSlash(rel))
+		}
+		toRemote = func(rel string) string {
+			return path.Join(pcsDir, rel)
+		}
+		fromRemote = func(p string) string {
+			return strings.TrimPrefix(strings.TrimPrefix(p, pcsDir), baidupcs.PathSeparator)
+		}
+	)
+
+	// 先处理移动和冲突文件的重命名, 再进行上传下载
+	for _, action := range plan {
+		switch action.Type {
+		case pcssync.ActionMoveRemote:
+			pcsError := pcs.Move(&baidupcs.CpMvJSON{From: toRemote(action.Path), To: toRemote(action.NewPath)})
+			if pcsError != nil {
+				fmt.Printf("移动网盘文件 %s 失败: %s\n", action.Path, pcsError)
+				failed[action.Path], failed[action.NewPath] = true, true
+				continue
+			}
+			fmt.Printf("移动网盘文件: %s -> %s\n", action.Path, action.NewPath)
+		case pcssync.ActionMoveLocal, pcssync.ActionKeepBoth:
+			err = os.MkdirAll(filepath.Dir(toLocal(action.NewPath)), 0777)
+			if err == nil {
+				err = os.Rename(toLocal(action.Path), toLocal(action.NewPath))
+			}
+			if err != nil {
+				fmt.Printf("移动本地文件 %s 失败: %s\n", action.Path, err)
+				failed[action.Path], failed[action.NewPath] = true, true
+				continue
+			}
+			fmt.Printf("移动本地文件: %s -> %s\n", action.Path, action.NewPath)
+		case pcssync.ActionMkdirRemote:
+			pcsError := pcs.Mkdir(toRemote(action.Path))
+			if pcsError != nil {
+				fmt.Printf("创建网盘目录 %s 失败: %s\n", action.Path, pcsError)
+				failed[action.Path] = true
+			}
+		case pcssync.ActionMkdirLocal:
+			err = os.MkdirAll(toLocal(action.Path), 0777)
+			if err != nil {
+				fmt.Printf("创建本地目录 %s 失败: %s\n", action.Path, err)
+				failed[action.Path] = true
+			}
+		}
+	}
+
+	// 打开上传状态
+	uploadDatabase, err := pcsupload.NewUploadingDatabase()
+	if err != nil {
+		fmt.Printf("打开上传未完成数据库错误: %s\n", err)
+		return
+	}
+	defer uploadDatabase.Close()
+
+	var (
+		uploadExecutor = &taskframework.TaskExecutor{
+			IsFailedDeque: true,
+		}
+		downloadExecutor = &taskframework.TaskExecutor{
+			IsFailedDeque: true,
+		}
+		uploadStatistic   = &pcsupload.UploadStatistic{}
+		downloadStatistic = &pcsdownload.DownloadStatistic{}
+		cfg               = newDownloaderConfig(false)
+		mirrorOpt         = &MirrorOptions{
+			NoCheck: pcsconfig.Config.NoCheck,
+		}
+		removePaths []string
+		deletePaths []string
+	)
+	cfg.MaxParallel = pcsconfig.AverageParallel(pcsconfig.Config.MaxParallel, pcsconfig.Config.MaxDownloadLoad)
+
+	appendUpload := func(rel string) {
+		info := uploadExecutor.Append(newSyncUploadTaskUnit(pcs, uploadDatabase, uploadStatistic, toLocal(rel), toRemote(rel), opt), opt.MaxRetry)
+		fmt.Printf("[%s] 加入上传队列: %s\n", info.Id(), rel)
+	}
+	appendDownload := func(rel string) {
+		fd, ok := fds[rel]
+		if !ok {
+			failed[rel] = true
+			return
+		}
+		info := downloadExecutor.Append(newMirrorDownloadTaskUnit(pcs, downloadExecutor, cfg, downloadStatistic, fd, toLocal(rel), pcsconfig.Config.MaxDownloadLoad, mirrorOpt), opt.MaxRetry)
+		fmt.Printf("[%s] 加入下载队列: %s\n", info.Id(), rel)
+	}
+
+	for _, action := range plan {
+		if failed[action.Path] {
+			continue
+		}
+		switch action.Type {
+		case pcssync.ActionUpload, pcssync.ActionOverwrite:
+			appendUpload(action.Path)
+		case pcssync.ActionDownload, pcssync.ActionDownloadOverwrite:
+			appendDownload(action.Path)
+		case pcssync.ActionKeepBoth:
+			appendUpload(action.NewPath)
+			appendDownload(action.Path)
+		case pcssync.ActionDeleteRemote:
+			removePaths = append(removePaths, toRemote(action.Path))
+		case pcssync.ActionDeleteLocal:
+			deletePaths = append(deletePaths, action.Path)
+		}
+	}
+
+	if uploadExecutor.Count() > 0 {
+		uploadStatistic.StartTimer()
+		uploadExecutor.SetParallel(opt.Load)
+		uploadExecutor.Execute()
+		fmt.Printf("\n上传结束, 时间: %s, 总大小: %s\n", uploadStatistic.Elapsed()/1e6*1e6, converter.ConvertFileSize(uploadStatistic.TotalSize()))
+		for e := uploadExecutor.FailedDeque().Shift(); e != nil; e = uploadExecutor.FailedDeque().Shift() {
+			unit := e.(*taskframework.TaskInfoItem).Unit.(*pcsupload.UploadTaskUnit)
+			failed[fromRemote(unit.SavePath)] = true
+		}
+	}
+
+	if downloadExecutor.Count() > 0 {
+		downloadStatistic.StartTimer()
+		downloadExecutor.SetParallel(pcsconfig.Config.MaxDownloadLoad)
+		downloadExecutor.Execute()
+		fmt.Printf("\n下载结束, 时间: %s, 数据总量: %s\n", downloadStatistic.Elapsed()/1e6*1e6, converter.ConvertFileSize(downloadStatistic.TotalSize()))
+		for e := downloadExecutor.FailedDeque().Shift(); e != nil; e = downloadExecutor.FailedDeque().Shift() {
+			unit := e.(*taskframework.TaskInfoItem).Unit.(*mirrorDownloadTaskUnit)
+			if rel, err := filepath.Rel(localDir, unit.targetPath); err == nil {
+				failed[filepath.ToSlash(rel)] = true
+			}
+		}
+	}
+
+	if len(removePaths) > 0 {
+		removeFailed := removeInBatches(pcs, removePaths, os.Stdout)
+		for _, p := range removeFailed {
+			failed[fromRemote(p)] = true
+		}
+		fmt.Printf("删除网盘文件 %d 个, 失败 %d 个\n", len(removePaths)-len(removeFailed), len(removeFailed))
+	}
+	for _, rel := range deletePaths {
+		err = os.RemoveAll(toLocal(rel))
+		if err != nil {
+			fmt.Printf("删除本地文件失败: %s\n", err)
+			failed[rel] = true
+			continue
+		}
+		fmt.Printf("删除本地文件: %s\n", rel)
+	}
+
+	// 重新获取两边的文件信息, 保存同步状态
+	local, err = listLocalEntries(localDir)
+	if err == nil {
+		remote, _, err = listRemoteEntries(pcs, pcsDir)
+	}
+	if err != nil {
+		fmt.Printf("获取同步后的文件信息错误, 同步状态未保存: %s\n", err)
+		return
+	}
+	stateDatabase.Update(pair, pcssync.NewSyncedEntries(local, remote, pair.Entries, failed))
+	err = stateDatabase.Save()
+	if err != nil {
+		fmt.Printf("保存同步状态错误: %s\n", err)
+	}
+
+	fmt.Printf("双向同步结束, 计划执行: %d, 失败: %d\n", len(plan), len(failed))
+}
@@ -2,50 +2,64 @@ package pcscommand
 
 import (
 	"BaiduPCS-Go/baidupcs"
+	"errors"
 	"fmt"
+	"net/url"
 	"path"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// RunShareTransfer 执行分享链接转存到网盘
-func RunShareTransfer(params []string, opt *baidupcs.TransferOption) {
-	var link string
-	var extraCode string
-	if len(params) == 1 {
-		link = params[0]
-		if strings.Contains(link, "bdlink=") || !strings.Contains(link, "pan.baidu.com/") {
-			//RunRapidTransfer(link, opt.Rname)
-			fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, "秒传已不再被支持")
-			return
-		}
+var (
+	// ErrShareLinkInvalid 分享链接或提取码非法
+	ErrShareLinkInvalid = errors.New("链接地址或提取码非法")
+)
+
+// parseShareLink 解析分享链接, 返回去掉提取码的链接, 分享的特征码和提取码.
+// extraCode 为空时, 从链接中的 ?pwd= 获取, 没有提取码时返回 none
+func parseShareLink(link, extraCode string) (shareLink, featureStr, code string, err error) {
+	link = strings.TrimSpace(link)
+	if extraCode == "" {
 		extraCode = "none"
 		if strings.Contains(link, "?pwd=") {
 			extraCode = strings.Split(link, "?pwd=")[1]
 			link = strings.Split(link, "?pwd=")[0]
 		}
-	} else if len(params) == 2 {
-		link = params[0]
-		extraCode = params[1]
+	}
+	if link == "" {
+		return "", "", "", ErrShareLinkInvalid
 	}
 	if link[len(link)-1:] == "/" {
 		link = link[0 : len(link)-1]
 	}
 	featureStrs := strings.Split(link, "/")
-	featureStr := featureStrs[len(featureStrs)-1]
+	featureStr = featureStrs[len(featureStrs)-1]
 	if strings.Contains(featureStr, "init?") {
 		featureStr = "1" + strings.Split(featureStr, "=")[1]
 	}
-	if len(featureStr) > 23 || featureStr[0:1] != "1" || len(extraCode) != 4 {
-		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, "链接地址或提取码非法")
-		return
+	if featureStr == "" || len(featureStr) > 23 || featureStr[0:1] != "1" || len(extraCode) != 4 {
+		return "", "", "", ErrShareLinkInvalid
 	}
-	pcs := GetBaiduPCS()
-	tokens := pcs.AccessSharePage(featureStr, true)
+	return link, featureStr, extraCode, nil
+}
+
+// isShareLink 是否为网盘分享链接
+func isShareLink(link string) bool {
+	return strings.Contains(link, "pan.baidu.com/") && !strings.Contains(link, "bdlink=")
+}
+
+// accessShare 访问分享页面, 私密分享使用提取码验证.
+// 返回分享链接的特征码和页面中的 bdstoken, shareid, share_uk 等参数
+func accessShare(pcs *baidupcs.BaiduPCS, link, extraCode string) (featureStr string, tokens map[string]string, err error) {
+	link, featureStr, extraCode, err = parseShareLink(link, extraCode)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tokens = pcs.AccessSharePage(featureStr, true)
 	if tokens["ErrMsg"] != "0" {
-		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, tokens["ErrMsg"])
-		return
+		return "", nil, errors.New(tokens["ErrMsg"])
 	}
 
 	if extraCode != "none" {
@@ -62,17 +76,34 @@ func RunShareTransfer(params []string, opt *baidupcs.TransferOption) {
 			"bdstoken":  tokens["bdstoken"],
 		})
 		if res["ErrMsg"] != "0" {
-			fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, res["ErrMsg"])
-			return
+			return "", nil, errors.New(res["ErrMsg"])
 		}
 	}
 	pcs.UpdatePCSCookies(true)
 
 	tokens = pcs.AccessSharePage(featureStr, false)
 	if tokens["ErrMsg"] != "0" {
-		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, tokens["ErrMsg"])
-		return
+		return "", nil, errors.New(tokens["ErrMsg"])
 	}
+	return featureStr, tokens, nil
+}
+
+// shareTransfer 将分享链接转存到网盘目录 saveDir, opt.Collect 为 true 时多个文件整合到一个目录.
+// opt 可指定只转存分享中的部分文件和同名文件的处理方式.
+// 返回转存结果的描述, 和转存的文件或目录的网盘路径
+func shareTransfer(pcs *baidupcs.BaiduPCS, link, extraCode, saveDir string, opt *baidupcs.TransferOption) (savedName string, paths []string, err error) {
+	if opt == nil {
+		opt = &baidupcs.TransferOption{}
+	}
+	if err = CheckTransferOption(opt); err != nil {
+		return "", nil, err
+	}
+
+	featureStr, tokens, err := accessShare(pcs, link, extraCode)
+	if err != nil {
+		return "", nil, err
+	}
+
 	featureMap := map[string]string{
 		"bdstoken": tokens["bdstoken"],
 		"root":     "1",
@@ -85,34 +116,120 @@ func RunShareTransfer(params []string, opt *baidupcs.TransferOption) {
 	transMetas := pcs.ExtractShareInfo(queryShareInfoUrl, tokens["shareid"], tokens["share_uk"], tokens["bdstoken"])
 
 	if transMetas["ErrMsg"] != "success" {
-		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, transMetas["ErrMsg"])
-		return
+		return "", nil, errors.New(transMetas["ErrMsg"])
+	}
+
+	if isSelectiveTransfer(opt) || opt.OnDup == baidupcs.TransferOnDupSkip {
+		var selected baidupcs.FileDirectoryList
+		selected, err = selectShareFiles(pcs, featureStr, tokens, opt)
+		if err != nil {
+			return "", nil, err
+		}
+		if opt.OnDup == baidupcs.TransferOnDupSkip {
+			selected, err = skipExistingFiles(pcs, saveDir, selected)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		if len(selected) == 0 {
+			return "", nil, ErrTransferNothing
+		}
+		transMetas["fs_id"] = fsIDListString(selected)
+		transMetas["item_num"] = strconv.Itoa(len(selected))
+		transMetas["filename"] = selected[0].Filename
+	}
+
+	if opt.OnDup == baidupcs.TransferOnDupRename || opt.OnDup == baidupcs.TransferOnDupOverwrite {
+		shareURL, err := url.Parse(transMetas["shareUrl"])
+		if err != nil {
+			return "", nil, err
+		}
+		uv := shareURL.Query()
+		if opt.OnDup == baidupcs.TransferOnDupRename {
+			uv.Set("ondup", "newcopy")
+		} else {
+			uv.Set("ondup", "overwrite")
+		}
+		shareURL.RawQuery = uv.Encode()
+		transMetas["shareUrl"] = shareURL.String()
 	}
-	transMetas["path"] = GetActiveUser().Workdir
+
+	transMetas["path"] = saveDir
 	if transMetas["item_num"] != "1" && opt.Collect {
 		transMetas["filename"] += "等文件"
-		transMetas["path"] = path.Join(GetActiveUser().Workdir, transMetas["filename"])
+		transMetas["path"] = path.Join(saveDir, transMetas["filename"])
 		pcs.Mkdir(transMetas["path"])
 	}
 	transMetas["referer"] = "https://pan.baidu.com/s/" + featureStr
 	pcs.UpdatePCSCookies(true)
 	resp := pcs.GenerateRequestQuery("POST", transMetas)
 	if resp["ErrNo"] != "0" {
-		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, resp["ErrMsg"])
 		//if resp["ErrNo"] == "4" {
 		//	transMetas["shorturl"] = featureStr
 		//	pcs.SuperTransfer(transMetas, resp["limit"]) // 试验性功能, 当前未启用
 		//}
-		return
+		return "", nil, errors.New(resp["ErrMsg"])
 	}
+
+	savedName = resp["filename"]
 	if opt.Collect {
-		resp["filename"] = transMetas["filename"]
+		savedName = transMetas["filename"]
+	}
+	for _, name := range strings.Split(resp["filenames"], ",") {
+		paths = append(paths, path.Join(transMetas["path"], name))
+	}
+	return savedName, paths, nil
+}
+
+// transferSaveDir 返回转存到的网盘目录, 指定的目录不存在时创建
+func transferSaveDir(pcs *baidupcs.BaiduPCS, opt *baidupcs.TransferOption) (string, error) {
+	if opt.SaveTo == "" {
+		return GetActiveUser().Workdir, nil
+	}
+	saveDir := GetActiveUser().PathJoin(opt.SaveTo)
+	pcsError := pcs.Mkdir(saveDir)
+	if pcsError != nil && pcsError.GetRemoteErrCode() != 31061 { // 31061: 目录已存在
+		return "", fmt.Errorf("创建目录 %s 失败, %s", saveDir, pcsError)
+	}
+	return saveDir, nil
+}
+
+// RunShareTransfer 执行分享链接转存到网盘
+func RunShareTransfer(params []string, opt *baidupcs.TransferOption) {
+	var link string
+	var extraCode string
+	if len(params) == 1 {
+		link = params[0]
+		if !isShareLink(link) {
+			//RunRapidTransfer(link, opt.Rname)
+			fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, "秒传已不再被支持")
+			return
+		}
+	} else if len(params) == 2 {
+		link = params[0]
+		extraCode = params[1]
+	}
+
+	pcs := GetBaiduPCS()
+	saveDir, err := transferSaveDir(pcs, opt)
+	if err != nil {
+		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, err)
+		return
+	}
+
+	savedName, paths, err := shareTransfer(pcs, link, extraCode, saveDir, opt)
+	if err != nil {
+		fmt.Printf("%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, err)
+		return
+	}
+	if opt.SaveTo != "" {
+		fmt.Printf("%s成功, 保存了%s到 %s\n", baidupcs.OperationShareFileSavetoLocal, savedName, saveDir)
+	} else {
+		fmt.Printf("%s成功, 保存了%s到当前目录\n", baidupcs.OperationShareFileSavetoLocal, savedName)
 	}
-	fmt.Printf("%s成功, 保存了%s到当前目录\n", baidupcs.OperationShareFileSavetoLocal, resp["filename"])
 	if opt.Download {
 		fmt.Println("10s后开始下载")
 		time.Sleep(10 * time.Second)
-		paths := strings.Split(resp["filenames"], ",")
 		RunDownload(paths, nil)
 	}
 }
@@ -0,0 +1,252 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsshare"
+	"BaiduPCS-Go/pcsoutput"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchTransferParallel 批量转存默认的并发数
+	DefaultBatchTransferParallel = 2
+	// DefaultBatchTransferInterval 批量转存默认的最小间隔
+	DefaultBatchTransferInterval = 3 * time.Second
+
+	// batchTransferVerifyRetry 触发验证时重试的次数
+	batchTransferVerifyRetry = 5
+	// batchTransferMaxInterval 触发验证后增大间隔的上限
+	batchTransferMaxInterval = 2 * time.Minute
+)
+
+var (
+	// ErrBatchTransferInteractive 批量转存不支持交互式选择
+	ErrBatchTransferInteractive = errors.New("批量转存不支持交互式选择文件")
+)
+
+type (
+	// BatchTransferOptions 批量转存可选参数
+	BatchTransferOptions struct {
+		Parallel int           // 同时转存的链接数
+		Interval time.Duration // 两次转存开始的最小间隔, 避免触发验证
+		Report   string        // 结果报告文件, 扩展名为 .json 或 .ndjson 时输出对应格式, 否则输出 csv
+	}
+
+	// batchTransferResult 单个链接的转存结果
+	batchTransferResult struct {
+		Line      int    `json:"line"`
+		Link      string `json:"link"`
+		Pwd       string `json:"pwd"`
+		Status    string `json:"status"`
+		SavedName string `json:"saved_name"`
+		Message   string `json:"message"`
+		paths     []string
+	}
+
+	// rateLimiter 限制两次操作开始的最小间隔
+	rateLimiter struct {
+		interval time.Duration
+		mu       sync.Mutex
+		next     time.Time
+	}
+)
+
+// Wait 等待到允许下一次操作
+func (rl *rateLimiter) Wait() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if d := time.Until(rl.next); d > 0 {
+		time.Sleep(d)
+	}
+	rl.next = time.Now().Add(rl.interval)
+}
+
+// Backoff 加倍间隔, 并推迟下一次操作, 返回新的间隔
+func (rl *rateLimiter) Backoff() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.interval *= 2
+	if rl.interval < DefaultBatchTransferInterval {
+		rl.interval = DefaultBatchTransferInterval
+	}
+	if rl.interval > batchTransferMaxInterval {
+		rl.interval = batchTransferMaxInterval
+	}
+	rl.next = time.Now().Add(rl.interval)
+	return rl.interval
+}
+
+// reportFormat 根据报告文件的扩展名选择输出格式
+func reportFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return pcsoutput.FormatJSON
+	case ".ndjson", ".jsonl":
+		return pcsoutput.FormatNDJSON
+	}
+	return pcsoutput.FormatCSV
+}
+
+// RunShareTransferFromFile 执行从文件中读取分享链接批量转存, filename 为 - 时从标准输入读取
+func RunShareTransferFromFile(filename string, opt *baidupcs.TransferOption, bopt *BatchTransferOptions) {
+	if opt == nil {
+		opt = &baidupcs.TransferOption{}
+	}
+	if bopt == nil {
+		bopt = &BatchTransferOptions{}
+	}
+	if bopt.Parallel < 1 {
+		bopt.Parallel = DefaultBatchTransferParallel
+	}
+	if bopt.Interval < 0 {
+		bopt.Interval = 0
+	}
+	// 使用机器可读的输出格式时, 进度输出到标准错误
+	msg := pcsoutput.Messages()
+	if opt.Interactive {
+		fmt.Fprintln(msg, ErrBatchTransferInteractive)
+		return
+	}
+
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintf(msg, "打开文件失败: %s\n", err)
+			return
+		}
+		defer f.Close()
+		r = f
+	}
+	links, err := pcsshare.ParseLinks(r)
+	if err != nil {
+		fmt.Fprintf(msg, "读取文件失败: %s\n", err)
+		return
+	}
+	if len(links) == 0 {
+		fmt.Fprintln(msg, "没有找到分享链接")
+		return
+	}
+
+	saveDir, err := transferSaveDir(GetBaiduPCS(), opt)
+	if err != nil {
+		fmt.Fprintf(msg, "%s失败: %s\n", baidupcs.OperationShareFileSavetoLocal, err)
+		return
+	}
+	fmt.Fprintf(msg, "共 %d 个分享链接, 转存到 %s\n", len(links), saveDir)
+
+	var (
+		results = make([]*batchTransferResult, len(links))
+		jobs    = make(chan int, len(links))
+		limiter = &rateLimiter{interval: bopt.Interval}
+		wg      sync.WaitGroup
+		printMu sync.Mutex
+		done    int
+	)
+	for i := range links {
+		jobs <- i
+	}
+	close(jobs)
+
+	for w := 0; w < bopt.Parallel && w < len(links); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个并发使用独立的 cookies, 避免提取码验证互相影响
+			pcs := GetActiveUser().BaiduPCS()
+			for i := range jobs {
+				var (
+					sl        = links[i]
+					savedName string
+					paths     []string
+					err       error
+				)
+				for retry := 0; ; retry++ {
+					limiter.Wait()
+					savedName, paths, err = shareTransfer(pcs, sl.Link, sl.Pwd, saveDir, opt)
+					if pcsshare.Classify(err) != pcsshare.StatusVerify || retry >= batchTransferVerifyRetry {
+						break
+					}
+					// 触发验证时增大间隔, 稍后重试
+					interval := limiter.Backoff()
+					printMu.Lock()
+					fmt.Fprintf(msg, "%s: %s, 转存间隔调整为 %s, 稍后重试\n", sl.Link, err, interval)
+					printMu.Unlock()
+				}
+				res := &batchTransferResult{
+					Line:      sl.Line,
+					Link:      sl.Link,
+					Pwd:       sl.Pwd,
+					Status:    pcsshare.Classify(err),
+					SavedName: savedName,
+					paths:     paths,
+				}
+				if err != nil {
+					res.Message = err.Error()
+				}
+				results[i] = res
+
+				printMu.Lock()
+				done++
+				if err != nil {
+					fmt.Fprintf(msg, "[%d/%d] %s %s: %s\n", done, len(links), res.Status, sl.Link, err)
+				} else {
+					fmt.Fprintf(msg, "[%d/%d] %s %s: 保存了%s\n", done, len(links), res.Status, sl.Link, savedName)
+				}
+				printMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		counts = map[string]int{}
+		paths  []string
+	)
+	for _, res := range results {
+		counts[res.Status]++
+		paths = append(paths, res.paths...)
+	}
+	fmt.Fprintf(msg, "\n转存完成, 成功: %d, 链接无效: %d, 提取码错误: %d, 已失效: %d, 容量不足: %d, 触发验证: %d, 其他错误: %d\n",
+		counts[pcsshare.StatusSuccess], counts[pcsshare.StatusInvalidLink], counts[pcsshare.StatusWrongCode],
+		counts[pcsshare.StatusExpired], counts[pcsshare.StatusQuotaFull], counts[pcsshare.StatusVerify], counts[pcsshare.StatusFailed])
+
+	if bopt.Report != "" {
+		err = writeBatchTransferReport(bopt.Report, results)
+		if err != nil {
+			fmt.Fprintf(msg, "写入报告失败: %s\n", err)
+		} else {
+			fmt.Fprintf(msg, "报告已保存到 %s\n", bopt.Report)
+		}
+	}
+	if pcsoutput.IsStructured() {
+		printRecords("transfer", results)
+	}
+
+	if opt.Download && len(paths) > 0 {
+		fmt.Fprintln(msg, "10s后开始下载")
+		time.Sleep(10 * time.Second)
+		RunDownload(paths, nil)
+	}
+}
+
+// writeBatchTransferReport 写入批量转存的结果报告
+func writeBatchTransferReport(filename string, results []*batchTransferResult) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = pcsoutput.Write(f, reportFormat(filename), results)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
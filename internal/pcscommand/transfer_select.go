@@ -0,0 +1,231 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/ui"
+	"BaiduPCS-Go/pcsoutput"
+	"BaiduPCS-Go/pcsutil"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTransferNothing 没有需要转存的文件
+	ErrTransferNothing = errors.New("没有需要转存的文件")
+	// ErrTransferOnDupInvalid 未知的同名文件处理方式
+	ErrTransferOnDupInvalid = errors.New("未知的同名文件处理方式, 可选 skip, rename, overwrite")
+	// ErrTransferOnDupSkipCollect 整合到新目录时没有同名文件可跳过
+	ErrTransferOnDupSkipCollect = errors.New("-ondup skip 不能与 -collect 同时使用")
+)
+
+// CheckTransferOption 检查转存选项, 包括同名文件的处理方式
+func CheckTransferOption(opt *baidupcs.TransferOption) error {
+	switch opt.OnDup {
+	case "", baidupcs.TransferOnDupSkip, baidupcs.TransferOnDupRename, baidupcs.TransferOnDupOverwrite:
+	default:
+		return ErrTransferOnDupInvalid
+	}
+	if opt.OnDup == baidupcs.TransferOnDupSkip && opt.Collect {
+		return ErrTransferOnDupSkipCollect
+	}
+	return nil
+}
+
+// isSelectiveTransfer 是否只转存分享中的部分文件
+func isSelectiveTransfer(opt *baidupcs.TransferOption) bool {
+	return len(opt.Paths) > 0 || len(opt.Patterns) > 0 || opt.Interactive
+}
+
+// resolve 在分享根目录的文件 root 中查找路径 p, literal 为 false 时 p 的每一级都可以使用通配符
+func (sl *shareLister) resolve(root baidupcs.FileDirectoryList, p string, literal bool) (baidupcs.FileDirectoryList, error) {
+	p = strings.Trim(path.Clean(baidupcs.PathSeparator+p), baidupcs.PathSeparator)
+	if p == "" {
+		return root, nil
+	}
+
+	var (
+		names = strings.Split(p, baidupcs.PathSeparator)
+		cur   = root
+	)
+	for i, name := range names {
+		var matched baidupcs.FileDirectoryList
+		for _, fd := range cur {
+			ok := fd.Filename == name
+			if !literal {
+				var err error
+				ok, err = path.Match(name, fd.Filename)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s", p, err)
+				}
+			}
+			if ok {
+				matched = append(matched, fd)
+			}
+		}
+		if i == len(names)-1 {
+			return matched, nil
+		}
+
+		cur = nil
+		for _, fd := range matched {
+			if !fd.Isdir {
+				continue
+			}
+			sub, err := sl.list(fd.Path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", sl.relPath(fd.Path), err)
+			}
+			cur = append(cur, sub...)
+		}
+	}
+	return nil, nil
+}
+
+// selectShareFiles 按 opt 选择分享中要转存的文件, 已选中目录中的文件不会重复选中
+func selectShareFiles(pcs *baidupcs.BaiduPCS, featureStr string, tokens map[string]string, opt *baidupcs.TransferOption) (baidupcs.FileDirectoryList, error) {
+	sl := &shareLister{
+		pcs: pcs,
+		opt: baidupcs.ShareFileListOption{
+			FeatureStr: featureStr,
+			ShareID:    tokens["shareid"],
+			ShareUK:    tokens["share_uk"],
+			BDStoken:   tokens["bdstoken"],
+		},
+		rootDir: baidupcs.PathSeparator,
+	}
+	root, err := sl.list("")
+	if err != nil {
+		return nil, err
+	}
+	if len(root) > 0 {
+		sl.rootDir = path.Dir(root[0].Path)
+	}
+
+	selected := root
+	if len(opt.Paths) > 0 || len(opt.Patterns) > 0 {
+		selected = nil
+		for _, p := range opt.Paths {
+			fdl, err := sl.resolve(root, p, true)
+			if err != nil {
+				return nil, err
+			}
+			if len(fdl) == 0 {
+				return nil, fmt.Errorf("分享中不存在: %s", p)
+			}
+			selected = append(selected, fdl...)
+		}
+		for _, pattern := range opt.Patterns {
+			fdl, err := sl.resolve(root, pattern, false)
+			if err != nil {
+				return nil, err
+			}
+			if len(fdl) == 0 {
+				fmt.Fprintf(pcsoutput.Messages(), "分享中没有匹配 %s 的文件\n", pattern)
+			}
+			selected = append(selected, fdl...)
+		}
+	}
+	selected = removeNestedFiles(selected)
+
+	if opt.Interactive && len(selected) > 0 {
+		items := make([]ui.FileSearchItem, 0, len(selected))
+		for _, fd := range selected {
+			item := ui.FileSearchItem{
+				FsId:           uint64(fd.FsID),
+				Path:           sl.relPath(fd.Path),
+				ServerFilename: fd.Filename,
+				Size:           uint64(fd.Size),
+				Md5:            fd.MD5,
+				ServerMtime:    fd.Mtime,
+			}
+			if fd.Isdir {
+				item.IsDir = 1
+			}
+			items = append(items, item)
+		}
+		chosen, err := ui.NewFileSelector(items).SelectFiles("转存")
+		if err != nil {
+			return nil, err
+		}
+		byFsID := make(map[int64]*baidupcs.FileDirectory, len(selected))
+		for _, fd := range selected {
+			byFsID[fd.FsID] = fd
+		}
+		selected = make(baidupcs.FileDirectoryList, 0, len(chosen))
+		for _, item := range chosen {
+			selected = append(selected, byFsID[int64(item.FsId)])
+		}
+	}
+	return selected, nil
+}
+
+// removeNestedFiles 去除重复的文件, 和已在其他选中目录中的文件
+func removeNestedFiles(fdl baidupcs.FileDirectoryList) baidupcs.FileDirectoryList {
+	sorted := make(baidupcs.FileDirectoryList, len(fdl))
+	copy(sorted, fdl)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	var (
+		dirs = map[string]bool{}
+		keep = map[int64]bool{}
+	)
+	for _, fd := range sorted {
+		if keep[fd.FsID] || pcsutil.IsUnderDirs(fd.Path, dirs) {
+			continue
+		}
+		keep[fd.FsID] = true
+		if fd.Isdir {
+			dirs[fd.Path] = true
+		}
+	}
+
+	// 保持原来的顺序
+	result := make(baidupcs.FileDirectoryList, 0, len(keep))
+	for _, fd := range fdl {
+		if keep[fd.FsID] {
+			result = append(result, fd)
+			delete(keep, fd.FsID)
+		}
+	}
+	return result
+}
+
+// skipExistingFiles 去除目标目录 saveDir 中已存在同名文件的文件
+func skipExistingFiles(pcs *baidupcs.BaiduPCS, saveDir string, fdl baidupcs.FileDirectoryList) (baidupcs.FileDirectoryList, error) {
+	existing, pcsError := pcs.FilesDirectoriesList(saveDir, baidupcs.DefaultOrderOptions)
+	if pcsError != nil {
+		if pcsError.GetRemoteErrCode() == 31066 { // 31066: 目录不存在
+			return fdl, nil
+		}
+		return nil, pcsError
+	}
+
+	names := make(map[string]bool, len(existing))
+	for _, fd := range existing {
+		names[fd.Filename] = true
+	}
+	result := make(baidupcs.FileDirectoryList, 0, len(fdl))
+	for _, fd := range fdl {
+		if names[fd.Filename] {
+			fmt.Fprintf(pcsoutput.Messages(), "跳过已存在的文件/目录: %s\n", path.Join(saveDir, fd.Filename))
+			continue
+		}
+		result = append(result, fd)
+	}
+	return result, nil
+}
+
+// fsIDListString 转存请求中的 fsidlist 参数
+func fsIDListString(fdl baidupcs.FileDirectoryList) string {
+	ids := make([]string, 0, len(fdl))
+	for _, fd := range fdl {
+		ids = append(ids, strconv.FormatInt(fd.FsID, 10))
+	}
+	return "[" + strings.Join(ids, ",") + "]"
+}
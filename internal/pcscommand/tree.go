@@ -2,6 +2,7 @@ package pcscommand
 
 import (
 	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/pcsoutput"
 	"fmt"
 	"strings"
 )
@@ -16,23 +17,12 @@ type (
 	TreeOptions struct {
 		Depth    int
 		ShowFsid bool
+		Offline  bool // 从离线索引获取
 	}
 )
 
-func getTree(pcspath string, depth int, option *TreeOptions) {
-	var (
-		err   error
-		files baidupcs.FileDirectoryList
-	)
-	if depth == 0 {
-		err := matchPathByShellPatternOnce(&pcspath)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-	}
-
-	files, err = GetBaiduPCS().FilesDirectoriesList(pcspath, baidupcs.DefaultOrderOptions)
+func getTree(lister fileLister, pcspath string, depth int, option *TreeOptions) {
+	files, err := lister.FilesDirectoriesList(pcspath, baidupcs.DefaultOrderOptions)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -51,7 +41,7 @@ func getTree(pcspath string, depth int, option *TreeOptions) {
 				fmt.Printf("%v%v %v/\n", indentPrefixStr, pathPrefix, file.Filename)
 			}
 			if option.Depth < 0 || depth < option.Depth {
-				getTree(file.Path, depth+1, option)
+				getTree(lister, file.Path, depth+1, option)
 			}
 			continue
 		}
@@ -69,7 +59,39 @@ func getTree(pcspath string, depth int, option *TreeOptions) {
 	return
 }
 
+// collectTree 递归收集树形图的机器可读输出, 获取子目录列表失败时输出错误并跳过
+func collectTree(lister fileLister, pcspath string, depth int, option *TreeOptions, records []treeRecord) []treeRecord {
+	files, err := lister.FilesDirectoriesList(pcspath, baidupcs.DefaultOrderOptions)
+	if err != nil {
+		pcsoutput.PrintError("tree", err)
+		return records
+	}
+
+	for _, file := range files {
+		records = append(records, treeRecord{Depth: depth, fileRecord: newFileRecord(file)})
+		if file.Isdir && (option.Depth < 0 || depth < option.Depth) {
+			records = collectTree(lister, file.Path, depth+1, option, records)
+		}
+	}
+	return records
+}
+
 // RunTree 列出树形图
 func RunTree(path string, depth int, option *TreeOptions) {
-	getTree(path, depth, option)
+	lister, err := newFileLister(option.Offline)
+	if err != nil {
+		printCommandError("tree", err)
+		return
+	}
+
+	err = matchListerPathOnce(lister, &path)
+	if err != nil {
+		printCommandError("tree", err)
+		return
+	}
+	if pcsoutput.IsStructured() {
+		printRecords("tree", collectTree(lister, path, depth, option, []treeRecord{}))
+		return
+	}
+	getTree(lister, path, depth, option)
 }
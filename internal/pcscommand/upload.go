@@ -8,6 +8,7 @@ import (
 	"BaiduPCS-Go/pcsutil"
 	"BaiduPCS-Go/pcsutil/checksum"
 	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/pcsutil/taskframework"
 	"fmt"
 	"os"
@@ -31,6 +32,8 @@ type (
 		NoSplitFile     bool   // 禁用分片上传
 		Policy          string // 同名文件处理策略
 		NoFilenameCheck bool   // 禁用文件名合法性检查
+		Encrypt         bool   // 加密上传
+		EncryptName     bool   // 加密上传, 同时加密文件名
 	}
 )
 
@@ -77,6 +80,20 @@ func RunUpload(localPaths []string, savePath string, opt *UploadOptions) {
 		return
 	}
 
+	var cipher *e2ee.Cipher
+	if opt.Encrypt || opt.EncryptName {
+		cipher, err = pcsconfig.Config.Cipher()
+		if err != nil {
+			if err == pcsconfig.ErrEncryptPassphraseNotSet {
+				fmt.Printf("未设置加密口令, 请先运行: config set -encrypt_passphrase <口令>\n")
+				return
+			}
+			fmt.Printf("初始化加密错误: %s\n", err)
+			return
+		}
+		fmt.Printf("[0] 提示: 加密上传将禁用秒传和断点续传\n")
+	}
+
 	// 打开上传状态
 	uploadDatabase, err := pcsupload.NewUploadingDatabase()
 	if err != nil {
@@ -127,6 +144,12 @@ func RunUpload(localPaths []string, savePath string, opt *UploadOptions) {
 				opt.Load = 1
 			}
 			subSavePath = strings.TrimPrefix(walkedFiles[k3], localPathDir)
+			switch {
+			case opt.EncryptName:
+				subSavePath = cipher.EncryptPath(subSavePath)
+			case opt.Encrypt:
+				subSavePath += e2ee.Suffix
+			}
 			if !opt.NoFilenameCheck && !pcsutil.ChPathLegal(walkedFiles[k3]) {
 				fmt.Printf("[0] %s 文件路径含有非法字符，已跳过!\n", walkedFiles[k3])
 				continue
@@ -143,6 +166,7 @@ func RunUpload(localPaths []string, savePath string, opt *UploadOptions) {
 				NoSplitFile:       opt.NoSplitFile,
 				UploadStatistic:   statistic,
 				Policy:            opt.Policy,
+				Cipher:            cipher,
 			}, opt.MaxRetry)
 			if LoadCount >= opt.Load {
 				LoadCount = opt.Load
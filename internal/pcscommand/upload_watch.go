@@ -0,0 +1,195 @@
+package pcscommand
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcsutil"
+	"BaiduPCS-Go/pcsutil/checksum"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/fswatch"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultWatchDebounce 默认文件静默多久后上传
+	DefaultWatchDebounce = 2 * time.Second
+)
+
+type (
+	// WatchOptions 监听上传可选项
+	WatchOptions struct {
+		Debounce   time.Duration // 文件静默多久后上传
+		SyncDelete bool          // 同步删除和重命名到网盘
+	}
+)
+
+// RunUploadWatch 上传本地目录, 之后持续监听目录变化, 自动上传新建和修改的文件
+func RunUploadWatch(localDir, savePath string, opt *UploadOptions, watchOpt *WatchOptions) {
+	if watchOpt == nil {
+		watchOpt = &WatchOptions{}
+	}
+	if watchOpt.Debounce <= 0 {
+		watchOpt.Debounce = DefaultWatchDebounce
+	}
+
+	localDir, err := filepath.Abs(localDir)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+	fi, err := os.Stat(localDir)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+	if !fi.IsDir() {
+		fmt.Printf("%s 不是目录, 无法监听\n", localDir)
+		return
+	}
+
+	err = matchPathByShellPatternOnce(&savePath)
+	if err != nil {
+		fmt.Printf("警告: 上传文件, 获取网盘路径 %s 错误, %s\n", savePath, err)
+	}
+
+	// 先开始监听, 以免遗漏首次上传期间发生的变化
+	watcher, err := fswatch.NewWatcher(localDir)
+	if err != nil {
+		fmt.Printf("监听目录 %s 错误: %s\n", localDir, err)
+		return
+	}
+	defer watcher.Close()
+
+	debouncer := fswatch.NewDebouncer(watchOpt.Debounce)
+	go func() {
+		for err := range watcher.Errors {
+			fmt.Printf("监听错误: %s\n", err)
+		}
+	}()
+	go debouncer.Run(watcher.Events)
+
+	// 上传整个目录, 与 upload 命令的行为一致
+	RunUpload([]string{localDir}, savePath, opt)
+
+	// 打开上传状态
+	uploadDatabase, err := pcsupload.NewUploadingDatabase()
+	if err != nil {
+		fmt.Printf("打开上传未完成数据库错误: %s\n", err)
+		return
+	}
+	defer uploadDatabase.Close()
+
+	var (
+		pcs        = GetBaiduPCS()
+		remoteBase = path.Join(savePath, filepath.Base(localDir))
+		toRemote   = func(p string) string {
+			rel, err := filepath.Rel(localDir, p)
+			if err != nil {
+				return ""
+			}
+			return path.Join(remoteBase, filepath.ToSlash(rel))
+		}
+	)
+
+	fmt.Printf("\n正在监听目录 %s, 文件变化将自动上传到 %s, 按 Ctrl+C 结束\n", localDir, remoteBase)
+
+	for events := range debouncer.Events() {
+		var uploadPaths []string
+		for _, event := range events {
+			pcsCommandVerbose.Infof("%s %s\n", event.Op, event.Path)
+			switch event.Op {
+			case fswatch.Write:
+				uploadPaths = append(uploadPaths, event.Path)
+			case fswatch.Overflow:
+				// 遗漏的变化无法得知, 重新上传整个目录
+				fmt.Printf("监听事件队列溢出, 重新上传目录 %s\n", localDir)
+				RunUpload([]string{localDir}, savePath, opt)
+			case fswatch.Remove:
+				if !watchOpt.SyncDelete {
+					continue
+				}
+				pcsError := pcs.Remove(toRemote(event.Path))
+				if pcsError != nil {
+					fmt.Printf("删除网盘文件 %s 失败: %s\n", toRemote(event.Path), pcsError)
+					continue
+				}
+				fmt.Printf("删除网盘文件: %s\n", toRemote(event.Path))
+			case fswatch.Rename:
+				if watchOpt.SyncDelete {
+					pcsError := pcs.Rename(toRemote(event.OldPath), toRemote(event.Path))
+					if pcsError == nil {
+						fmt.Printf("重命名网盘文件: %s -> %s\n", toRemote(event.OldPath), toRemote(event.Path))
+						continue
+					}
+					fmt.Printf("重命名网盘文件 %s 失败: %s, 重新上传\n", toRemote(event.OldPath), pcsError)
+				}
+				// 不同步重命名, 或重命名失败, 上传新路径下的文件
+				walkedFiles, err := pcsutil.WalkDir(event.Path, "")
+				if err != nil {
+					continue
+				}
+				uploadPaths = append(uploadPaths, walkedFiles...)
+			}
+		}
+
+		if len(uploadPaths) > 0 {
+			uploadWatchedFiles(pcs, uploadDatabase, uploadPaths, toRemote, opt)
+		}
+	}
+}
+
+// uploadWatchedFiles 上传监听到变化的文件, 覆盖网盘中的同名文件
+func uploadWatchedFiles(pcs *baidupcs.BaiduPCS, uploadDatabase *pcsupload.UploadingDatabase, localPaths []string, toRemote func(string) string, opt *UploadOptions) {
+	var (
+		executor = &taskframework.TaskExecutor{
+			IsFailedDeque: true,
+		}
+		statistic = &pcsupload.UploadStatistic{}
+	)
+
+	for _, localPath := range localPaths {
+		// 文件可能在等待期间被删除
+		fi, err := os.Stat(localPath)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if !pcsconfig.Config.IgnoreIllegal && !pcsutil.ChPathLegal(localPath) {
+			fmt.Printf("[0] %s 文件路径含有非法字符，已跳过!\n", localPath)
+			continue
+		}
+
+		info := executor.Append(&pcsupload.UploadTaskUnit{
+			LocalFileChecksum: checksum.NewLocalFileChecksum(localPath, int(baidupcs.SliceMD5Size)),
+			SavePath:          toRemote(localPath),
+			PCS:               pcs,
+			UploadingDatabase: uploadDatabase,
+			Parallel:          opt.Parallel,
+			PrintFormat:       uploadPrintFormat(opt.Load),
+			NoRapidUpload:     opt.NoRapidUpload,
+			NoSplitFile:       opt.NoSplitFile,
+			UploadStatistic:   statistic,
+			Policy:            baidupcs.OverWritePolicy,
+		}, opt.MaxRetry)
+		fmt.Printf("[%s] 加入上传队列: %s\n", info.Id(), localPath)
+	}
+
+	if executor.Count() == 0 {
+		return
+	}
+
+	statistic.StartTimer()
+	executor.SetParallel(opt.Load)
+	executor.Execute()
+	fmt.Printf("上传结束, 时间: %s, 总大小: %s\n", statistic.Elapsed()/1e6*1e6, converter.ConvertFileSize(statistic.TotalSize()))
+
+	failedList := executor.FailedDeque()
+	if failedList != nil && failedList.Size() != 0 {
+		fmt.Printf("%d 个文件上传失败, 将在文件再次变化时重新上传\n", failedList.Size())
+	}
+}
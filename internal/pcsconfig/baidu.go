@@ -46,7 +46,8 @@ type Baidu struct {
 	RefreshToken   string `json:"refreshtoken"`
 	TokenExpiresAt int64  `json:"token_expires_at"`
 
-	Workdir string `json:"workdir"` // 工作目录
+	Workdir    string `json:"workdir"`     // 工作目录
+	DiffCursor string `json:"diff_cursor"` // 获取文件变更的 cursor
 }
 
 // BaiduPCS 初始化*baidupcs.BaiduPCS
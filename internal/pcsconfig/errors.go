@@ -15,4 +15,6 @@ var (
 	ErrConfigFileNoPermission = errors.New("config file permission denied")
 	//ErrConfigContentsParseError 解析Config数据错误
 	ErrConfigContentsParseError = errors.New("config contents parse error")
+	//ErrEncryptPassphraseNotSet 未设置加密口令
+	ErrEncryptPassphraseNotSet = errors.New("encrypt passphrase not set")
 )
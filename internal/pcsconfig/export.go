@@ -4,6 +4,7 @@ import (
 	"BaiduPCS-Go/baidupcs"
 	"BaiduPCS-Go/pcstable"
 	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/requester"
 	"fmt"
 	"os"
@@ -60,6 +61,23 @@ func (c *PCSConfig) AverageParallel() int {
 	return AverageParallel(c.MaxParallel, c.MaxDownloadLoad)
 }
 
+// Cipher 返回由加密口令生成的加解密器
+func (c *PCSConfig) Cipher() (*e2ee.Cipher, error) {
+	if c.EncryptPassphrase == "" {
+		return nil, ErrEncryptPassphraseNotSet
+	}
+	if c.cipher != nil && c.cipherPass == c.EncryptPassphrase {
+		return c.cipher, nil
+	}
+
+	cipher, err := e2ee.NewCipher(c.EncryptPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	c.cipher, c.cipherPass = cipher, c.EncryptPassphrase
+	return cipher, nil
+}
+
 // PrintTable 输出表格
 func (c *PCSConfig) PrintTable() {
 	tb := pcstable.NewTable(os.Stdout)
@@ -88,6 +106,8 @@ func (c *PCSConfig) PrintTable() {
 		[]string{"pan_ua", c.PanUA, baidupcs.NetdiskUA, "Pan 浏览器标识"},
 		[]string{"proxy", c.Proxy, "", "设置代理, 支持 http/socks5 代理"},
 		[]string{"local_addrs", c.LocalAddrs, "", "设置本地网卡地址, 多个地址用逗号隔开"},
+		[]string{"encrypt_passphrase", showPassphrase(c.EncryptPassphrase), "", "客户端加密口令, 用于 upload --encrypt 和解密下载"},
+		[]string{"completion_index", fmt.Sprint(c.CompletionIndex), "false", "tab 补全从离线索引获取, 需要先运行 index update, 索引未更新时补全结果可能过期"},
 	})
 	tb.Render()
 }
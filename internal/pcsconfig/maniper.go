@@ -258,7 +258,17 @@ func (c *PCSConfig) SetIgnoreIllegal(ignore bool) {
 	c.IgnoreIllegal = ignore
 }
 
+// SetCompletionIndex 设置 tab 补全是否从离线索引获取
+func (c *PCSConfig) SetCompletionIndex(enable bool) {
+	c.CompletionIndex = enable
+}
+
 // SetForceLogin 设置强制登录
 func (c *PCSConfig) SetForceLogin(username string) {
 	c.ForceLogin = username
 }
+
+// SetEncryptPassphrase 设置客户端加密口令
+func (c *PCSConfig) SetEncryptPassphrase(passphrase string) {
+	c.EncryptPassphrase = passphrase
+}
@@ -4,6 +4,7 @@ package pcsconfig
 import (
 	"BaiduPCS-Go/baidupcs"
 	"BaiduPCS-Go/pcsutil"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/pcsutil/jsonhelper"
 	"BaiduPCS-Go/pcsverbose"
 	"BaiduPCS-Go/requester"
@@ -61,11 +62,16 @@ type PCSConfig struct {
 	IgnoreIllegal bool   `json:"ignore_illegal"`       // 禁用上传文件名非法字符检查
 	UPolicy       string `json:"u_policy"`             // 上传重名文件处理策略
 
+	EncryptPassphrase string `json:"encrypt_passphrase"` // 客户端加密口令
+	CompletionIndex   bool   `json:"completion_index"`   // tab 补全从离线索引获取
+
 	configFilePath string
 	configFile     *os.File
 	fileMu         sync.Mutex
 	activeUser     *Baidu
 	pcs            *baidupcs.BaiduPCS
+	cipher         *e2ee.Cipher
+	cipherPass     string
 }
 
 // NewConfig 返回 PCSConfig 指针对象
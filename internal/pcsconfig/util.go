@@ -32,3 +32,10 @@ func showMaxRate(size int64) string {
 	}
 	return converter.ConvertFileSize(size, 2) + "/s"
 }
+
+func showPassphrase(passphrase string) string {
+	if passphrase == "" {
+		return ""
+	}
+	return "******"
+}
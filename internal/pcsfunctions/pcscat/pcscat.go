@@ -0,0 +1,128 @@
+// Package pcscat 按行或字节截取输出, 用于 cat, head, tail 命令
+package pcscat
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	// tailKeepSize TailWriter 缓冲区超过该大小时丢弃不需要的数据
+	tailKeepSize = 64 * 1024
+)
+
+var (
+	// ErrLimitReached 已输出足够的行数或字节数
+	ErrLimitReached = errors.New("limit reached")
+)
+
+type (
+	// LimitWriter 最多写入 Lines 行或 Bytes 字节, 达到限制后返回 ErrLimitReached.
+	// Bytes 大于 0 时按字节限制, 否则按行限制
+	LimitWriter struct {
+		W       io.Writer
+		Lines   int
+		Bytes   int64
+		reached bool
+	}
+
+	// TailWriter 只保留最后 Lines 行或 Bytes 字节. Bytes 大于 0 时按字节保留, 否则按行保留
+	TailWriter struct {
+		Lines int
+		Bytes int64
+		buf   []byte
+	}
+)
+
+// LastLinesOffset 返回 data 中最后 n 行的起始位置, 末尾的换行符不算作新的一行.
+// data 中不足 n 行时, ok 为 false, offset 为 0
+func LastLinesOffset(data []byte, n int) (offset int, ok bool) {
+	if n <= 0 {
+		return len(data), true
+	}
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for i := 0; i < n; i++ {
+		idx := bytes.LastIndexByte(data[:end], '\n')
+		if idx < 0 {
+			return 0, false
+		}
+		end = idx
+	}
+	return end + 1, true
+}
+
+// Write 实现 io.Writer
+func (lw *LimitWriter) Write(p []byte) (n int, err error) {
+	if lw.reached {
+		return 0, ErrLimitReached
+	}
+
+	data := p
+	if lw.Bytes > 0 {
+		if int64(len(data)) >= lw.Bytes {
+			data, lw.reached = data[:lw.Bytes], true
+		}
+		lw.Bytes -= int64(len(data))
+	} else {
+		if lw.Lines <= 0 {
+			return 0, ErrLimitReached
+		}
+		for i, b := range data {
+			if b != '\n' {
+				continue
+			}
+			lw.Lines--
+			if lw.Lines == 0 {
+				data, lw.reached = data[:i+1], true
+				break
+			}
+		}
+	}
+
+	n, err = lw.W.Write(data)
+	if err != nil {
+		return
+	}
+	if lw.reached {
+		return n, ErrLimitReached
+	}
+	return len(p), nil
+}
+
+// Write 实现 io.Writer
+func (tw *TailWriter) Write(p []byte) (n int, err error) {
+	tw.buf = append(tw.buf, p...)
+	if len(tw.buf) <= tailKeepSize {
+		return len(p), nil
+	}
+
+	var offset int
+	if tw.Bytes > 0 {
+		if int64(len(tw.buf)) > tw.Bytes {
+			offset = len(tw.buf) - int(tw.Bytes)
+		}
+	} else {
+		// 保留末尾不完整的一行
+		offset, _ = LastLinesOffset(tw.buf, tw.Lines+1)
+	}
+	if offset > tailKeepSize {
+		tw.buf = append(tw.buf[:0], tw.buf[offset:]...)
+	}
+	return len(p), nil
+}
+
+// Tail 返回保留的数据
+func (tw *TailWriter) Tail() []byte {
+	if tw.Bytes > 0 {
+		if int64(len(tw.buf)) > tw.Bytes {
+			return tw.buf[len(tw.buf)-int(tw.Bytes):]
+		}
+		return tw.buf
+	}
+	offset, _ := LastLinesOffset(tw.buf, tw.Lines)
+	return tw.buf[offset:]
+}
@@ -0,0 +1,84 @@
+package pcscat_test
+
+import (
+	"BaiduPCS-Go/internal/pcsfunctions/pcscat"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLastLinesOffset(t *testing.T) {
+	cases := []struct {
+		data   string
+		n      int
+		offset int
+		ok     bool
+	}{
+		{"a\nb\nc\n", 2, 2, true},
+		{"a\nb\nc", 2, 2, true},
+		{"a\nb\nc\n", 3, 0, false},
+		{"a\nb\nc\n", 0, 6, true},
+		{"\n\n", 1, 1, true},
+		{"", 1, 0, false},
+	}
+	for _, c := range cases {
+		offset, ok := pcscat.LastLinesOffset([]byte(c.data), c.n)
+		if offset != c.offset || ok != c.ok {
+			t.Fatalf("LastLinesOffset(%q, %d) = %d, %v, want %d, %v", c.data, c.n, offset, ok, c.offset, c.ok)
+		}
+	}
+}
+
+func TestLimitWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lw := &pcscat.LimitWriter{W: buf, Lines: 2}
+	if _, err := lw.Write([]byte("a\nb")); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if _, err := lw.Write([]byte("c\nd\n")); err != pcscat.ErrLimitReached {
+		t.Fatalf("want ErrLimitReached, got %v", err)
+	}
+	if buf.String() != "a\nbc\n" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	lw = &pcscat.LimitWriter{W: buf, Bytes: 3}
+	lw.Write([]byte("ab"))
+	if _, err := lw.Write([]byte("cd")); err != pcscat.ErrLimitReached {
+		t.Fatalf("want ErrLimitReached, got %v", err)
+	}
+	if _, err := lw.Write([]byte("e\n")); err != pcscat.ErrLimitReached {
+		t.Fatalf("want ErrLimitReached, got %v", err)
+	}
+	if buf.String() != "abc" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestTailWriter(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 100000; i++ {
+		sb.WriteString("line\n")
+	}
+	sb.WriteString("x\ny\nz")
+	data := sb.String()
+
+	tw := &pcscat.TailWriter{Lines: 2}
+	for i := 0; i < len(data); i += 4096 {
+		end := i + 4096
+		if end > len(data) {
+			end = len(data)
+		}
+		tw.Write([]byte(data[i:end]))
+	}
+	if string(tw.Tail()) != "y\nz" {
+		t.Fatalf("unexpected tail: %q", tw.Tail())
+	}
+
+	tw = &pcscat.TailWriter{Bytes: 4}
+	tw.Write([]byte(data))
+	if string(tw.Tail()) != "\ny\nz" {
+		t.Fatalf("unexpected tail: %q", tw.Tail())
+	}
+}
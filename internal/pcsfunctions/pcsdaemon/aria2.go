@@ -0,0 +1,468 @@
+package pcsdaemon
+
+import (
+	"BaiduPCS-Go/pcsutil/jsonrpc"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// aria2ErrCode aria2 的 JSON-RPC 错误码
+	aria2ErrCode = 1
+
+	aria2TokenPrefix = "token:"
+)
+
+var (
+	errAria2Unauthorized = jsonrpc.NewError(aria2ErrCode, "Unauthorized")
+	errAria2GIDNotFound  = errors.New("GID is not found")
+)
+
+type (
+	// AddURIFunc 添加 aria2.addUri 的下载任务, 返回加入的任务id
+	AddURIFunc func(uri, dir string) (ids []string, err error)
+
+	// Aria2 在 TaskManager 的下载任务上实现部分 aria2 JSON-RPC 接口
+	Aria2 struct {
+		TaskManager *TaskManager
+		Secret      string // 对应 aria2 的 --rpc-secret
+		Version     string
+		AddURI      AddURIFunc
+
+		followedBy map[string][]string // 一个 uri 对应多个文件时, 第一个任务之后的任务
+		mu         sync.Mutex
+	}
+
+	// aria2Call system.multicall 的参数
+	aria2Call struct {
+		MethodName string          `json:"methodName"`
+		Params     json.RawMessage `json:"params"`
+	}
+)
+
+// NewAria2 初始化 Aria2
+func NewAria2(tm *TaskManager, secret, version string, addURI AddURIFunc) *Aria2 {
+	return &Aria2{
+		TaskManager: tm,
+		Secret:      secret,
+		Version:     version,
+		AddURI:      addURI,
+		followedBy:  map[string][]string{},
+	}
+}
+
+// gid 任务id 转为 aria2 的 gid
+func gid(id string) string {
+	n, _ := strconv.ParseUint(id, 10, 64)
+	return fmt.Sprintf("%016x", n)
+}
+
+// taskID aria2 的 gid 转为任务id
+func taskID(gid string) (string, error) {
+	n, err := strconv.ParseUint(gid, 16, 64)
+	if err != nil {
+		return "", errAria2GIDNotFound
+	}
+	return strconv.FormatUint(n, 10), nil
+}
+
+// params 解析参数, 检查 token, 返回去掉 token 的参数
+func (a *Aria2) params(raw json.RawMessage) ([]json.RawMessage, error) {
+	var params []json.RawMessage
+	if len(raw) > 0 {
+		err := json.Unmarshal(raw, &params)
+		if err != nil {
+			return nil, jsonrpc.NewInvalidParamsError(err)
+		}
+	}
+
+	var token string
+	if len(params) > 0 && json.Unmarshal(params[0], &token) == nil && strings.HasPrefix(token, aria2TokenPrefix) {
+		params = params[1:]
+	} else {
+		token = ""
+	}
+	if a.Secret != "" && token != aria2TokenPrefix+a.Secret {
+		return nil, errAria2Unauthorized
+	}
+	return params, nil
+}
+
+// param 解析第 i 个参数, 参数不存在时不修改 v
+func param(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) {
+		return nil
+	}
+	err := json.Unmarshal(params[i], v)
+	if err != nil {
+		return jsonrpc.NewInvalidParamsError(err)
+	}
+	return nil
+}
+
+func (a *Aria2) handle(f func(params []json.RawMessage) (interface{}, error)) jsonrpc.HandlerFunc {
+	return func(raw json.RawMessage) (interface{}, error) {
+		params, err := a.params(raw)
+		if err != nil {
+			return nil, err
+		}
+		result, err := f(params)
+		if err != nil {
+			if _, ok := err.(*jsonrpc.Error); !ok {
+				err = jsonrpc.NewError(aria2ErrCode, err.Error())
+			}
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// handleGID 第一个参数为 gid 的方法
+func (a *Aria2) handleGID(f func(id string) error) jsonrpc.HandlerFunc {
+	return a.handle(func(params []json.RawMessage) (interface{}, error) {
+		var g string
+		err := param(params, 0, &g)
+		if err != nil {
+			return nil, err
+		}
+		id, err := taskID(g)
+		if err != nil {
+			return nil, err
+		}
+		err = f(id)
+		if err == ErrTaskNotFound {
+			return nil, errAria2GIDNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return g, nil
+	})
+}
+
+// Register 注册 aria2 的方法
+func (a *Aria2) Register(server *jsonrpc.Server) {
+	methods := map[string]jsonrpc.HandlerFunc{
+		"aria2.addUri":               a.handle(a.addURI),
+		"aria2.tellStatus":           a.handle(a.tellStatus),
+		"aria2.tellActive":           a.handle(a.tellActive),
+		"aria2.tellWaiting":          a.handle(a.tellWaiting),
+		"aria2.tellStopped":          a.handle(a.tellStopped),
+		"aria2.pause":                a.handleGID(a.TaskManager.Pause),
+		"aria2.forcePause":           a.handleGID(a.TaskManager.Pause),
+		"aria2.unpause":              a.handleGID(a.TaskManager.Resume),
+		"aria2.remove":               a.handleGID(a.TaskManager.Cancel),
+		"aria2.forceRemove":          a.handleGID(a.TaskManager.Cancel),
+		"aria2.removeDownloadResult": a.handleGID(a.TaskManager.Remove),
+		"aria2.purgeDownloadResult":  a.handle(a.purgeDownloadResult),
+		"aria2.getGlobalStat":        a.handle(a.getGlobalStat),
+		"aria2.getVersion":           a.handle(a.getVersion),
+	}
+	methods["system.multicall"] = func(raw json.RawMessage) (interface{}, error) {
+		return a.multicall(server, raw)
+	}
+	methods["system.listMethods"] = func(json.RawMessage) (interface{}, error) {
+		names := make([]string, 0, len(methods))
+		for name := range methods {
+			names = append(names, name)
+		}
+		return names, nil
+	}
+
+	for name, handler := range methods {
+		server.Register(name, handler)
+	}
+}
+
+// multicall 依次调用多个方法, 成功的结果放在数组中, 失败时返回错误结构
+func (a *Aria2) multicall(server *jsonrpc.Server, raw json.RawMessage) (interface{}, error) {
+	var params [][]*aria2Call
+	err := json.Unmarshal(raw, &params)
+	if err != nil || len(params) != 1 {
+		return nil, jsonrpc.NewInvalidParamsError(errors.New("expected an array of calls"))
+	}
+
+	results := make([]interface{}, 0, len(params[0]))
+	for _, call := range params[0] {
+		if call == nil || call.MethodName == "system.multicall" {
+			results = append(results, jsonrpc.NewError(aria2ErrCode, "Recursive system.multicall forbidden."))
+			continue
+		}
+		resp := server.Call(&jsonrpc.Request{
+			JSONRPC: jsonrpc.Version,
+			ID:      json.RawMessage("0"),
+			Method:  call.MethodName,
+			Params:  call.Params,
+		})
+		if resp.Error != nil {
+			results = append(results, resp.Error)
+			continue
+		}
+		results = append(results, []json.RawMessage{resp.Result})
+	}
+	return results, nil
+}
+
+// addURI aria2.addUri([secret, ]uris[, options[, position]])
+func (a *Aria2) addURI(params []json.RawMessage) (interface{}, error) {
+	var (
+		uris    []string
+		options map[string]interface{}
+	)
+	err := param(params, 0, &uris)
+	if err != nil {
+		return nil, err
+	}
+	err = param(params, 1, &options)
+	if err != nil {
+		return nil, err
+	}
+	if len(uris) == 0 {
+		return nil, jsonrpc.NewInvalidParamsError(errors.New("no URI to download"))
+	}
+
+	dir, _ := options["dir"].(string)
+	// 多个 uri 在 aria2 中为同一个文件的镜像, 这里只使用第一个
+	ids, err := a.AddURI(uris[0], dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no file to download")
+	}
+
+	if len(ids) > 1 {
+		a.mu.Lock()
+		a.followedBy[ids[0]] = ids[1:]
+		a.mu.Unlock()
+	}
+	return gid(ids[0]), nil
+}
+
+// status 转为 aria2 的任务状态, keys 不为空时只返回 keys 中的字段
+func (a *Aria2) status(info *TaskInfo, keys []string) map[string]interface{} {
+	var status string
+	switch info.State {
+	case TaskStatePending:
+		status = "waiting"
+	case TaskStateRunning:
+		status = "active"
+	case TaskStatePaused:
+		status = "paused"
+	case TaskStateSucceeded:
+		status = "complete"
+	case TaskStateFailed:
+		status = "error"
+	case TaskStateCanceled:
+		status = "removed"
+	}
+
+	var (
+		errorCode  = "0"
+		length     = strconv.FormatInt(info.TotalSize, 10)
+		completed  = strconv.FormatInt(info.Transferred, 10)
+		followedBy []string
+	)
+	if info.State == TaskStateFailed {
+		errorCode = strconv.Itoa(aria2ErrCode)
+	}
+
+	a.mu.Lock()
+	for _, id := range a.followedBy[info.ID] {
+		followedBy = append(followedBy, gid(id))
+	}
+	a.mu.Unlock()
+
+	m := map[string]interface{}{
+		"gid":             gid(info.ID),
+		"status":          status,
+		"totalLength":     length,
+		"completedLength": completed,
+		"uploadLength":    "0",
+		"downloadSpeed":   strconv.FormatInt(info.Speed, 10),
+		"uploadSpeed":     "0",
+		"connections":     "0",
+		"numPieces":       "1",
+		"pieceLength":     length,
+		"errorCode":       errorCode,
+		"errorMessage":    info.Error,
+		"dir":             filepath.Dir(info.Target),
+		"files": []map[string]interface{}{
+			{
+				"index":           "1",
+				"path":            info.Target,
+				"length":          length,
+				"completedLength": completed,
+				"selected":        "true",
+				"uris": []map[string]string{
+					{
+						"uri":    info.Source,
+						"status": "used",
+					},
+				},
+			},
+		},
+	}
+	if followedBy != nil {
+		m["followedBy"] = followedBy
+	}
+
+	if len(keys) == 0 {
+		return m
+	}
+	filtered := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if v, ok := m[key]; ok {
+			filtered[key] = v
+		}
+	}
+	return filtered
+}
+
+// downloads 列出下载任务
+func (a *Aria2) downloads(match func(state TaskState) bool) []*TaskInfo {
+	var infos []*TaskInfo
+	for _, info := range a.TaskManager.List() {
+		if info.Type == TaskTypeDownload && match(info.State) {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// tellStatus aria2.tellStatus([secret, ]gid[, keys])
+func (a *Aria2) tellStatus(params []json.RawMessage) (interface{}, error) {
+	var (
+		g    string
+		keys []string
+	)
+	err := param(params, 0, &g)
+	if err != nil {
+		return nil, err
+	}
+	err = param(params, 1, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := taskID(g)
+	if err != nil {
+		return nil, err
+	}
+	info, err := a.TaskManager.Get(id)
+	if err != nil || info.Type != TaskTypeDownload {
+		return nil, errAria2GIDNotFound
+	}
+	return a.status(info, keys), nil
+}
+
+// tellActive aria2.tellActive([secret][, keys])
+func (a *Aria2) tellActive(params []json.RawMessage) (interface{}, error) {
+	var keys []string
+	err := param(params, 0, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []map[string]interface{}{}
+	for _, info := range a.downloads(func(state TaskState) bool {
+		return state == TaskStateRunning
+	}) {
+		statuses = append(statuses, a.status(info, keys))
+	}
+	return statuses, nil
+}
+
+// tellRange aria2.tellWaiting 和 aria2.tellStopped 的参数为 offset, num[, keys]
+func (a *Aria2) tellRange(params []json.RawMessage, match func(state TaskState) bool) (interface{}, error) {
+	var (
+		offset, num int
+		keys        []string
+	)
+	for i, v := range []interface{}{&offset, &num, &keys} {
+		err := param(params, i, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	infos := a.downloads(match)
+	if offset < 0 {
+		// 负数时从末尾开始, 倒序返回
+		offset = len(infos) + offset
+		for i, j := 0, len(infos)-1; i < j; i, j = i+1, j-1 {
+			infos[i], infos[j] = infos[j], infos[i]
+		}
+		offset = len(infos) - 1 - offset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	statuses := []map[string]interface{}{}
+	for i := offset; i < len(infos) && len(statuses) < num; i++ {
+		statuses = append(statuses, a.status(infos[i], keys))
+	}
+	return statuses, nil
+}
+
+// tellWaiting aria2.tellWaiting([secret, ]offset, num[, keys])
+func (a *Aria2) tellWaiting(params []json.RawMessage) (interface{}, error) {
+	return a.tellRange(params, func(state TaskState) bool {
+		return state == TaskStatePending || state == TaskStatePaused
+	})
+}
+
+// tellStopped aria2.tellStopped([secret, ]offset, num[, keys])
+func (a *Aria2) tellStopped(params []json.RawMessage) (interface{}, error) {
+	return a.tellRange(params, func(state TaskState) bool {
+		switch state {
+		case TaskStateSucceeded, TaskStateFailed, TaskStateCanceled:
+			return true
+		}
+		return false
+	})
+}
+
+// purgeDownloadResult aria2.purgeDownloadResult([secret])
+func (a *Aria2) purgeDownloadResult(params []json.RawMessage) (interface{}, error) {
+	a.TaskManager.Purge()
+	return "OK", nil
+}
+
+// getGlobalStat aria2.getGlobalStat([secret])
+func (a *Aria2) getGlobalStat(params []json.RawMessage) (interface{}, error) {
+	var speed, active, waiting, stopped int64
+	for _, info := range a.downloads(func(TaskState) bool { return true }) {
+		switch info.State {
+		case TaskStateRunning:
+			active++
+			speed += info.Speed
+		case TaskStatePending, TaskStatePaused:
+			waiting++
+		default:
+			stopped++
+		}
+	}
+	return map[string]string{
+		"downloadSpeed":   strconv.FormatInt(speed, 10),
+		"uploadSpeed":     "0",
+		"numActive":       strconv.FormatInt(active, 10),
+		"numWaiting":      strconv.FormatInt(waiting, 10),
+		"numStopped":      strconv.FormatInt(stopped, 10),
+		"numStoppedTotal": strconv.FormatInt(stopped, 10),
+	}, nil
+}
+
+// getVersion aria2.getVersion([secret])
+func (a *Aria2) getVersion(params []json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"version":         a.Version,
+		"enabledFeatures": []string{},
+	}, nil
+}
@@ -0,0 +1,44 @@
+package pcsdaemon_test
+
+import (
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdaemon"
+	"BaiduPCS-Go/pcsutil/jsonrpc"
+	"encoding/json"
+	"testing"
+)
+
+func TestAria2(t *testing.T) {
+	server := jsonrpc.NewServer()
+	aria2 := pcsdaemon.NewAria2(pcsdaemon.NewTaskManager(1, 0), "secret", "test", func(uri, dir string) ([]string, error) {
+		if uri != "/a" || dir != "/tmp" {
+			t.Fatalf("unexpected addUri: %s, %s", uri, dir)
+		}
+		return []string{"10", "11"}, nil
+	})
+	aria2.Register(server)
+
+	cases := []struct {
+		req  string
+		want string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.getVersion"}`, `{"jsonrpc":"2.0","id":1,"error":{"code":1,"message":"Unauthorized"}}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.getVersion","params":["token:wrong"]}`, `{"jsonrpc":"2.0","id":1,"error":{"code":1,"message":"Unauthorized"}}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.getVersion","params":["token:secret"]}`, `{"jsonrpc":"2.0","id":1,"result":{"enabledFeatures":[],"version":"test"}}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.addUri","params":["token:secret",["/a"],{"dir":"/tmp"}]}`, `{"jsonrpc":"2.0","id":1,"result":"000000000000000a"}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.tellStatus","params":["token:secret","zz"]}`, `{"jsonrpc":"2.0","id":1,"error":{"code":1,"message":"GID is not found"}}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.pause","params":["token:secret","000000000000000a"]}`, `{"jsonrpc":"2.0","id":1,"error":{"code":1,"message":"GID is not found"}}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"aria2.tellActive","params":["token:secret"]}`, `{"jsonrpc":"2.0","id":1,"result":[]}`},
+		{`{"jsonrpc":"2.0","id":1,"method":"system.multicall","params":[[{"methodName":"aria2.getGlobalStat","params":["token:secret"]},{"methodName":"aria2.getGlobalStat"}]]}`,
+			`{"jsonrpc":"2.0","id":1,"result":[[{"downloadSpeed":"0","numActive":"0","numStopped":"0","numStoppedTotal":"0","numWaiting":"0","uploadSpeed":"0"}],{"code":1,"message":"Unauthorized"}]}`},
+	}
+
+	for _, c := range cases {
+		data, err := json.Marshal(server.Handle([]byte(c.req)))
+		if err != nil {
+			t.Fatalf("marshal error: %s", err)
+		}
+		if string(data) != c.want {
+			t.Fatalf("request %s:\n got %s\nwant %s", c.req, data, c.want)
+		}
+	}
+}
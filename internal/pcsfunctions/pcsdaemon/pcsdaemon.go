@@ -0,0 +1,601 @@
+// Package pcsdaemon 后台传输任务管理
+package pcsdaemon
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"BaiduPCS-Go/requester/downloader"
+	"BaiduPCS-Go/requester/transfer"
+	"BaiduPCS-Go/requester/uploader"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// TaskTypeDownload 下载任务
+	TaskTypeDownload TaskType = "download"
+	// TaskTypeUpload 上传任务
+	TaskTypeUpload TaskType = "upload"
+
+	// TaskStatePending 等待中
+	TaskStatePending TaskState = "pending"
+	// TaskStateRunning 执行中
+	TaskStateRunning TaskState = "running"
+	// TaskStatePaused 已暂停
+	TaskStatePaused TaskState = "paused"
+	// TaskStateSucceeded 已完成
+	TaskStateSucceeded TaskState = "succeeded"
+	// TaskStateFailed 失败
+	TaskStateFailed TaskState = "failed"
+	// TaskStateCanceled 已取消
+	TaskStateCanceled TaskState = "canceled"
+
+	// StrTaskCanceled 任务已取消
+	StrTaskCanceled = "任务已取消"
+)
+
+var (
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskFinished 任务已结束
+	ErrTaskFinished = errors.New("task already finished")
+	// ErrTaskNotFinished 任务未结束
+	ErrTaskNotFinished = errors.New("task not finished")
+	// ErrTaskNotPaused 任务未暂停
+	ErrTaskNotPaused = errors.New("task not paused")
+	// ErrPauseNotSupported 正在上传的任务不支持暂停
+	ErrPauseNotSupported = errors.New("pausing a running upload is not supported")
+)
+
+type (
+	// TaskType 任务类型
+	TaskType string
+
+	// TaskState 任务状态
+	TaskState string
+
+	// Task 后台任务
+	Task struct {
+		ID         string
+		Type       TaskType
+		Source     string // 下载时为网盘路径, 上传时为本地路径
+		Target     string // 下载时为本地路径, 上传时为网盘路径
+		Priority   int    // 优先级, 数值越大越先执行
+		State      TaskState
+		Err        string
+		CreateTime time.Time
+
+		unit     taskframework.TaskUnit
+		maxRetry int
+		seq      int
+		started  bool // 已交给 TaskExecutor 执行
+		canceled bool
+
+		der            *downloader.Downloader
+		derPaused      bool
+		downloadStatus transfer.DownloadStatuser
+		muer           *uploader.MultiUploader
+		uploadStatus   uploader.Status
+	}
+
+	// TaskInfo 任务信息
+	TaskInfo struct {
+		ID          string    `json:"id"`
+		Type        TaskType  `json:"type"`
+		Source      string    `json:"source"`
+		Target      string    `json:"target"`
+		Priority    int       `json:"priority"`
+		State       TaskState `json:"state"`
+		Error       string    `json:"error,omitempty"`
+		TotalSize   int64     `json:"total_size"`
+		Transferred int64     `json:"transferred"`
+		Speed       int64     `json:"speed"`   // 每秒的速度
+		Elapsed     int64     `json:"elapsed"` // 已用时间, 秒
+		Left        int64     `json:"left"`    // 预计剩余时间, 秒, -1 代表未知
+		CreateTime  int64     `json:"create_time"`
+	}
+
+	// TaskManager 后台任务管理, 使用常驻的 TaskExecutor 执行任务.
+	// 等待中的任务按优先级交给 TaskExecutor, 同时执行的任务数不超过 Parallel
+	TaskManager struct {
+		Parallel int
+		MaxRetry int
+
+		executor *taskframework.TaskExecutor
+		tasks    map[string]*Task
+		pending  []*Task
+		running  int
+		seq      int
+		mu       sync.Mutex
+		cond     *sync.Cond
+	}
+
+	// taskUnit 包装任务单元, 处理暂停和取消, 更新任务状态
+	taskUnit struct {
+		tm   *TaskManager
+		task *Task
+	}
+)
+
+// NewTaskManager 初始化 TaskManager
+func NewTaskManager(parallel, maxRetry int) *TaskManager {
+	if parallel < 1 {
+		parallel = 1
+	}
+	tm := &TaskManager{
+		Parallel: parallel,
+		MaxRetry: maxRetry,
+		executor: taskframework.NewTaskExecutor(),
+		tasks:    map[string]*Task{},
+	}
+	tm.cond = sync.NewCond(&tm.mu)
+	tm.executor.SetParallel(parallel)
+	return tm
+}
+
+// Serve 开始执行任务, 直到调用 Stop
+func (tm *TaskManager) Serve() {
+	tm.executor.Serve()
+}
+
+// Stop 取消所有未结束的任务, 停止执行
+func (tm *TaskManager) Stop() {
+	tm.mu.Lock()
+	for _, task := range tm.tasks {
+		tm.cancel(task)
+	}
+	tm.mu.Unlock()
+	tm.executor.Stop()
+}
+
+// add 加入任务, 调用时需持有锁
+func (tm *TaskManager) add(task *Task, priority int) *Task {
+	tm.seq++
+	task.ID = strconv.Itoa(tm.seq)
+	task.seq = tm.seq
+	task.Priority = priority
+	task.State = TaskStatePending
+	task.CreateTime = time.Now()
+	task.maxRetry = tm.MaxRetry
+	tm.tasks[task.ID] = task
+	tm.pending = append(tm.pending, task)
+	tm.dispatch()
+	return task
+}
+
+// AddDownload 加入下载任务
+func (tm *TaskManager) AddDownload(unit *pcsdownload.DownloadTaskUnit, priority int) *Task {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task := &Task{
+		Type:   TaskTypeDownload,
+		Source: unit.PcsPath,
+		Target: unit.SavePath,
+		unit:   unit,
+	}
+	unit.OnStartDownload = func(der *downloader.Downloader) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		task.der = der
+		task.derPaused = false
+		if task.canceled {
+			der.Cancel()
+		}
+	}
+	unit.StatusFunc = func(status transfer.DownloadStatuser) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		task.downloadStatus = status
+		if task.der == nil {
+			return
+		}
+		// 下载开始前无法暂停, 在这里同步暂停状态
+		switch {
+		case task.State == TaskStatePaused && !task.derPaused:
+			task.der.Pause()
+			task.derPaused = true
+		case task.State == TaskStateRunning && task.derPaused:
+			task.der.Resume()
+			task.derPaused = false
+		}
+	}
+	return tm.add(task, priority)
+}
+
+// AddUpload 加入上传任务
+func (tm *TaskManager) AddUpload(unit *pcsupload.UploadTaskUnit, priority int) *Task {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task := &Task{
+		Type:   TaskTypeUpload,
+		Source: unit.LocalFileChecksum.Path,
+		Target: unit.SavePath,
+		unit:   unit,
+	}
+	unit.OnStartUpload = func(muer *uploader.MultiUploader) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		task.muer = muer
+		if task.canceled {
+			muer.Cancel()
+		}
+	}
+	unit.StatusFunc = func(status uploader.Status) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		task.uploadStatus = status
+	}
+	return tm.add(task, priority)
+}
+
+// dispatch 将等待中的任务交给 TaskExecutor, 调用时需持有锁
+func (tm *TaskManager) dispatch() {
+	for tm.running < tm.Parallel && len(tm.pending) > 0 {
+		// 优先级高的先执行, 优先级相同时先加入的先执行
+		sort.SliceStable(tm.pending, func(i, j int) bool {
+			if tm.pending[i].Priority != tm.pending[j].Priority {
+				return tm.pending[i].Priority > tm.pending[j].Priority
+			}
+			return tm.pending[i].seq < tm.pending[j].seq
+		})
+
+		i := 0
+		for ; i < len(tm.pending); i++ {
+			if tm.pending[i].State == TaskStatePending {
+				break
+			}
+		}
+		if i == len(tm.pending) {
+			// 剩下的都已暂停
+			return
+		}
+
+		task := tm.pending[i]
+		tm.pending = append(tm.pending[:i], tm.pending[i+1:]...)
+		task.started = true
+		task.State = TaskStateRunning
+		tm.running++
+		tm.executor.AppendWithID(task.ID, &taskUnit{
+			tm:   tm,
+			task: task,
+		}, task.maxRetry)
+	}
+}
+
+// removePending 从等待队列中删除, 调用时需持有锁
+func (tm *TaskManager) removePending(task *Task) {
+	for i := range tm.pending {
+		if tm.pending[i] == task {
+			tm.pending = append(tm.pending[:i], tm.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// finish 任务结束, 调用时需持有锁
+func (tm *TaskManager) finish(task *Task, state TaskState) {
+	if task.canceled {
+		state = TaskStateCanceled
+	}
+	task.State = state
+	task.der = nil
+	task.muer = nil
+	if task.started {
+		task.started = false
+		tm.running--
+	}
+	tm.cond.Broadcast()
+	tm.dispatch()
+}
+
+func (tm *TaskManager) get(id string) (*Task, error) {
+	task, ok := tm.tasks[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// Pause 暂停任务. 等待中的任务暂停后不会开始执行,
+// 正在下载的任务暂停所有下载线程, 仍然占用一个执行位置
+func (tm *TaskManager) Pause(id string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	switch task.State {
+	case TaskStatePending:
+		task.State = TaskStatePaused
+	case TaskStateRunning:
+		if task.Type == TaskTypeUpload {
+			return ErrPauseNotSupported
+		}
+		task.State = TaskStatePaused
+		if task.der != nil && !task.derPaused {
+			task.der.Pause()
+			task.derPaused = true
+		}
+	case TaskStatePaused:
+	default:
+		return ErrTaskFinished
+	}
+	return nil
+}
+
+// Resume 恢复已暂停的任务
+func (tm *TaskManager) Resume(id string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	if task.State != TaskStatePaused {
+		return ErrTaskNotPaused
+	}
+
+	if !task.started {
+		task.State = TaskStatePending
+		tm.dispatch()
+		return nil
+	}
+
+	task.State = TaskStateRunning
+	if task.der != nil && task.derPaused {
+		task.der.Resume()
+		task.derPaused = false
+	}
+	tm.cond.Broadcast()
+	return nil
+}
+
+// cancel 取消任务, 调用时需持有锁
+func (tm *TaskManager) cancel(task *Task) error {
+	switch task.State {
+	case TaskStateSucceeded, TaskStateFailed, TaskStateCanceled:
+		return ErrTaskFinished
+	}
+
+	task.canceled = true
+	if !task.started {
+		tm.removePending(task)
+		tm.finish(task, TaskStateCanceled)
+		return nil
+	}
+
+	if task.der != nil {
+		task.der.Cancel()
+	}
+	if task.muer != nil {
+		task.muer.Cancel()
+	}
+	tm.cond.Broadcast()
+	return nil
+}
+
+// Cancel 取消任务
+func (tm *TaskManager) Cancel(id string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	return tm.cancel(task)
+}
+
+// SetPriority 修改任务的优先级, 只影响还未开始执行的任务
+func (tm *TaskManager) SetPriority(id string, priority int) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	switch task.State {
+	case TaskStateSucceeded, TaskStateFailed, TaskStateCanceled:
+		return ErrTaskFinished
+	}
+	task.Priority = priority
+	return nil
+}
+
+// Purge 删除已结束的任务, 返回删除的数量
+func (tm *TaskManager) Purge() int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	n := 0
+	for id, task := range tm.tasks {
+		switch task.State {
+		case TaskStateSucceeded, TaskStateFailed, TaskStateCanceled:
+			delete(tm.tasks, id)
+			n++
+		}
+	}
+	return n
+}
+
+// Remove 删除已结束的任务
+func (tm *TaskManager) Remove(id string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	switch task.State {
+	case TaskStateSucceeded, TaskStateFailed, TaskStateCanceled:
+		delete(tm.tasks, id)
+		return nil
+	}
+	return ErrTaskNotFinished
+}
+
+// Get 获取任务信息
+func (tm *TaskManager) Get(id string) (*TaskInfo, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, err := tm.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return task.info(), nil
+}
+
+// List 列出所有任务, 按加入顺序排列
+func (tm *TaskManager) List() []*TaskInfo {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tasks := make([]*Task, 0, len(tm.tasks))
+	for _, task := range tm.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].seq < tasks[j].seq
+	})
+
+	infos := make([]*TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		infos = append(infos, task.info())
+	}
+	return infos
+}
+
+// info 获取任务信息, 调用时需持有锁
+func (task *Task) info() *TaskInfo {
+	info := &TaskInfo{
+		ID:         task.ID,
+		Type:       task.Type,
+		Source:     task.Source,
+		Target:     task.Target,
+		Priority:   task.Priority,
+		State:      task.State,
+		Error:      task.Err,
+		Left:       -1,
+		CreateTime: task.CreateTime.Unix(),
+	}
+
+	switch {
+	case task.downloadStatus != nil:
+		info.TotalSize = task.downloadStatus.TotalSize()
+		info.Transferred = task.downloadStatus.Downloaded()
+		info.Speed = task.downloadStatus.SpeedsPerSecond()
+		info.Elapsed = int64(task.downloadStatus.TimeElapsed() / time.Second)
+		if left := task.downloadStatus.TimeLeft(); left >= 0 {
+			info.Left = int64(left / time.Second)
+		}
+	case task.uploadStatus != nil:
+		info.TotalSize = task.uploadStatus.TotalSize()
+		info.Transferred = task.uploadStatus.Uploaded()
+		info.Speed = task.uploadStatus.SpeedsPerSecond()
+		info.Elapsed = int64(task.uploadStatus.TimeElapsed() / time.Second)
+		if info.Speed > 0 {
+			info.Left = (info.TotalSize - info.Transferred) / info.Speed
+		}
+	}
+
+	if task.State == TaskStateSucceeded {
+		info.Transferred = info.TotalSize
+		info.Speed = 0
+		info.Left = 0
+	}
+	return info
+}
+
+func (tu *taskUnit) SetTaskInfo(info *taskframework.TaskInfo) {
+	tu.task.unit.SetTaskInfo(info)
+}
+
+// Run 已暂停时等待恢复, 已取消时直接返回
+func (tu *taskUnit) Run() (result *taskframework.TaskUnitRunResult) {
+	tu.tm.mu.Lock()
+	for tu.task.State == TaskStatePaused && !tu.task.canceled {
+		tu.tm.cond.Wait()
+	}
+	canceled := tu.task.canceled
+	tu.tm.mu.Unlock()
+
+	if !canceled {
+		result = tu.task.unit.Run()
+	}
+
+	tu.tm.mu.Lock()
+	defer tu.tm.mu.Unlock()
+	tu.task.der = nil
+	tu.task.muer = nil
+	if tu.task.canceled {
+		// 不重试
+		return &taskframework.TaskUnitRunResult{
+			ResultMessage: StrTaskCanceled,
+		}
+	}
+	if result == nil {
+		result = &taskframework.TaskUnitRunResult{}
+	}
+	return result
+}
+
+func (tu *taskUnit) OnRetry(lastRunResult *taskframework.TaskUnitRunResult) {
+	tu.task.unit.OnRetry(lastRunResult)
+	tu.tm.mu.Lock()
+	defer tu.tm.mu.Unlock()
+	tu.task.Err = resultError(lastRunResult)
+}
+
+func (tu *taskUnit) OnSuccess(lastRunResult *taskframework.TaskUnitRunResult) {
+	tu.task.unit.OnSuccess(lastRunResult)
+	tu.tm.mu.Lock()
+	defer tu.tm.mu.Unlock()
+	tu.task.Err = ""
+	tu.tm.finish(tu.task, TaskStateSucceeded)
+}
+
+func (tu *taskUnit) OnFailed(lastRunResult *taskframework.TaskUnitRunResult) {
+	tu.task.unit.OnFailed(lastRunResult)
+	tu.tm.mu.Lock()
+	defer tu.tm.mu.Unlock()
+	tu.task.Err = resultError(lastRunResult)
+	switch lastRunResult.Extra {
+	case baidupcs.SkipPolicy, baidupcs.RsyncPolicy:
+		// 按上传策略跳过的文件
+		tu.tm.finish(tu.task, TaskStateSucceeded)
+	default:
+		tu.tm.finish(tu.task, TaskStateFailed)
+	}
+}
+
+func (tu *taskUnit) OnComplete(lastRunResult *taskframework.TaskUnitRunResult) {
+	tu.task.unit.OnComplete(lastRunResult)
+}
+
+func (tu *taskUnit) RetryWait() time.Duration {
+	return tu.task.unit.RetryWait()
+}
+
+// resultError 任务执行结果的错误信息
+func resultError(result *taskframework.TaskUnitRunResult) string {
+	if result.Err == nil {
+		return result.ResultMessage
+	}
+	if result.ResultMessage == "" {
+		return result.Err.Error()
+	}
+	return result.ResultMessage + ", " + result.Err.Error()
+}
@@ -0,0 +1,102 @@
+package pcsdaemon_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdaemon"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/requester/downloader"
+	"testing"
+	"time"
+)
+
+// newTestUnit 测试下载的目录, 不需要访问网盘
+func newTestUnit(pcspath string) *pcsdownload.DownloadTaskUnit {
+	return &pcsdownload.DownloadTaskUnit{
+		Cfg:      &downloader.Config{IsTest: true},
+		PcsPath:  pcspath,
+		SavePath: pcspath,
+		FileInfo: &baidupcs.FileDirectory{Path: pcspath, Isdir: true},
+	}
+}
+
+func states(tm *pcsdaemon.TaskManager) map[string]pcsdaemon.TaskState {
+	m := map[string]pcsdaemon.TaskState{}
+	for _, info := range tm.List() {
+		m[info.ID] = info.State
+	}
+	return m
+}
+
+func TestTaskManagerState(t *testing.T) {
+	tm := pcsdaemon.NewTaskManager(1, 0)
+	t1 := tm.AddDownload(newTestUnit("/1"), 0)
+	t2 := tm.AddDownload(newTestUnit("/2"), 0)
+	t3 := tm.AddDownload(newTestUnit("/3"), 5)
+
+	if err := tm.Pause(t2.ID); err != nil {
+		t.Fatalf("pause: %s", err)
+	}
+	if err := tm.Cancel(t3.ID); err != nil {
+		t.Fatalf("cancel: %s", err)
+	}
+
+	want := map[string]pcsdaemon.TaskState{
+		t1.ID: pcsdaemon.TaskStateRunning,
+		t2.ID: pcsdaemon.TaskStatePaused,
+		t3.ID: pcsdaemon.TaskStateCanceled,
+	}
+	got := states(tm)
+	for id, state := range want {
+		if got[id] != state {
+			t.Fatalf("task %s: got %s, want %s", id, got[id], state)
+		}
+	}
+
+	if _, err := tm.Get("100"); err != pcsdaemon.ErrTaskNotFound {
+		t.Fatalf("get: got %v, want %v", err, pcsdaemon.ErrTaskNotFound)
+	}
+	if err := tm.Resume(t3.ID); err != pcsdaemon.ErrTaskNotPaused {
+		t.Fatalf("resume: got %v, want %v", err, pcsdaemon.ErrTaskNotPaused)
+	}
+	if err := tm.SetPriority(t3.ID, 1); err != pcsdaemon.ErrTaskFinished {
+		t.Fatalf("set priority: got %v, want %v", err, pcsdaemon.ErrTaskFinished)
+	}
+	if err := tm.Remove(t2.ID); err != pcsdaemon.ErrTaskNotFinished {
+		t.Fatalf("remove: got %v, want %v", err, pcsdaemon.ErrTaskNotFinished)
+	}
+	if n := tm.Purge(); n != 1 {
+		t.Fatalf("purge: got %d, want 1", n)
+	}
+	if err := tm.Resume(t2.ID); err != nil {
+		t.Fatalf("resume: %s", err)
+	}
+	if got := states(tm); got[t2.ID] != pcsdaemon.TaskStatePending || len(got) != 2 {
+		t.Fatalf("unexpected states after resume: %v", got)
+	}
+}
+
+func TestTaskManagerPriority(t *testing.T) {
+	tm := pcsdaemon.NewTaskManager(1, 0)
+	low := tm.AddDownload(newTestUnit("/low"), 0)
+	mid := tm.AddDownload(newTestUnit("/mid"), 0)
+	high := tm.AddDownload(newTestUnit("/high"), 5)
+
+	go tm.Serve()
+	defer tm.Stop()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got := states(tm)
+		// 同时只执行一个任务, 优先级高的任务先于先加入的任务执行
+		if got[mid.ID] != pcsdaemon.TaskStatePending && got[high.ID] != pcsdaemon.TaskStateSucceeded {
+			t.Fatalf("task %s started before higher priority task %s: %v", mid.ID, high.ID, got)
+		}
+		if got[low.ID] == pcsdaemon.TaskStateSucceeded && got[mid.ID] == pcsdaemon.TaskStateSucceeded && got[high.ID] == pcsdaemon.TaskStateSucceeded {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("tasks not finished: %v", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,150 @@
+// Package pcsdedupe 查找网盘中的重复文件
+package pcsdedupe
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// KeepOldest 保留最早上传的文件
+	KeepOldest KeepPolicy = "oldest"
+	// KeepNewest 保留最新上传的文件
+	KeepNewest KeepPolicy = "newest"
+	// KeepShortestPath 保留路径最短的文件
+	KeepShortestPath KeepPolicy = "shortest-path"
+	// KeepInteractive 逐组选择保留的文件
+	KeepInteractive KeepPolicy = "interactive"
+)
+
+var (
+	// ErrKeepPolicy 未知的保留策略
+	ErrKeepPolicy = errors.New("保留策略只支持 oldest, newest, shortest-path, interactive")
+)
+
+type (
+	// KeepPolicy 重复文件的保留策略
+	KeepPolicy string
+
+	// Group 一组重复的文件, md5 和大小都相同
+	Group struct {
+		MD5   string
+		Size  int64
+		Files baidupcs.FileDirectoryList
+	}
+)
+
+// ParseKeepPolicy 解析保留策略, 可省略 keep- 前缀
+func ParseKeepPolicy(s string) (KeepPolicy, error) {
+	policy := KeepPolicy(strings.TrimPrefix(s, "keep-"))
+	switch policy {
+	case KeepOldest, KeepNewest, KeepShortestPath, KeepInteractive:
+		return policy, nil
+	}
+	return "", ErrKeepPolicy
+}
+
+// FindGroups 按 md5 和大小将文件分组, 返回重复文件的分组, 按浪费的空间从大到小排序.
+// 忽略目录, md5 为空的文件, 和小于 minSize 的文件
+func FindGroups(files baidupcs.FileDirectoryList, minSize int64) (groups []*Group) {
+	type key struct {
+		md5  string
+		size int64
+	}
+	var (
+		index = map[key]*Group{}
+		keys  []key
+	)
+	for _, fd := range files {
+		if fd == nil || fd.Isdir || fd.MD5 == "" || fd.Size < minSize {
+			continue
+		}
+		k := key{strings.ToLower(fd.MD5), fd.Size}
+		g, ok := index[k]
+		if !ok {
+			g = &Group{MD5: k.md5, Size: k.size}
+			index[k] = g
+			keys = append(keys, k)
+		}
+		g.Files = append(g.Files, fd)
+	}
+
+	for _, k := range keys {
+		g := index[k]
+		if len(g.Files) < 2 {
+			continue
+		}
+		sort.Slice(g.Files, func(i, j int) bool {
+			return g.Files[i].Path < g.Files[j].Path
+		})
+		groups = append(groups, g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Wasted() > groups[j].Wasted()
+	})
+	return groups
+}
+
+// Wasted 重复文件浪费的空间
+func (g *Group) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// Keep 根据保留策略, 返回保留的文件和需要删除的文件.
+// 条件相同时保留路径较短的文件, 交互式策略需要调用方选择, 返回 ErrKeepPolicy
+func (g *Group) Keep(policy KeepPolicy) (keep *baidupcs.FileDirectory, remove baidupcs.FileDirectoryList, err error) {
+	shorter := func(a, b *baidupcs.FileDirectory) bool {
+		la, lb := utf8.RuneCountInString(a.Path), utf8.RuneCountInString(b.Path)
+		if la != lb {
+			return la < lb
+		}
+		return a.Path < b.Path
+	}
+
+	var better func(a, b *baidupcs.FileDirectory) bool
+	switch policy {
+	case KeepOldest:
+		better = func(a, b *baidupcs.FileDirectory) bool {
+			if a.Ctime != b.Ctime {
+				return a.Ctime < b.Ctime
+			}
+			return shorter(a, b)
+		}
+	case KeepNewest:
+		better = func(a, b *baidupcs.FileDirectory) bool {
+			if a.Ctime != b.Ctime {
+				return a.Ctime > b.Ctime
+			}
+			return shorter(a, b)
+		}
+	case KeepShortestPath:
+		better = shorter
+	default:
+		return nil, nil, ErrKeepPolicy
+	}
+
+	keepIndex := 0
+	for k := 1; k < len(g.Files); k++ {
+		if better(g.Files[k], g.Files[keepIndex]) {
+			keepIndex = k
+		}
+	}
+	return g.Files[keepIndex], g.Except(keepIndex), nil
+}
+
+// Except 返回除了 keepIndexes 以外的文件
+func (g *Group) Except(keepIndexes ...int) (remove baidupcs.FileDirectoryList) {
+	keeps := map[int]bool{}
+	for _, k := range keepIndexes {
+		keeps[k] = true
+	}
+	for k, fd := range g.Files {
+		if !keeps[k] {
+			remove = append(remove, fd)
+		}
+	}
+	return remove
+}
@@ -0,0 +1,89 @@
+package pcsdedupe_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdedupe"
+	"testing"
+)
+
+func paths(fdl baidupcs.FileDirectoryList) (ps []string) {
+	for _, fd := range fdl {
+		ps = append(ps, fd.Path)
+	}
+	return
+}
+
+func TestFindGroups(t *testing.T) {
+	files := baidupcs.FileDirectoryList{
+		{Path: "/backup/2019/a.mp4", MD5: "AAA", Size: 100, Ctime: 3},
+		{Path: "/a.mp4", MD5: "aaa", Size: 100, Ctime: 2},
+		{Path: "/videos/a copy.mp4", MD5: "aaa", Size: 100, Ctime: 1},
+		{Path: "/b.txt", MD5: "bbb", Size: 10, Ctime: 1},
+		{Path: "/c/b.txt", MD5: "bbb", Size: 10, Ctime: 2},
+		{Path: "/other.txt", MD5: "bbb", Size: 11},
+		{Path: "/nomd5", Size: 100},
+		{Path: "/nomd5-2", Size: 100},
+		{Path: "/dir", Isdir: true},
+	}
+
+	groups := pcsdedupe.FindGroups(files, 0)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if groups[0].MD5 != "aaa" || groups[0].Wasted() != 200 || len(groups[0].Files) != 3 {
+		t.Fatalf("unexpected first group: %+v", groups[0])
+	}
+	if groups[1].MD5 != "bbb" || groups[1].Wasted() != 10 {
+		t.Fatalf("unexpected second group: %+v", groups[1])
+	}
+	if len(pcsdedupe.FindGroups(files, 50)) != 1 {
+		t.Fatalf("minSize not applied")
+	}
+
+	cases := []struct {
+		policy pcsdedupe.KeepPolicy
+		keep   string
+	}{
+		{pcsdedupe.KeepOldest, "/videos/a copy.mp4"},
+		{pcsdedupe.KeepNewest, "/backup/2019/a.mp4"},
+		{pcsdedupe.KeepShortestPath, "/a.mp4"},
+	}
+	for _, c := range cases {
+		keep, remove, err := groups[0].Keep(c.policy)
+		if err != nil {
+			t.Fatalf("Keep(%s): %s", c.policy, err)
+		}
+		if keep.Path != c.keep || len(remove) != 2 {
+			t.Fatalf("Keep(%s) = %s, %v", c.policy, keep.Path, paths(remove))
+		}
+		for _, fd := range remove {
+			if fd.Path == keep.Path {
+				t.Fatalf("Keep(%s) removes the kept file", c.policy)
+			}
+		}
+	}
+
+	if _, _, err := groups[0].Keep(pcsdedupe.KeepInteractive); err == nil {
+		t.Fatalf("Keep(interactive) should fail")
+	}
+	if got := paths(groups[0].Except(0, 2)); len(got) != 1 || got[0] != "/backup/2019/a.mp4" {
+		t.Fatalf("Except = %v", got)
+	}
+}
+
+func TestParseKeepPolicy(t *testing.T) {
+	for s, want := range map[string]pcsdedupe.KeepPolicy{
+		"oldest":             pcsdedupe.KeepOldest,
+		"keep-newest":        pcsdedupe.KeepNewest,
+		"keep-shortest-path": pcsdedupe.KeepShortestPath,
+		"interactive":        pcsdedupe.KeepInteractive,
+	} {
+		got, err := pcsdedupe.ParseKeepPolicy(s)
+		if err != nil || got != want {
+			t.Fatalf("ParseKeepPolicy(%s) = %s, %v", s, got, err)
+		}
+	}
+	if _, err := pcsdedupe.ParseKeepPolicy("largest"); err == nil {
+		t.Fatalf("ParseKeepPolicy should fail")
+	}
+}
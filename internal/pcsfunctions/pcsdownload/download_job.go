@@ -0,0 +1,304 @@
+package pcsdownload
+
+import (
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/pcsutil/jsonhelper"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DownloadJobDirName 下载任务的保存目录, 每个下载任务保存为一个文件, 多个进程同时下载时互不影响
+	DownloadJobDirName = "download_jobs"
+
+	// JobFilePending 等待下载
+	JobFilePending JobFileStatus = "pending"
+	// JobFileSucceeded 下载成功
+	JobFileSucceeded JobFileStatus = "succeeded"
+	// JobFileFailed 下载失败
+	JobFileFailed JobFileStatus = "failed"
+
+	downloadJobExt = ".json"
+)
+
+var (
+	// ErrDownloadJobNotFound 下载任务不存在
+	ErrDownloadJobNotFound = errors.New("下载任务不存在")
+)
+
+type (
+	// JobFileStatus 下载任务中文件的状态
+	JobFileStatus string
+
+	// DownloadJobFile 下载任务中的文件
+	DownloadJobFile struct {
+		Path     string        `json:"path"`
+		SavePath string        `json:"save_path"`
+		Size     int64         `json:"size"`
+		Isdir    bool          `json:"isdir,omitempty"`
+		Status   JobFileStatus `json:"status"`
+		Retry    int           `json:"retry"`
+		Err      string        `json:"err,omitempty"`
+	}
+
+	// DownloadJob 一次批量下载的任务, 记录文件列表和下载选项
+	DownloadJob struct {
+		ID         string             `json:"id"`
+		UID        uint64             `json:"uid"`
+		Paths      []string           `json:"paths"`
+		Options    json.RawMessage    `json:"options"`
+		Files      []*DownloadJobFile `json:"files"`
+		CreateTime int64              `json:"create_time"`
+		UpdateTime int64              `json:"update_time"`
+
+		lock     sync.Mutex
+		dirty    bool
+		filename string
+	}
+
+	// DownloadJobDatabase 下载任务数据库, 每个下载任务保存在目录中的单独文件
+	DownloadJobDatabase struct {
+		dir string
+	}
+)
+
+// NewDownloadJobDatabase 初始化配置目录中的下载任务数据库
+func NewDownloadJobDatabase() (*DownloadJobDatabase, error) {
+	return OpenDownloadJobDatabase(filepath.Join(pcsconfig.GetConfigDir(), DownloadJobDirName))
+}
+
+// OpenDownloadJobDatabase 初始化保存在目录 dir 的下载任务数据库, 目录不存在时创建
+func OpenDownloadJobDatabase(dir string) (*DownloadJobDatabase, error) {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+	return &DownloadJobDatabase{
+		dir: dir,
+	}, nil
+}
+
+// jobFilename 下载任务的文件名
+func (jd *DownloadJobDatabase) jobFilename(id string) string {
+	return filepath.Join(jd.dir, id+downloadJobExt)
+}
+
+// maxID 目录中最大的任务ID
+func (jd *DownloadJobDatabase) maxID() (maxID int, err error) {
+	infos, err := ioutil.ReadDir(jd.dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, info := range infos {
+		id, err := strconv.Atoi(strings.TrimSuffix(info.Name(), downloadJobExt))
+		if err == nil && id > maxID {
+			maxID = id
+		}
+	}
+	return maxID, nil
+}
+
+// NewJob 新建下载任务, options 为下载选项, 恢复任务时原样返回.
+// 以独占方式创建任务文件分配ID, 多个进程同时新建任务时ID不会重复
+func (jd *DownloadJobDatabase) NewJob(uid uint64, paths []string, options interface{}) (*DownloadJob, error) {
+	opt, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := jd.maxID()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		id++
+		file, err := os.OpenFile(jd.jobFilename(strconv.Itoa(id)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		file.Close()
+		break
+	}
+
+	now := time.Now().Unix()
+	job := &DownloadJob{
+		ID:         strconv.Itoa(id),
+		UID:        uid,
+		Paths:      paths,
+		Options:    opt,
+		CreateTime: now,
+		UpdateTime: now,
+		dirty:      true,
+		filename:   jd.jobFilename(strconv.Itoa(id)),
+	}
+	return job, job.Save()
+}
+
+// load 读取任务文件
+func (jd *DownloadJobDatabase) load(filename string) (*DownloadJob, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	job := &DownloadJob{}
+	err = jsonhelper.UnmarshalData(file, job)
+	if err != nil {
+		return nil, err
+	}
+	job.filename = filename
+	return job, nil
+}
+
+// Get 获取下载任务
+func (jd *DownloadJobDatabase) Get(id string) (*DownloadJob, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return nil, ErrDownloadJobNotFound
+	}
+	job, err := jd.load(jd.jobFilename(id))
+	if os.IsNotExist(err) {
+		return nil, ErrDownloadJobNotFound
+	}
+	return job, err
+}
+
+// List 列出用户 uid 的下载任务, uid 为 0 时列出全部, 按ID排列. 无法读取的任务文件被忽略
+func (jd *DownloadJobDatabase) List(uid uint64) (jobs []*DownloadJob) {
+	matches, _ := filepath.Glob(filepath.Join(jd.dir, "*"+downloadJobExt))
+	for _, filename := range matches {
+		job, err := jd.load(filename)
+		if err != nil {
+			continue
+		}
+		if uid == 0 || job.UID == uid {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, _ := strconv.Atoi(jobs[i].ID)
+		b, _ := strconv.Atoi(jobs[j].ID)
+		return a < b
+	})
+	return
+}
+
+// Delete 删除下载任务
+func (jd *DownloadJobDatabase) Delete(id string) error {
+	if _, err := strconv.Atoi(id); err != nil {
+		return ErrDownloadJobNotFound
+	}
+	err := os.Remove(jd.jobFilename(id))
+	if os.IsNotExist(err) {
+		return ErrDownloadJobNotFound
+	}
+	return err
+}
+
+// AddFile 将文件加入下载任务
+func (job *DownloadJob) AddFile(pcsPath, savePath string, size int64, isdir bool) *DownloadJobFile {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	file := &DownloadJobFile{
+		Path:     pcsPath,
+		SavePath: savePath,
+		Size:     size,
+		Isdir:    isdir,
+		Status:   JobFilePending,
+	}
+	job.Files = append(job.Files, file)
+	job.dirty = true
+	return file
+}
+
+// UpdateFile 更新下载任务中文件的状态
+func (job *DownloadJob) UpdateFile(file *DownloadJobFile, status JobFileStatus, retry int, err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	file.Status = status
+	file.Retry = retry
+	file.Err = ""
+	if err != nil {
+		file.Err = err.Error()
+	}
+	job.UpdateTime = time.Now().Unix()
+	job.dirty = true
+}
+
+// ResetFailed 将下载失败的文件重置为等待下载, 用于恢复任务
+func (job *DownloadJob) ResetFailed() {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	for _, file := range job.Files {
+		if file.Status == JobFileFailed {
+			file.Status = JobFilePending
+			file.Retry = 0
+			file.Err = ""
+		}
+	}
+	job.dirty = true
+}
+
+// Save 保存下载任务, 内容未改变时不写入. 先写入临时文件再重命名, 避免中断时损坏
+func (job *DownloadJob) Save() error {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	if !job.dirty {
+		return nil
+	}
+	if job.filename == "" {
+		return errors.New("filename is empty")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(job.filename), filepath.Base(job.filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = jsonhelper.MarshalData(tmp, job)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), job.filename)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	job.dirty = false
+	return nil
+}
+
+// Count 统计下载任务中各状态的文件数量
+func (job *DownloadJob) Count() (pending, succeeded, failed int) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	for _, file := range job.Files {
+		switch file.Status {
+		case JobFileSucceeded:
+			succeeded++
+		case JobFileFailed:
+			failed++
+		default:
+			pending++
+		}
+	}
+	return
+}
+
+// Done 下载任务中的文件是否全部下载成功
+func (job *DownloadJob) Done() bool {
+	pending, _, failed := job.Count()
+	return pending == 0 && failed == 0
+}
@@ -0,0 +1,113 @@
+package pcsdownload_test
+
+import (
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestDownloadJobDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "download_jobs")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 两个数据库模拟同时下载的两个进程
+	jd1, err := pcsdownload.OpenDownloadJobDatabase(dir)
+	if err != nil {
+		t.Fatalf("OpenDownloadJobDatabase: %s", err)
+	}
+	jd2, err := pcsdownload.OpenDownloadJobDatabase(dir)
+	if err != nil {
+		t.Fatalf("OpenDownloadJobDatabase: %s", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		jobs = make([]*pcsdownload.DownloadJob, 10)
+	)
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jd := jd1
+			if i%2 == 1 {
+				jd = jd2
+			}
+			job, err := jd.NewJob(1, []string{"/a"}, map[string]int{"p": i})
+			if err != nil {
+				t.Errorf("NewJob: %s", err)
+				return
+			}
+			jobs[i] = job
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, job := range jobs {
+		if job == nil {
+			t.FailNow()
+		}
+		if seen[job.ID] {
+			t.Fatalf("duplicate job id: %s", job.ID)
+		}
+		seen[job.ID] = true
+	}
+
+	// 分别更新两个任务, 互不覆盖
+	a, b := jobs[0], jobs[1]
+	fa := a.AddFile("/a/1", "1", 10, false)
+	fb := b.AddFile("/a/2", "2", 20, false)
+	a.UpdateFile(fa, pcsdownload.JobFileSucceeded, 0, nil)
+	b.UpdateFile(fb, pcsdownload.JobFileFailed, 3, errors.New("timeout"))
+	if err = a.Save(); err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+	if err = b.Save(); err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+
+	la, err := jd2.Get(a.ID)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	if !la.Done() || len(la.Files) != 1 {
+		t.Fatalf("job %s not saved: %+v", a.ID, la.Files)
+	}
+	lb, err := jd1.Get(b.ID)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	if _, _, failed := lb.Count(); failed != 1 || lb.Files[0].Err != "timeout" {
+		t.Fatalf("job %s not saved: %+v", b.ID, lb.Files[0])
+	}
+	lb.ResetFailed()
+	if pending, _, _ := lb.Count(); pending != 1 || lb.Files[0].Retry != 0 {
+		t.Fatalf("ResetFailed: %+v", lb.Files[0])
+	}
+
+	if n := len(jd1.List(1)); n != len(jobs) {
+		t.Fatalf("List: got %d jobs, want %d", n, len(jobs))
+	}
+	if n := len(jd1.List(2)); n != 0 {
+		t.Fatalf("List: got %d jobs, want 0", n)
+	}
+
+	if err = jd1.Delete(a.ID); err != nil {
+		t.Fatalf("Delete: %s", err)
+	}
+	if _, err = jd2.Get(a.ID); err != pcsdownload.ErrDownloadJobNotFound {
+		t.Fatalf("Get deleted job: got %v, want %v", err, pcsdownload.ErrDownloadJobNotFound)
+	}
+	if err = jd2.Delete(a.ID); err != pcsdownload.ErrDownloadJobNotFound {
+		t.Fatalf("Delete deleted job: got %v, want %v", err, pcsdownload.ErrDownloadJobNotFound)
+	}
+	if _, err = jd2.Get("../x"); err != pcsdownload.ErrDownloadJobNotFound {
+		t.Fatalf("Get invalid id: got %v, want %v", err, pcsdownload.ErrDownloadJobNotFound)
+	}
+}
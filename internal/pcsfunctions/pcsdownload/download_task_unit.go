@@ -7,6 +7,7 @@ import (
 	"BaiduPCS-Go/internal/pcsfunctions"
 	"BaiduPCS-Go/pcstable"
 	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/pcsutil/taskframework"
 	"BaiduPCS-Go/pcsverbose"
 	"BaiduPCS-Go/requester"
@@ -40,12 +41,22 @@ type (
 		// 可选项
 		VerbosePrinter       *pcsverbose.PCSVerbose
 		PrintFormat          string
-		IsPrintStatus        bool // 是否输出各个下载线程的详细信息
-		IsExecutedPermission bool // 下载成功后是否加上执行权限
-		IsOverwrite          bool // 是否覆盖已存在的文件
-		NoCheck              bool // 不校验文件
-		DlinkPrefer          int  // 使用所有备选下载链接中的第几个链接
-		ModifyMTime          bool // 下载的文件mtime修改为与网盘一致
+		IsPrintStatus        bool         // 是否输出各个下载线程的详细信息
+		IsExecutedPermission bool         // 下载成功后是否加上执行权限
+		IsOverwrite          bool         // 是否覆盖已存在的文件
+		NoCheck              bool         // 不校验文件
+		DlinkPrefer          int          // 使用所有备选下载链接中的第几个链接
+		ModifyMTime          bool         // 下载的文件mtime修改为与网盘一致
+		Cipher               *e2ee.Cipher // 不为nil时, 下载的是加密文件, 边下载边解密
+
+		// StatusFunc 不为nil时, 下载状态交给 StatusFunc 处理, 不输出下载进度
+		StatusFunc func(status transfer.DownloadStatuser)
+		// OnStartDownload 开始下载时调用, 可用于暂停, 恢复, 取消下载
+		OnStartDownload func(der *downloader.Downloader)
+
+		// 不为nil时, 下载结果记录到下载任务
+		Job     *DownloadJob
+		JobFile *DownloadJobFile
 
 		DownloadMode DownloadMode // 下载模式
 
@@ -96,13 +107,18 @@ func (dtu *DownloadTaskUnit) verboseInfof(format string, a ...interface{}) {
 // download 执行下载
 func (dtu *DownloadTaskUnit) download(downloadURL string, client *requester.HTTPClient) (err error) {
 	var (
-		writer downloader.Writer
-		file   *os.File
+		writer        downloader.Writer
+		file          *os.File
+		decryptWriter *e2ee.DecryptWriterAt
 	)
 
 	if !dtu.Cfg.IsTest {
 		// 非测试下载
 		dtu.Cfg.InstanceStatePath = dtu.SavePath + DownloadSuffix
+		if dtu.Cipher != nil {
+			// 未写满的块缓存在内存中, 加密文件不支持断点续传
+			dtu.Cfg.InstanceStatePath = ""
+		}
 
 		// 创建下载的目录
 		// 获取SavePath所在的目录
@@ -125,6 +141,19 @@ func (dtu *DownloadTaskUnit) download(downloadURL string, client *requester.HTTP
 			return fmt.Errorf("%s, %s", StrDownloadInitError, err)
 		}
 		defer file.Close()
+
+		if dtu.Cipher != nil {
+			decryptWriter, err = dtu.Cipher.NewDecryptWriterAt(file, dtu.FileInfo.Size)
+			if err != nil {
+				return fmt.Errorf("%s, %s", StrDownloadInitError, err)
+			}
+			// 截断旧文件残留的数据
+			err = file.Truncate(decryptWriter.PlainSize())
+			if err != nil {
+				return fmt.Errorf("%s, %s", StrDownloadInitError, err)
+			}
+			writer = decryptWriter
+		}
 	}
 
 	der := downloader.NewDownloader(downloadURL, writer, dtu.Cfg)
@@ -145,6 +174,11 @@ func (dtu *DownloadTaskUnit) download(downloadURL string, client *requester.HTTP
 	// 这里用共享变量的方式
 	isComplete := false
 	der.OnDownloadStatusEvent(func(status transfer.DownloadStatuser, workersCallback func(downloader.RangeWorkerFunc)) {
+		if dtu.StatusFunc != nil {
+			dtu.StatusFunc(status)
+			return
+		}
+
 		// 这里可能会下载结束了, 还会输出内容
 		builder := &strings.Builder{}
 		if dtu.IsPrintStatus {
@@ -192,9 +226,15 @@ func (dtu *DownloadTaskUnit) download(downloadURL string, client *requester.HTTP
 		}
 	})
 
+	if dtu.OnStartDownload != nil {
+		dtu.OnStartDownload(der)
+	}
+
 	err = der.Execute()
 	isComplete = true
-	fmt.Print("\n")
+	if dtu.StatusFunc == nil {
+		fmt.Print("\n")
+	}
 
 	if err != nil {
 		// 下载发生错误
@@ -216,6 +256,13 @@ func (dtu *DownloadTaskUnit) download(downloadURL string, client *requester.HTTP
 
 	// 下载成功
 	if !dtu.Cfg.IsTest {
+		if decryptWriter != nil {
+			err = decryptWriter.Finish()
+			if err != nil {
+				return err
+			}
+		}
+
 		if dtu.IsExecutedPermission {
 			err = file.Chmod(0766)
 			if err != nil {
@@ -353,9 +400,14 @@ func (dtu *DownloadTaskUnit) pcsOrStreamingDownload(mode DownloadMode, result *t
 
 // checkFileValid 检测文件有效性
 func (dtu *DownloadTaskUnit) checkFileValid(result *taskframework.TaskUnitRunResult) (ok bool) {
+	size := dtu.FileInfo.Size
+	if dtu.Cipher != nil {
+		size, _ = e2ee.PlainSize(size)
+	}
+
 	fi, err := os.Stat(dtu.SavePath)
 	if err == nil {
-		if fi.Size() != dtu.FileInfo.Size {
+		if fi.Size() != size {
 			result.ResultMessage = StrDownloadCheckLengthFailed
 			result.NeedNextdindex = true
 			result.NeedRetry = true
@@ -368,6 +420,12 @@ func (dtu *DownloadTaskUnit) checkFileValid(result *taskframework.TaskUnitRunRes
 		return true
 	}
 
+	if dtu.Cipher != nil {
+		// 解密时已逐块校验
+		fmt.Printf("[%s] 解密校验成功: %s\n", dtu.taskInfo.Id(), dtu.SavePath)
+		return true
+	}
+
 	if dtu.FileInfo.Size >= 128*converter.MB {
 		// 大文件, 输出一句提示消息
 		fmt.Printf("[%s] 开始检验文件有效性, 请稍候...\n", dtu.taskInfo.Id())
@@ -405,7 +463,21 @@ func (dtu *DownloadTaskUnit) checkFileValid(result *taskframework.TaskUnitRunRes
 	return true
 }
 
+// updateJobFile 更新下载任务中文件的状态
+func (dtu *DownloadTaskUnit) updateJobFile(status JobFileStatus, lastRunResult *taskframework.TaskUnitRunResult) {
+	if dtu.Job == nil || dtu.JobFile == nil {
+		return
+	}
+	err := lastRunResult.Err
+	if err == nil && lastRunResult.ResultMessage != "" && status != JobFileSucceeded {
+		err = errors.New(lastRunResult.ResultMessage)
+	}
+	dtu.Job.UpdateFile(dtu.JobFile, status, dtu.taskInfo.Retry(), err)
+}
+
 func (dtu *DownloadTaskUnit) OnRetry(lastRunResult *taskframework.TaskUnitRunResult) {
+	dtu.updateJobFile(JobFilePending, lastRunResult)
+
 	// 输出错误信息
 	if lastRunResult.Err == nil {
 		// result中不包含Err, 忽略输出
@@ -416,9 +488,12 @@ func (dtu *DownloadTaskUnit) OnRetry(lastRunResult *taskframework.TaskUnitRunRes
 }
 
 func (dtu *DownloadTaskUnit) OnSuccess(lastRunResult *taskframework.TaskUnitRunResult) {
+	dtu.updateJobFile(JobFileSucceeded, lastRunResult)
 }
 
 func (dtu *DownloadTaskUnit) OnFailed(lastRunResult *taskframework.TaskUnitRunResult) {
+	dtu.updateJobFile(JobFileFailed, lastRunResult)
+
 	// 失败
 	if lastRunResult.Err == nil {
 		// result中不包含Err, 忽略输出
@@ -0,0 +1,95 @@
+package pcsdownload
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// DownloadFilter 下载目录时文件的过滤条件, 为零值的条件不生效
+	DownloadFilter struct {
+		Include   []string  `json:"include,omitempty"`  // 文件名或相对路径匹配其中之一的文件才下载
+		Exclude   []string  `json:"exclude,omitempty"`  // 文件名或相对路径匹配其中之一的文件和目录不下载
+		MinSize   int64     `json:"min_size,omitempty"` // 最小文件大小
+		MaxSize   int64     `json:"max_size,omitempty"` // 最大文件大小
+		NewerThan time.Time `json:"newer_than"`         // 修改时间不早于
+		OlderThan time.Time `json:"older_than"`         // 修改时间不晚于
+	}
+)
+
+var (
+	// ErrFilterPattern 通配符格式错误
+	ErrFilterPattern = errors.New("通配符格式错误")
+)
+
+// IsEmpty 是否没有设置任何过滤条件
+func (df *DownloadFilter) IsEmpty() bool {
+	return df == nil || (len(df.Include) == 0 && len(df.Exclude) == 0 && df.MinSize <= 0 && df.MaxSize <= 0 && df.NewerThan.IsZero() && df.OlderThan.IsZero())
+}
+
+// Validate 检查通配符格式
+func (df *DownloadFilter) Validate() error {
+	for _, patterns := range [][]string{df.Include, df.Exclude} {
+		for _, pattern := range patterns {
+			_, err := path.Match(pattern, "")
+			if err != nil {
+				return errors.New(ErrFilterPattern.Error() + ": " + pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// matchAny relPath 是否匹配 patterns 之一.
+// 不含 / 的通配符匹配文件名, 含 / 的通配符匹配相对路径
+func matchAny(patterns []string, relPath string) bool {
+	relPath = strings.TrimPrefix(relPath, "/")
+	name := path.Base(relPath)
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, "/") {
+			pattern = strings.TrimPrefix(pattern, "/")
+			target = relPath
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludeDir 目录是否被排除, 被排除的目录下的文件都不下载
+func (df *DownloadFilter) ExcludeDir(relPath string) bool {
+	if df == nil {
+		return false
+	}
+	return matchAny(df.Exclude, relPath)
+}
+
+// MatchFile 文件是否满足过滤条件, relPath 为相对于下载目录的路径, mtime 为 Unix 时间戳
+func (df *DownloadFilter) MatchFile(relPath string, size, mtime int64) bool {
+	if df == nil {
+		return true
+	}
+	if len(df.Include) > 0 && !matchAny(df.Include, relPath) {
+		return false
+	}
+	if matchAny(df.Exclude, relPath) {
+		return false
+	}
+	if df.MinSize > 0 && size < df.MinSize {
+		return false
+	}
+	if df.MaxSize > 0 && size > df.MaxSize {
+		return false
+	}
+	if !df.NewerThan.IsZero() && mtime < df.NewerThan.Unix() {
+		return false
+	}
+	if !df.OlderThan.IsZero() && mtime > df.OlderThan.Unix() {
+		return false
+	}
+	return true
+}
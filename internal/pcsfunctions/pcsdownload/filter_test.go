@@ -0,0 +1,53 @@
+package pcsdownload_test
+
+import (
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"testing"
+	"time"
+)
+
+func TestDownloadFilter(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.Local)
+	df := &pcsdownload.DownloadFilter{
+		Include:   []string{"*.mkv", "logs/*.log"},
+		Exclude:   []string{"sample*"},
+		MinSize:   10,
+		MaxSize:   1000,
+		NewerThan: now.AddDate(0, 0, -7),
+	}
+	if err := df.Validate(); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+
+	mtime := now.Unix()
+	cases := []struct {
+		rel   string
+		size  int64
+		mtime int64
+		want  bool
+	}{
+		{"a/b/movie.mkv", 100, mtime, true},
+		{"a/b/movie.mp4", 100, mtime, false},
+		{"logs/app.log", 100, mtime, true},
+		{"old/logs/app.log", 100, mtime, false},
+		{"a/sample.mkv", 100, mtime, false},
+		{"movie.mkv", 5, mtime, false},
+		{"movie.mkv", 5000, mtime, false},
+		{"movie.mkv", 100, now.AddDate(0, 0, -8).Unix(), false},
+	}
+	for _, c := range cases {
+		if got := df.MatchFile(c.rel, c.size, c.mtime); got != c.want {
+			t.Fatalf("MatchFile(%s, %d, %d) = %v, want %v", c.rel, c.size, c.mtime, got, c.want)
+		}
+	}
+
+	if !df.ExcludeDir("a/samples") || df.ExcludeDir("a/movies") {
+		t.Fatalf("ExcludeDir mismatch")
+	}
+	if (&pcsdownload.DownloadFilter{Include: []string{"[a"}}).Validate() == nil {
+		t.Fatalf("Validate should fail for bad pattern")
+	}
+	if !(&pcsdownload.DownloadFilter{}).IsEmpty() || df.IsEmpty() {
+		t.Fatalf("IsEmpty mismatch")
+	}
+}
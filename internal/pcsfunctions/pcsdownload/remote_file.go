@@ -0,0 +1,85 @@
+package pcsdownload
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/requester"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetDownloadLink 获取网盘文件的下载链接, 先尝试 LocateDownload, 失败时使用 LocatePanAPIDownload
+func GetDownloadLink(pcs *baidupcs.BaiduPCS, fd *baidupcs.FileDirectory) (dlink string, err error) {
+	dlinks, err := GetLocateDownloadLinks(pcs, fd.Path)
+	if err == nil {
+		// 跳过 nb.cache 这种还没有证书的
+		u := dlinks[0]
+		if strings.HasPrefix(u.Host, "nb.cache") && len(dlinks) > 1 {
+			u = dlinks[1]
+		}
+		FixHTTPLinkURL(u)
+		return u.String(), nil
+	}
+
+	list, pcsError := pcs.LocatePanAPIDownload(fd.FsID)
+	if pcsError != nil {
+		return "", fmt.Errorf("%s, %s", err, pcsError)
+	}
+	for _, info := range list {
+		if info != nil && info.Dlink != "" {
+			return info.Dlink, nil
+		}
+	}
+	return "", ErrDlinkNotFound
+}
+
+// NewPanDownloadClient 返回请求下载链接 dlink 的 HTTPClient, 使用 pan User-Agent 和 pcs 的 cookies
+func NewPanDownloadClient(pcs *baidupcs.BaiduPCS, dlink string) *requester.HTTPClient {
+	client := pcsconfig.Config.PanHTTPClient()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// 去掉 Referer
+		if !pcsconfig.Config.EnableHTTPS {
+			req.Header.Del("Referer")
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	client.SetTimeout(2 * time.Minute)
+	client.SetKeepAlive(true)
+
+	jar, err := CloneJarWithDomain(pcs.GetClient().Jar, dlink)
+	if err == nil {
+		client.SetCookiejar(jar)
+	}
+	return client
+}
+
+// RangeRequest 请求 url 中 [begin, end] 范围的数据, end 小于 0 时读取到文件末尾.
+// 服务器不支持 Range 时, begin 为 0 的请求返回整个文件
+func RangeRequest(client *requester.HTTPClient, url string, begin, end int64) (io.ReadCloser, error) {
+	rangeStr := "bytes=" + strconv.FormatInt(begin, 10) + "-"
+	if end >= 0 {
+		rangeStr += strconv.FormatInt(end, 10)
+	}
+	resp, err := client.Req(http.MethodGet, url, nil, map[string]string{
+		"Range": rangeStr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode == http.StatusOK && begin == 0:
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("读取远程文件错误, 范围: %s, 状态码: %s", rangeStr, resp.Status)
+	}
+	return resp.Body, nil
+}
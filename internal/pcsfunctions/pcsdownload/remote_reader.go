@@ -0,0 +1,97 @@
+package pcsdownload
+
+import (
+	"BaiduPCS-Go/requester"
+	"io"
+	"sync"
+)
+
+type (
+	// RemoteReaderAt 通过 Range 请求读取网盘文件, 实现 rio.ReaderAtLen64, 数据不经过本地磁盘.
+	// 每个连接按顺序读取, 读取位置与上次结束的位置相同时复用连接
+	RemoteReaderAt struct {
+		client   *requester.HTTPClient
+		url      string
+		size     int64
+		maxConns int
+
+		mu    sync.Mutex
+		conns map[int64]io.ReadCloser // key: 连接下一次读取的位置
+	}
+)
+
+// NewRemoteReaderAt 初始化 RemoteReaderAt, maxConns 为最多保持的空闲连接数
+func NewRemoteReaderAt(client *requester.HTTPClient, url string, size int64, maxConns int) *RemoteReaderAt {
+	if maxConns < 1 {
+		maxConns = 1
+	}
+	return &RemoteReaderAt{
+		client:   client,
+		url:      url,
+		size:     size,
+		maxConns: maxConns,
+		conns:    map[int64]io.ReadCloser{},
+	}
+}
+
+func (rr *RemoteReaderAt) open(off int64) (io.ReadCloser, error) {
+	return RangeRequest(rr.client, rr.url, off, -1)
+}
+
+// ReadAt 实现 io.ReaderAt
+func (rr *RemoteReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off >= rr.size {
+		return 0, io.EOF
+	}
+	if left := rr.size - off; int64(len(p)) > left {
+		p = p[:left]
+		defer func() {
+			if err == nil {
+				err = io.EOF
+			}
+		}()
+	}
+
+	rr.mu.Lock()
+	body := rr.conns[off]
+	delete(rr.conns, off)
+	rr.mu.Unlock()
+
+	if body == nil {
+		body, err = rr.open(off)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = io.ReadFull(body, p)
+	if err != nil {
+		body.Close()
+		return
+	}
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if _, ok := rr.conns[off+int64(n)]; ok || len(rr.conns) >= rr.maxConns {
+		body.Close()
+		return
+	}
+	rr.conns[off+int64(n)] = body
+	return
+}
+
+// Len 文件大小
+func (rr *RemoteReaderAt) Len() int64 {
+	return rr.size
+}
+
+// Close 关闭所有连接
+func (rr *RemoteReaderAt) Close() error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	for off, body := range rr.conns {
+		body.Close()
+		delete(rr.conns, off)
+	}
+	return nil
+}
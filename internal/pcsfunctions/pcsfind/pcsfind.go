@@ -0,0 +1,393 @@
+// Package pcsfind 解析和执行 find 命令的表达式
+package pcsfind
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/pcsutil/converter"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ActionPrint 输出路径, 以换行分隔
+	ActionPrint Action = iota
+	// ActionPrint0 输出路径, 以 \0 分隔
+	ActionPrint0
+	// ActionJSON 以 JSON 格式输出文件信息, 一行一个
+	ActionJSON
+	// ActionDelete 删除文件或目录
+	ActionDelete
+)
+
+type (
+	// Action 匹配后执行的动作
+	Action int
+
+	// ActionFunc 执行动作的函数
+	ActionFunc func(action Action, fd *baidupcs.FileDirectory)
+
+	// Expression 解析后的表达式
+	Expression struct {
+		MaxDepth int // 最大深度, 小于0时不限制
+		MinDepth int // 最小深度
+
+		root      node
+		hasAction bool
+		actions   map[Action]bool
+	}
+
+	// node 表达式的节点
+	node interface {
+		eval(fd *baidupcs.FileDirectory, do ActionFunc) bool
+	}
+
+	andNode struct{ left, right node }
+	orNode  struct{ left, right node }
+	notNode struct{ n node }
+
+	testNode func(fd *baidupcs.FileDirectory) bool
+
+	actionNode Action
+
+	parser struct {
+		args []string
+		pos  int
+		now  time.Time
+		expr *Expression
+	}
+)
+
+func (n *andNode) eval(fd *baidupcs.FileDirectory, do ActionFunc) bool {
+	return n.left.eval(fd, do) && n.right.eval(fd, do)
+}
+
+func (n *orNode) eval(fd *baidupcs.FileDirectory, do ActionFunc) bool {
+	return n.left.eval(fd, do) || n.right.eval(fd, do)
+}
+
+func (n *notNode) eval(fd *baidupcs.FileDirectory, do ActionFunc) bool {
+	return !n.n.eval(fd, do)
+}
+
+func (n testNode) eval(fd *baidupcs.FileDirectory, do ActionFunc) bool {
+	return n(fd)
+}
+
+func (n actionNode) eval(fd *baidupcs.FileDirectory, do ActionFunc) bool {
+	if do != nil {
+		do(Action(n), fd)
+	}
+	return true
+}
+
+func alwaysTrue(*baidupcs.FileDirectory) bool {
+	return true
+}
+
+// IsExpressionArg arg 是否为表达式的开始
+func IsExpressionArg(arg string) bool {
+	return arg == "(" || arg == "!" || (strings.HasPrefix(arg, "-") && len(arg) > 1)
+}
+
+// Parse 解析 find 的参数, 返回起始目录和表达式. now 用于计算 -mtime, -mmin.
+// 表达式之前的参数为起始目录, 没有指定动作时默认为 -print
+func Parse(args []string, now time.Time) (dirs []string, expr *Expression, err error) {
+	k := 0
+	for ; k < len(args) && !IsExpressionArg(args[k]); k++ {
+		dirs = append(dirs, args[k])
+	}
+
+	p := &parser{
+		args: args[k:],
+		now:  now,
+		expr: &Expression{
+			MaxDepth: -1,
+			actions:  map[Action]bool{},
+		},
+	}
+
+	var root node = testNode(alwaysTrue)
+	if len(p.args) > 0 {
+		root, err = p.parseOr()
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.pos < len(p.args) {
+			return nil, nil, fmt.Errorf("无法解析的参数: %s", p.args[p.pos])
+		}
+	}
+
+	if !p.expr.hasAction {
+		root = &andNode{root, actionNode(ActionPrint)}
+		p.expr.actions[ActionPrint] = true
+	}
+	p.expr.root = root
+	return dirs, p.expr, nil
+}
+
+// Eval 对文件或目录求值, depth 为相对于起始目录的深度, 起始目录为0.
+// 匹配时执行表达式中的动作
+func (e *Expression) Eval(fd *baidupcs.FileDirectory, depth int, do ActionFunc) bool {
+	if depth < e.MinDepth || (e.MaxDepth >= 0 && depth > e.MaxDepth) {
+		return false
+	}
+	return e.root.eval(fd, do)
+}
+
+// Descend 是否需要继续遍历深度为 depth 的目录的子目录
+func (e *Expression) Descend(depth int) bool {
+	return e.MaxDepth < 0 || depth < e.MaxDepth
+}
+
+// HasAction 表达式中是否包含动作 action
+func (e *Expression) HasAction(action Action) bool {
+	return e.actions[action]
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.args) {
+		return ""
+	}
+	return p.args[p.pos]
+}
+
+func (p *parser) next() (string, bool) {
+	if p.pos >= len(p.args) {
+		return "", false
+	}
+	p.pos++
+	return p.args[p.pos-1], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "-o", "-or":
+			p.pos++
+			right, err := p.parseAnd()
+			if err != nil {
+				return nil, err
+			}
+			left = &orNode{left, right}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "", ")", "-o", "-or":
+			return left, nil
+		case "-a", "-and":
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	switch p.peek() {
+	case "!", "-not":
+		p.pos++
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	arg, ok := p.next()
+	if !ok {
+		return nil, errors.New("表达式不完整")
+	}
+
+	switch arg {
+	case "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, _ := p.next(); closing != ")" {
+			return nil, errors.New("缺少 )")
+		}
+		return n, nil
+	case ")":
+		return nil, errors.New("多余的 )")
+	case "-print", "-print0", "-json", "-delete":
+		action := map[string]Action{
+			"-print":  ActionPrint,
+			"-print0": ActionPrint0,
+			"-json":   ActionJSON,
+			"-delete": ActionDelete,
+		}[arg]
+		p.expr.hasAction = true
+		p.expr.actions[action] = true
+		return actionNode(action), nil
+	case "-true":
+		return testNode(alwaysTrue), nil
+	case "-false":
+		return testNode(func(*baidupcs.FileDirectory) bool { return false }), nil
+	}
+
+	value, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("%s 缺少参数", arg)
+	}
+
+	switch arg {
+	case "-name", "-iname", "-path", "-ipath":
+		fold := arg == "-iname" || arg == "-ipath"
+		if fold {
+			value = strings.ToLower(value)
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("%s 通配符格式错误: %s", arg, value)
+		}
+		matchPath := arg == "-path" || arg == "-ipath"
+		return testNode(func(fd *baidupcs.FileDirectory) bool {
+			target := fd.Filename
+			if matchPath {
+				target = fd.Path
+			}
+			if fold {
+				target = strings.ToLower(target)
+			}
+			ok, _ := path.Match(value, target)
+			return ok
+		}), nil
+	case "-regex", "-iregex":
+		expr := "^(?:" + value + ")$"
+		if arg == "-iregex" {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s 正则表达式错误: %s", arg, err)
+		}
+		return testNode(func(fd *baidupcs.FileDirectory) bool {
+			return re.MatchString(fd.Path)
+		}), nil
+	case "-type":
+		switch value {
+		case "f":
+			return testNode(func(fd *baidupcs.FileDirectory) bool { return !fd.Isdir }), nil
+		case "d":
+			return testNode(func(fd *baidupcs.FileDirectory) bool { return fd.Isdir }), nil
+		}
+		return nil, fmt.Errorf("-type 只支持 f 或 d: %s", value)
+	case "-size":
+		cmp, n, unit, err := parseSize(value)
+		if err != nil {
+			return nil, err
+		}
+		return testNode(func(fd *baidupcs.FileDirectory) bool {
+			if fd.Isdir {
+				return false
+			}
+			// 与 find 一致, 大小以单位向上取整后比较
+			return compare(cmp, int64(math.Ceil(float64(fd.Size)/float64(unit))), n)
+		}), nil
+	case "-mtime", "-mmin":
+		cmp, n, err := parseNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s 参数错误: %s", arg, value)
+		}
+		unit := int64(24 * 60 * 60)
+		if arg == "-mmin" {
+			unit = 60
+		}
+		now := p.now.Unix()
+		return testNode(func(fd *baidupcs.FileDirectory) bool {
+			// 与 find 一致, 时间差以单位向下取整后比较
+			return compare(cmp, (now-fd.Mtime)/unit, n)
+		}), nil
+	case "-md5":
+		value = strings.ToLower(value)
+		return testNode(func(fd *baidupcs.FileDirectory) bool {
+			return !fd.Isdir && strings.ToLower(fd.MD5) == value
+		}), nil
+	case "-maxdepth", "-mindepth":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s 参数错误: %s", arg, value)
+		}
+		if arg == "-maxdepth" {
+			p.expr.MaxDepth = n
+		} else {
+			p.expr.MinDepth = n
+		}
+		return testNode(alwaysTrue), nil
+	}
+
+	return nil, fmt.Errorf("未知的参数: %s", arg)
+}
+
+// parseNumber 解析 +n, -n, n
+func parseNumber(s string) (cmp byte, n int64, err error) {
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		cmp, s = s[0], s[1:]
+	}
+	n, err = strconv.ParseInt(s, 10, 64)
+	if err == nil && n < 0 {
+		err = errors.New("负数")
+	}
+	return
+}
+
+// parseSize 解析 -size 的参数, 如 +100M, -1k, 20c, 单位默认为字节
+func parseSize(value string) (cmp byte, n, unit int64, err error) {
+	s := value
+	unit = 1
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'c':
+			s = s[:len(s)-1]
+		case 'k', 'K':
+			unit, s = converter.KB, s[:len(s)-1]
+		case 'M':
+			unit, s = converter.MB, s[:len(s)-1]
+		case 'G':
+			unit, s = converter.GB, s[:len(s)-1]
+		case 'T':
+			unit, s = converter.TB, s[:len(s)-1]
+		}
+	}
+	cmp, n, err = parseNumber(s)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("-size 参数错误: %s", value)
+	}
+	return
+}
+
+func compare(cmp byte, v, n int64) bool {
+	switch cmp {
+	case '+':
+		return v > n
+	case '-':
+		return v < n
+	}
+	return v == n
+}
@@ -0,0 +1,102 @@
+package pcsfind_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsfind"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	files := []*baidupcs.FileDirectory{
+		{Path: "/a", Filename: "a", Isdir: true, Mtime: now.Unix()},
+		{Path: "/a/Movie.MKV", Filename: "Movie.MKV", Size: 200 << 20, Mtime: now.AddDate(0, 0, -3).Unix(), MD5: "ABCDEF"},
+		{Path: "/a/app.log", Filename: "app.log", Size: 1 << 10, Mtime: now.AddDate(0, 0, -10).Unix()},
+		{Path: "/a/logs", Filename: "logs", Isdir: true, Mtime: now.Unix()},
+		{Path: "/a/logs/x.log", Filename: "x.log", Size: 10, Mtime: now.Unix()},
+	}
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"/a"}, []string{"/a", "/a/Movie.MKV", "/a/app.log", "/a/logs", "/a/logs/x.log"}},
+		{[]string{"/a", "-name", "*.log"}, []string{"/a/app.log", "/a/logs/x.log"}},
+		{[]string{"/a", "-iname", "*.mkv"}, []string{"/a/Movie.MKV"}},
+		{[]string{"/a", "-type", "d"}, []string{"/a", "/a/logs"}},
+		{[]string{"/a", "-size", "+100M"}, []string{"/a/Movie.MKV"}},
+		{[]string{"/a", "-size", "-1k"}, nil},
+		{[]string{"/a", "-size", "1k", "-type", "f"}, []string{"/a/app.log", "/a/logs/x.log"}},
+		{[]string{"/a", "-mtime", "-7", "-type", "f"}, []string{"/a/Movie.MKV", "/a/logs/x.log"}},
+		{[]string{"/a", "-mtime", "+7"}, []string{"/a/app.log"}},
+		{[]string{"/a", "-md5", "abcdef"}, []string{"/a/Movie.MKV"}},
+		{[]string{"/a", "-regex", "/a/logs/.*"}, []string{"/a/logs/x.log"}},
+		{[]string{"/a", "-path", "/a/*/*.log"}, []string{"/a/logs/x.log"}},
+		{[]string{"/a", "-name", "*.mkv", "-o", "-name", "x.*"}, []string{"/a/logs/x.log"}},
+		{[]string{"/a", "-type", "f", "!", "-name", "*.log"}, []string{"/a/Movie.MKV"}},
+		{[]string{"/a", "-not", "(", "-type", "d", "-or", "-name", "*.log", ")"}, []string{"/a/Movie.MKV"}},
+		{[]string{"/a", "-maxdepth", "1", "-type", "f"}, []string{"/a/Movie.MKV", "/a/app.log"}},
+		{[]string{"/a", "-mindepth", "2"}, []string{"/a/logs/x.log"}},
+	}
+
+	for _, c := range cases {
+		dirs, expr, err := pcsfind.Parse(c.args, now)
+		if err != nil {
+			t.Fatalf("Parse(%v): %s", c.args, err)
+		}
+		if !reflect.DeepEqual(dirs, []string{"/a"}) {
+			t.Fatalf("Parse(%v) dirs = %v", c.args, dirs)
+		}
+		var got []string
+		for _, fd := range files {
+			depth := strings.Count(fd.Path, "/") - 1
+			expr.Eval(fd, depth, func(action pcsfind.Action, fd *baidupcs.FileDirectory) {
+				if action != pcsfind.ActionPrint {
+					t.Fatalf("unexpected action %d", action)
+				}
+				got = append(got, fd.Path)
+			})
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%v: got %v, want %v", c.args, got, c.want)
+		}
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	_, expr, err := pcsfind.Parse([]string{"/a", "-name", "*.log", "-delete", "-o", "-json"}, time.Now())
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if !expr.HasAction(pcsfind.ActionDelete) || !expr.HasAction(pcsfind.ActionJSON) || expr.HasAction(pcsfind.ActionPrint) {
+		t.Fatalf("HasAction mismatch")
+	}
+
+	actions := map[string]pcsfind.Action{}
+	for _, fd := range []*baidupcs.FileDirectory{{Path: "/a/x.log", Filename: "x.log"}, {Path: "/a/y.txt", Filename: "y.txt"}} {
+		expr.Eval(fd, 1, func(action pcsfind.Action, fd *baidupcs.FileDirectory) {
+			actions[fd.Path] = action
+		})
+	}
+	want := map[string]pcsfind.Action{"/a/x.log": pcsfind.ActionDelete, "/a/y.txt": pcsfind.ActionJSON}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+
+	for _, args := range [][]string{
+		{"/a", "-name"},
+		{"/a", "(", "-type", "f"},
+		{"/a", "-type", "x"},
+		{"/a", "-size", "abc"},
+		{"/a", "-regex", "("},
+		{"/a", "-unknown", "1"},
+		{"/a", "-type", "f", ")"},
+	} {
+		if _, _, err := pcsfind.Parse(args, time.Now()); err == nil {
+			t.Fatalf("Parse(%v) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+// Package pcsindex 网盘文件元信息的离线索引
+package pcsindex
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/pcsutil/escaper"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound 索引中不存在该文件或目录
+	ErrNotFound = errors.New("离线索引中不存在该文件或目录")
+	// ErrNotDir 不是目录
+	ErrNotDir = errors.New("不是一个目录")
+	// ErrNotAbsPath 不是绝对路径
+	ErrNotAbsPath = errors.New("不是绝对路径")
+)
+
+type (
+	// Lister 获取网盘文件变更和目录列表, *baidupcs.BaiduPCS 实现了该接口
+	Lister interface {
+		FilesDirectoriesDiff(cursor string) (*baidupcs.FileDiff, pcserror.Error)
+		FilesDirectoriesList(path string, options *baidupcs.OrderOptions) (baidupcs.FileDirectoryList, pcserror.Error)
+	}
+
+	// Index 离线索引, 以路径为键保存网盘中全部文件和目录的元信息
+	Index struct {
+		UID        uint64 // 百度帐号 uid
+		Cursor     string // 获取文件变更的 cursor, 为空时通过重新列目录更新
+		UpdateTime int64  // 更新时间
+
+		files    map[string]*baidupcs.FileDirectory
+		children map[string]map[string]*baidupcs.FileDirectory
+	}
+
+	// UpdateResult 更新索引的结果
+	UpdateResult struct {
+		Added    int
+		Modified int
+		Deleted  int
+		Listed   int   // 重新列出的目录数
+		Relist   bool  // 是否通过重新列目录更新
+		DiffErr  error // 获取文件变更的错误, 已有 cursor 时保留 cursor 等待重试, 否则改为重新列目录
+	}
+
+	indexJSON struct {
+		UID        uint64       `json:"uid"`
+		Cursor     string       `json:"cursor"`
+		UpdateTime int64        `json:"update_time"`
+		Entries    []*entryJSON `json:"entries"`
+	}
+
+	entryJSON struct {
+		FsID  int64  `json:"fs_id"`
+		Path  string `json:"path"`
+		Size  int64  `json:"size,omitempty"`
+		Isdir bool   `json:"isdir,omitempty"`
+		MD5   string `json:"md5,omitempty"`
+		Ctime int64  `json:"ctime"`
+		Mtime int64  `json:"mtime"`
+	}
+)
+
+// New 初始化空的索引
+func New(uid uint64) *Index {
+	return &Index{
+		UID:      uid,
+		files:    map[string]*baidupcs.FileDirectory{},
+		children: map[string]map[string]*baidupcs.FileDirectory{},
+	}
+}
+
+// Load 从文件读取索引, 文件为 gzip 压缩的 JSON
+func Load(filename string) (*Index, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	data := indexJSON{}
+	err = json.NewDecoder(gr).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := New(data.UID)
+	idx.Cursor = data.Cursor
+	idx.UpdateTime = data.UpdateTime
+	for _, e := range data.Entries {
+		idx.Put(&baidupcs.FileDirectory{
+			FsID:  e.FsID,
+			Path:  e.Path,
+			Size:  e.Size,
+			Isdir: e.Isdir,
+			MD5:   e.MD5,
+			Ctime: e.Ctime,
+			Mtime: e.Mtime,
+		})
+	}
+	return idx, nil
+}
+
+// Save 保存索引到文件, 先写入临时文件再替换, 避免中断时损坏索引
+func (idx *Index) Save(filename string) error {
+	data := indexJSON{
+		UID:        idx.UID,
+		Cursor:     idx.Cursor,
+		UpdateTime: idx.UpdateTime,
+		Entries:    make([]*entryJSON, 0, len(idx.files)),
+	}
+	for _, fd := range idx.files {
+		data.Entries = append(data.Entries, &entryJSON{
+			FsID:  fd.FsID,
+			Path:  fd.Path,
+			Size:  fd.Size,
+			Isdir: fd.Isdir,
+			MD5:   fd.MD5,
+			Ctime: fd.Ctime,
+			Mtime: fd.Mtime,
+		})
+	}
+	sort.Slice(data.Entries, func(i, j int) bool {
+		return data.Entries[i].Path < data.Entries[j].Path
+	})
+
+	tmp := filename + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(file)
+	err = json.NewEncoder(gw).Encode(&data)
+	if err == nil {
+		err = gw.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Len 索引中文件和目录的数量
+func (idx *Index) Len() int {
+	return len(idx.files)
+}
+
+// Count 统计索引中的文件数, 目录数和文件总大小
+func (idx *Index) Count() (files, dirs, size int64) {
+	for _, fd := range idx.files {
+		if fd.Isdir {
+			dirs++
+			continue
+		}
+		files++
+		size += fd.Size
+	}
+	return
+}
+
+// Clear 清空索引
+func (idx *Index) Clear() {
+	idx.files = map[string]*baidupcs.FileDirectory{}
+	idx.children = map[string]map[string]*baidupcs.FileDirectory{}
+}
+
+// Put 添加或更新文件/目录
+func (idx *Index) Put(fd *baidupcs.FileDirectory) {
+	p := path.Clean(fd.Path)
+	if p == baidupcs.PathSeparator {
+		return
+	}
+
+	entry := &baidupcs.FileDirectory{
+		FsID:     fd.FsID,
+		Path:     p,
+		Filename: path.Base(p),
+		Size:     fd.Size,
+		Isdir:    fd.Isdir,
+		MD5:      fd.MD5,
+		Ctime:    fd.Ctime,
+		Mtime:    fd.Mtime,
+	}
+
+	// 目录变为文件时, 删除目录下的内容
+	if old := idx.files[p]; old != nil && old.Isdir && !entry.Isdir {
+		idx.removeChildren(p)
+	}
+
+	dir := path.Dir(p)
+	if idx.children[dir] == nil {
+		idx.children[dir] = map[string]*baidupcs.FileDirectory{}
+	}
+	idx.children[dir][p] = entry
+	idx.files[p] = entry
+}
+
+// Remove 删除文件/目录, 目录下的内容一并删除
+func (idx *Index) Remove(pcspath string) {
+	p := path.Clean(pcspath)
+	idx.removeChildren(p)
+	if _, ok := idx.files[p]; !ok {
+		return
+	}
+	delete(idx.files, p)
+	if m := idx.children[path.Dir(p)]; m != nil {
+		delete(m, p)
+		if len(m) == 0 {
+			delete(idx.children, path.Dir(p))
+		}
+	}
+}
+
+func (idx *Index) removeChildren(dir string) {
+	for p, fd := range idx.children[dir] {
+		if fd.Isdir {
+			idx.removeChildren(p)
+		}
+		delete(idx.files, p)
+	}
+	delete(idx.children, dir)
+}
+
+// Apply 应用文件变更, 变更的 cursor 已失效时清空索引
+func (idx *Index) Apply(diff *baidupcs.FileDiff) {
+	if diff.Reset {
+		idx.Clear()
+	}
+	for _, fd := range diff.Deleted {
+		idx.Remove(fd.Path)
+	}
+	for _, list := range []baidupcs.FileDirectoryList{diff.Added, diff.Modified} {
+		for _, fd := range list {
+			idx.Put(fd)
+		}
+	}
+	idx.Cursor = diff.Cursor
+}
+
+// FilesDirectoriesMeta 获取单个文件/目录的元信息
+func (idx *Index) FilesDirectoriesMeta(pcspath string) (*baidupcs.FileDirectory, error) {
+	p := path.Clean(pcspath)
+	if p == baidupcs.PathSeparator || p == "." {
+		return &baidupcs.FileDirectory{
+			Path:  baidupcs.PathSeparator,
+			Isdir: true,
+		}, nil
+	}
+	fd := idx.files[p]
+	if fd == nil {
+		return nil, fmt.Errorf("%s: %s", p, ErrNotFound)
+	}
+	copied := *fd
+	return &copied, nil
+}
+
+// FilesDirectoriesList 获取目录下的文件和目录列表, 目录排在前面
+func (idx *Index) FilesDirectoriesList(pcspath string, options *baidupcs.OrderOptions) (baidupcs.FileDirectoryList, error) {
+	fd, err := idx.FilesDirectoriesMeta(pcspath)
+	if err != nil {
+		return nil, err
+	}
+	if !fd.Isdir {
+		return nil, fmt.Errorf("%s: %s", fd.Path, ErrNotDir)
+	}
+
+	m := idx.children[fd.Path]
+	fdl := make(baidupcs.FileDirectoryList, 0, len(m))
+	for _, sub := range m {
+		copied := *sub
+		fdl = append(fdl, &copied)
+	}
+	sortFileDirectoryList(fdl, options)
+	return fdl, nil
+}
+
+// sortFileDirectoryList 按排序选项排序, 目录排在前面
+func sortFileDirectoryList(fdl baidupcs.FileDirectoryList, options *baidupcs.OrderOptions) {
+	if options == nil {
+		options = baidupcs.DefaultOrderOptions
+	}
+	sort.Slice(fdl, func(i, j int) bool {
+		a, b := fdl[i], fdl[j]
+		if a.Isdir != b.Isdir {
+			return a.Isdir
+		}
+		if options.Order == baidupcs.OrderDesc {
+			a, b = b, a
+		}
+		switch {
+		case options.By == baidupcs.OrderByTime && a.Mtime != b.Mtime:
+			return a.Mtime < b.Mtime
+		case options.By == baidupcs.OrderBySize && a.Size != b.Size:
+			return a.Size < b.Size
+		}
+		return a.Filename < b.Filename
+	})
+}
+
+// Search 按文件名搜索文件, 不区分大小写, 结果按路径排序
+func (idx *Index) Search(targetPath, keyword string, recursive bool) (baidupcs.FileDirectoryList, error) {
+	dir, err := idx.FilesDirectoriesMeta(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.Isdir {
+		return nil, fmt.Errorf("%s: %s", dir.Path, ErrNotDir)
+	}
+
+	var (
+		keywordLower = strings.ToLower(keyword)
+		prefix       = strings.TrimSuffix(dir.Path, baidupcs.PathSeparator) + baidupcs.PathSeparator
+		fdl          baidupcs.FileDirectoryList
+	)
+	for p, fd := range idx.files {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		if !recursive && path.Dir(p) != dir.Path {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(fd.Filename), keywordLower) {
+			continue
+		}
+		copied := *fd
+		fdl = append(fdl, &copied)
+	}
+	sort.Slice(fdl, func(i, j int) bool {
+		return fdl[i].Path < fdl[j].Path
+	})
+	return fdl, nil
+}
+
+// MatchPathByShellPattern 通配符匹配文件路径, pattern 为绝对路径
+func (idx *Index) MatchPathByShellPattern(pattern string) (pcspaths []string, err error) {
+	patternSlice := strings.Split(escaper.Escape(path.Clean(pattern), []rune{'['}), baidupcs.PathSeparator) // 转义中括号
+	if patternSlice[0] != "" {
+		return nil, ErrNotAbsPath
+	}
+
+	matched := []string{""}
+	for _, part := range patternSlice[1:] {
+		var next []string
+		for _, prefix := range matched {
+			if !strings.ContainsAny(part, baidupcs.ShellPatternCharacters) {
+				next = append(next, prefix+baidupcs.PathSeparator+part)
+				continue
+			}
+			dir := prefix
+			if dir == "" {
+				dir = baidupcs.PathSeparator
+			}
+			for p, fd := range idx.children[dir] {
+				if ok, _ := path.Match(part, fd.Filename); ok {
+					next = append(next, p)
+				}
+			}
+		}
+		sort.Strings(next)
+		matched = next
+	}
+	if len(matched) == 1 && matched[0] == "" {
+		return []string{baidupcs.PathSeparator}, nil
+	}
+	return matched, nil
+}
+
+// Update 更新索引. 有 cursor 或索引为空时, 通过文件变更增量更新, 获取文件变更失败时保留 cursor,
+// 再次调用时从中断处继续. 没有 cursor 或 relist 为 true 时, 重新列出修改日期有变化的目录, full 为 true 时重新列出全部目录.
+// handleError 处理列目录时的错误, 出错的目录保留原有的内容
+func (idx *Index) Update(lister Lister, relist, full bool, handleError func(dir string, err error)) (result *UpdateResult) {
+	result = &UpdateResult{}
+	defer func() {
+		idx.UpdateTime = time.Now().Unix()
+	}()
+
+	if !relist && !full && (idx.Cursor != "" || idx.Len() == 0) {
+		if idx.Cursor == "" {
+			// cursor 为空时返回全部文件
+			idx.Clear()
+		}
+		for {
+			diff, pcsError := lister.FilesDirectoriesDiff(idx.Cursor)
+			if pcsError != nil {
+				result.DiffErr = pcsError
+				if idx.Cursor != "" {
+					return result
+				}
+				// 不支持获取文件变更
+				break
+			}
+			if diff.Reset {
+				result.Added, result.Modified, result.Deleted = 0, 0, 0
+			}
+			result.Added += len(diff.Added)
+			result.Modified += len(diff.Modified)
+			result.Deleted += len(diff.Deleted)
+			idx.Apply(diff)
+			if !diff.HasMore {
+				return result
+			}
+		}
+	}
+
+	// 无法使用文件变更, 改为重新列目录
+	idx.Cursor = ""
+	result.Relist = true
+	idx.relist(lister, baidupcs.PathSeparator, full, result, handleError)
+	return result
+}
+
+// relist 重新列出目录, 只进入新增的和修改日期有变化的子目录, full 为 true 时进入全部子目录
+func (idx *Index) relist(lister Lister, dir string, full bool, result *UpdateResult, handleError func(dir string, err error)) {
+	fdl, pcsError := lister.FilesDirectoriesList(dir, baidupcs.DefaultOrderOptions)
+	if pcsError != nil {
+		if handleError != nil {
+			handleError(dir, pcsError)
+		}
+		return
+	}
+	result.Listed++
+
+	seen := make(map[string]bool, len(fdl))
+	for _, fd := range fdl {
+		p := path.Clean(fd.Path)
+		seen[p] = true
+
+		old := idx.files[p]
+		switch {
+		case old == nil:
+			result.Added++
+		case old.Mtime != fd.Mtime || old.Size != fd.Size || old.Isdir != fd.Isdir || old.FsID != fd.FsID:
+			result.Modified++
+		}
+		idx.Put(fd)
+
+		if fd.Isdir && (full || old == nil || !old.Isdir || old.Mtime != fd.Mtime) {
+			idx.relist(lister, p, full, result, handleError)
+		}
+	}
+
+	for p := range idx.children[dir] {
+		if !seen[p] {
+			idx.Remove(p)
+			result.Deleted++
+		}
+	}
+}
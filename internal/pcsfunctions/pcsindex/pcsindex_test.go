@@ -0,0 +1,163 @@
+package pcsindex_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsindex"
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeLister 模拟网盘, diffs 为依次返回的文件变更, 为空时获取变更失败
+type fakeLister struct {
+	diffs []*baidupcs.FileDiff
+	tree  map[string]baidupcs.FileDirectoryList
+	lists []string
+}
+
+func (fl *fakeLister) FilesDirectoriesDiff(cursor string) (*baidupcs.FileDiff, pcserror.Error) {
+	if len(fl.diffs) == 0 {
+		errInfo := pcserror.NewPanErrorInfo(baidupcs.OperationGetCursorDiff)
+		errInfo.ErrType = pcserror.ErrTypeOthers
+		errInfo.Err = errors.New("not supported")
+		return nil, errInfo
+	}
+	diff := fl.diffs[0]
+	fl.diffs = fl.diffs[1:]
+	return diff, nil
+}
+
+func (fl *fakeLister) FilesDirectoriesList(dir string, options *baidupcs.OrderOptions) (baidupcs.FileDirectoryList, pcserror.Error) {
+	fl.lists = append(fl.lists, dir)
+	return fl.tree[dir], nil
+}
+
+func fd(p string, isdir bool, size, mtime int64) *baidupcs.FileDirectory {
+	return &baidupcs.FileDirectory{Path: p, Filename: path.Base(p), Isdir: isdir, Size: size, Mtime: mtime}
+}
+
+func TestApply(t *testing.T) {
+	idx := pcsindex.New(1)
+	idx.Apply(&baidupcs.FileDiff{
+		Added:  baidupcs.FileDirectoryList{fd("/a", true, 0, 1), fd("/a/b", true, 0, 1), fd("/a/b/c.txt", false, 3, 1), fd("/a/z.mp4", false, 10, 2), fd("/x.txt", false, 1, 1)},
+		Cursor: "c1",
+	})
+	if idx.Cursor != "c1" || idx.Len() != 5 {
+		t.Fatalf("unexpected index: %s %d", idx.Cursor, idx.Len())
+	}
+
+	fdl, err := idx.FilesDirectoriesList("/a", &baidupcs.OrderOptions{By: baidupcs.OrderBySize, Order: baidupcs.OrderDesc})
+	if err != nil || len(fdl) != 2 || fdl[0].Filename != "b" || fdl[1].Filename != "z.mp4" {
+		t.Fatalf("unexpected list: %v %v", fdl, err)
+	}
+	if _, err = idx.FilesDirectoriesList("/x.txt", nil); err == nil {
+		t.Fatalf("listing a file should fail")
+	}
+
+	fdl, _ = idx.Search("/", "C.TXT", true)
+	if len(fdl) != 1 || fdl[0].Path != "/a/b/c.txt" {
+		t.Fatalf("unexpected search result: %v", fdl)
+	}
+	fdl, _ = idx.Search("/", ".txt", false)
+	if len(fdl) != 1 || fdl[0].Path != "/x.txt" {
+		t.Fatalf("unexpected search result: %v", fdl)
+	}
+
+	paths, _ := idx.MatchPathByShellPattern("/a/*/*.txt")
+	if len(paths) != 1 || paths[0] != "/a/b/c.txt" {
+		t.Fatalf("unexpected match: %v", paths)
+	}
+	paths, _ = idx.MatchPathByShellPattern("/")
+	if len(paths) != 1 || paths[0] != "/" {
+		t.Fatalf("unexpected match: %v", paths)
+	}
+
+	// 删除目录时一并删除目录下的内容
+	idx.Apply(&baidupcs.FileDiff{Deleted: baidupcs.FileDirectoryList{fd("/a/b", true, 0, 0)}, Cursor: "c2"})
+	if idx.Len() != 3 {
+		t.Fatalf("want 3 entries, got %d", idx.Len())
+	}
+	if _, err = idx.FilesDirectoriesMeta("/a/b/c.txt"); err == nil {
+		t.Fatalf("/a/b/c.txt should be removed")
+	}
+
+	idx.Apply(&baidupcs.FileDiff{Reset: true, Added: baidupcs.FileDirectoryList{fd("/y", false, 1, 1)}, Cursor: "c3"})
+	if files, dirs, size := idx.Count(); files != 1 || dirs != 0 || size != 1 {
+		t.Fatalf("unexpected count after reset: %d %d %d", files, dirs, size)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	fl := &fakeLister{
+		diffs: []*baidupcs.FileDiff{
+			{Added: baidupcs.FileDirectoryList{fd("/a", true, 0, 1)}, Cursor: "c1", HasMore: true},
+			{Added: baidupcs.FileDirectoryList{fd("/a/1.txt", false, 1, 1)}, Cursor: "c2"},
+		},
+	}
+	idx := pcsindex.New(1)
+	result := idx.Update(fl, false, false, nil)
+	if result.Relist || result.Added != 2 || idx.Cursor != "c2" {
+		t.Fatalf("unexpected result: %+v, cursor: %s", result, idx.Cursor)
+	}
+
+	// 获取变更失败时, 保留 cursor, 不重新列目录
+	fl.tree = map[string]baidupcs.FileDirectoryList{
+		"/":  {fd("/a", true, 0, 1), fd("/b", true, 0, 5)},
+		"/a": {fd("/a/2.txt", false, 2, 1)},
+		"/b": {fd("/b/3.txt", false, 3, 5)},
+	}
+	result = idx.Update(fl, false, false, nil)
+	if result.Relist || result.DiffErr == nil || idx.Cursor != "c2" || len(fl.lists) != 0 || idx.Len() != 2 {
+		t.Fatalf("want cursor kept, got %+v, cursor: %s, listed: %v", result, idx.Cursor, fl.lists)
+	}
+
+	// 重试时从 cursor 继续
+	fl.diffs = []*baidupcs.FileDiff{{Added: baidupcs.FileDirectoryList{fd("/a/4.txt", false, 4, 1)}, Cursor: "c3"}}
+	result = idx.Update(fl, false, false, nil)
+	if result.DiffErr != nil || result.Added != 1 || idx.Cursor != "c3" || idx.Len() != 3 {
+		t.Fatalf("unexpected result after retry: %+v, cursor: %s", result, idx.Cursor)
+	}
+
+	// relist 时重新列出修改日期有变化的目录
+	var errs int
+	result = idx.Update(fl, true, false, func(dir string, err error) { errs++ })
+	if !result.Relist || errs != 0 || idx.Cursor != "" {
+		t.Fatalf("want relist, got %+v", result)
+	}
+	if strings.Join(fl.lists, ",") != "/,/b" {
+		t.Fatalf("unexpected listed dirs: %v", fl.lists)
+	}
+	if _, err := idx.FilesDirectoriesMeta("/a/1.txt"); err != nil {
+		t.Fatalf("unchanged directory should be kept: %s", err)
+	}
+
+	fl.lists = nil
+	result = idx.Update(fl, true, true, nil)
+	if strings.Join(fl.lists, ",") != "/,/a,/b" || result.Added != 1 || result.Deleted != 2 {
+		t.Fatalf("unexpected full relist: %v %+v", fl.lists, result)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	idx := pcsindex.New(42)
+	idx.Apply(&baidupcs.FileDiff{Added: baidupcs.FileDirectoryList{fd("/a", true, 0, 1), fd("/a/1.txt", false, 1, 1)}, Cursor: "c1"})
+
+	filename := filepath.Join(t.TempDir(), "index.json.gz")
+	if err := idx.Save(filename); err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+	loaded, err := pcsindex.Load(filename)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if loaded.UID != 42 || loaded.Cursor != "c1" || loaded.Len() != 2 {
+		t.Fatalf("unexpected loaded index: %+v", loaded)
+	}
+	fdl, err := loaded.FilesDirectoriesList("/a", nil)
+	if err != nil || len(fdl) != 1 || fdl[0].Filename != "1.txt" {
+		t.Fatalf("unexpected list: %v %v", fdl, err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package pcsrecycle 按原路径, 通配符和删除时间匹配回收站中的文件
+package pcsrecycle
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// Matcher 回收站文件的匹配条件
+	Matcher struct {
+		Patterns      []string  // 原路径或通配符, 为绝对路径, 支持 ** 匹配任意层目录
+		DeletedAfter  time.Time // 删除时间不早于, 为零值时不限制
+		DeletedBefore time.Time // 删除时间早于, 为零值时不限制
+	}
+)
+
+// MatchPath 路径 p 是否匹配通配符 pattern, ** 匹配零层或任意层目录, 其余部分使用 path.Match 匹配
+func MatchPath(pattern, p string) bool {
+	return matchParts(splitPath(pattern), splitPath(p))
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(path.Clean(p), baidupcs.PathSeparator)
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, baidupcs.PathSeparator)
+}
+
+func matchParts(patterns, parts []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			// 连续的 ** 与一个等价
+			for len(patterns) > 0 && patterns[0] == "**" {
+				patterns = patterns[1:]
+			}
+			if len(patterns) == 0 {
+				return true
+			}
+			for i := range parts {
+				if matchParts(patterns, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(patterns[0], parts[0]); !ok {
+			return false
+		}
+		patterns, parts = patterns[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// DeletedTime 回收站中文件的删除时间, 移入回收站时服务器会更新文件的修改时间
+func DeletedTime(info *baidupcs.RecycleFDInfo) time.Time {
+	return time.Unix(info.Mtime, 0)
+}
+
+// Match 回收站中的文件是否满足条件, 没有设置通配符时只按删除时间匹配
+func (m *Matcher) Match(info *baidupcs.RecycleFDInfo) bool {
+	deleted := DeletedTime(info)
+	if !m.DeletedAfter.IsZero() && deleted.Before(m.DeletedAfter) {
+		return false
+	}
+	if !m.DeletedBefore.IsZero() && !deleted.Before(m.DeletedBefore) {
+		return false
+	}
+	if len(m.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range m.Patterns {
+		if MatchPath(pattern, info.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select 选出满足条件的文件
+func (m *Matcher) Select(list baidupcs.RecycleFDInfoList) (matched baidupcs.RecycleFDInfoList) {
+	for _, info := range list {
+		if info != nil && m.Match(info) {
+			matched = append(matched, info)
+		}
+	}
+	return
+}
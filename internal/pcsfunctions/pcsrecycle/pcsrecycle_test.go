@@ -0,0 +1,58 @@
+package pcsrecycle_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsrecycle"
+	"testing"
+	"time"
+)
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern string
+		p       string
+		want    bool
+	}{
+		{"/work/2023/a.psd", "/work/2023/a.psd", true},
+		{"/work/2023", "/work/2023/a.psd", false},
+		{"/work/*/a.psd", "/work/2023/a.psd", true},
+		{"/work/2023/**/*.psd", "/work/2023/a.psd", true},
+		{"/work/2023/**/*.psd", "/work/2023/x/y/b.psd", true},
+		{"/work/2023/**/*.psd", "/work/2024/b.psd", false},
+		{"/work/2023/**/*.psd", "/work/2023/x/b.png", false},
+		{"/**", "/a/b", true},
+		{"/work/**", "/work", true},
+		{"/**/**/c", "/a/b/c", true},
+		{"/*.txt", "/a/b.txt", false},
+	}
+	for _, c := range cases {
+		if got := pcsrecycle.MatchPath(c.pattern, c.p); got != c.want {
+			t.Fatalf("MatchPath(%s, %s) = %v, want %v", c.pattern, c.p, got, c.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	day := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	list := baidupcs.RecycleFDInfoList{
+		{FsID: 1, Path: "/work/a.psd", Mtime: day.Add(-time.Hour).Unix()},
+		{FsID: 2, Path: "/work/b.psd", Mtime: day.Unix()},
+		{FsID: 3, Path: "/work/c.png", Mtime: day.Add(time.Hour).Unix()},
+		nil,
+	}
+
+	m := &pcsrecycle.Matcher{Patterns: []string{"/work/*.psd"}, DeletedAfter: day}
+	if matched := m.Select(list); len(matched) != 1 || matched[0].FsID != 2 {
+		t.Fatalf("unexpected matched: %v", matched)
+	}
+
+	m = &pcsrecycle.Matcher{DeletedBefore: day}
+	if matched := m.Select(list); len(matched) != 1 || matched[0].FsID != 1 {
+		t.Fatalf("unexpected matched: %v", matched)
+	}
+
+	m = &pcsrecycle.Matcher{Patterns: []string{"/work/c.png", "/work/a.psd"}}
+	if matched := m.Select(list); len(matched) != 2 {
+		t.Fatalf("unexpected matched: %v", matched)
+	}
+}
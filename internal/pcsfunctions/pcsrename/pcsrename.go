@@ -0,0 +1,203 @@
+// Package pcsrename 批量重命名的模板和规划
+package pcsrename
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Template 文件名模板, 支持 {name}, {ext}, {mtime:2006-01-02}, {n:03}, {parent}
+	Template struct {
+		parts []templatePart
+	}
+
+	templatePart struct {
+		literal string
+		field   string // 为空时为普通文本
+		arg     string
+	}
+
+	// Renamer 根据文件信息和序号生成新的文件名
+	Renamer interface {
+		NewName(fd *baidupcs.FileDirectory, n int) (string, error)
+	}
+
+	// RegexpRenamer 使用正则表达式替换文件名, 替换内容支持 $1, ${name} 等
+	RegexpRenamer struct {
+		Regexp      *regexp.Regexp
+		Replacement string
+	}
+
+	// Rename 一个重命名操作
+	Rename struct {
+		From     string
+		To       string
+		Conflict string // 不为空时, 为冲突的原因
+	}
+
+	// Plan 重命名计划
+	Plan []*Rename
+)
+
+var (
+	// Location 模板中时间使用的时区
+	Location = time.Local
+)
+
+// ParseTemplate 解析文件名模板
+func ParseTemplate(s string) (*Template, error) {
+	t := &Template{}
+	for s != "" {
+		i := strings.IndexAny(s, "{}")
+		if i < 0 {
+			t.parts = append(t.parts, templatePart{literal: s})
+			break
+		}
+		if s[i] == '}' {
+			return nil, errors.New("模板中有多余的 }")
+		}
+		if i > 0 {
+			t.parts = append(t.parts, templatePart{literal: s[:i]})
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return nil, errors.New("模板中缺少 }")
+		}
+		field := s[i+1 : i+end]
+		s = s[i+end+1:]
+
+		var arg string
+		if k := strings.IndexByte(field, ':'); k >= 0 {
+			field, arg = field[:k], field[k+1:]
+		}
+		switch field {
+		case "name", "ext", "parent":
+			if arg != "" {
+				return nil, fmt.Errorf("{%s} 不支持参数", field)
+			}
+		case "mtime":
+			if arg == "" {
+				arg = "2006-01-02"
+			}
+		case "n":
+			if arg != "" {
+				if _, err := strconv.ParseUint(arg, 10, 8); err != nil {
+					return nil, fmt.Errorf("{n:%s} 的宽度错误", arg)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("未知的模板变量: {%s}", field)
+		}
+		t.parts = append(t.parts, templatePart{field: field, arg: arg})
+	}
+	return t, nil
+}
+
+// NewName 生成新的文件名, n 为序号. {ext} 包含 "."
+func (t *Template) NewName(fd *baidupcs.FileDirectory, n int) (string, error) {
+	var (
+		builder  strings.Builder
+		filename = path.Base(fd.Path)
+		ext      = path.Ext(filename)
+	)
+	if fd.Isdir {
+		ext = ""
+	}
+	for _, part := range t.parts {
+		switch part.field {
+		case "":
+			builder.WriteString(part.literal)
+		case "name":
+			builder.WriteString(strings.TrimSuffix(filename, ext))
+		case "ext":
+			builder.WriteString(ext)
+		case "parent":
+			builder.WriteString(path.Base(path.Dir(fd.Path)))
+		case "mtime":
+			builder.WriteString(time.Unix(fd.Mtime, 0).In(Location).Format(part.arg))
+		case "n":
+			builder.WriteString(fmt.Sprintf("%"+part.arg+"d", n))
+		}
+	}
+	return builder.String(), nil
+}
+
+// NewName 生成新的文件名, 不匹配时文件名不变
+func (rr *RegexpRenamer) NewName(fd *baidupcs.FileDirectory, n int) (string, error) {
+	return rr.Regexp.ReplaceAllString(path.Base(fd.Path), rr.Replacement), nil
+}
+
+// NewPlan 生成重命名计划, 序号从 start 开始, 文件名不变的文件不重命名.
+// exists 判断目标路径是否已存在, 检测冲突
+func NewPlan(files baidupcs.FileDirectoryList, renamer Renamer, start int, exists func(pcspath string) bool) (plan Plan, err error) {
+	sources := map[string]bool{}
+	for _, fd := range files {
+		sources[fd.Path] = true
+	}
+
+	targets := map[string]*Rename{}
+	for k, fd := range files {
+		name, err := renamer.NewName(fd, start+k)
+		if err != nil {
+			return nil, err
+		}
+		if name == path.Base(fd.Path) {
+			continue
+		}
+
+		r := &Rename{
+			From: fd.Path,
+			To:   path.Join(path.Dir(fd.Path), name),
+		}
+		plan = append(plan, r)
+
+		switch {
+		case name == "" || name == "." || name == "..":
+			r.Conflict = "新文件名为空"
+		case strings.Contains(name, baidupcs.PathSeparator):
+			r.Conflict = "新文件名不能包含 /"
+		case targets[r.To] != nil:
+			r.Conflict = "与 " + targets[r.To].From + " 的新文件名相同"
+			if targets[r.To].Conflict == "" {
+				targets[r.To].Conflict = "与 " + r.From + " 的新文件名相同"
+			}
+		case sources[r.To]:
+			r.Conflict = "新文件名与其他待重命名的文件相同"
+		case exists != nil && exists(r.To):
+			r.Conflict = "文件已存在"
+		}
+		if targets[r.To] == nil {
+			targets[r.To] = r
+		}
+	}
+	return plan, nil
+}
+
+// Conflicts 冲突的数量
+func (p Plan) Conflicts() (n int) {
+	for _, r := range p {
+		if r.Conflict != "" {
+			n++
+		}
+	}
+	return
+}
+
+// CpMvJSONList 转换为批量移动的参数
+func (p Plan) CpMvJSONList() (list baidupcs.CpMvJSONList) {
+	list = make(baidupcs.CpMvJSONList, 0, len(p))
+	for _, r := range p {
+		list = append(list, &baidupcs.CpMvJSON{
+			From: r.From,
+			To:   r.To,
+		})
+	}
+	return list
+}
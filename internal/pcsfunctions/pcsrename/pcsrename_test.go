@@ -0,0 +1,98 @@
+package pcsrename_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsrename"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTemplate(t *testing.T) {
+	pcsrename.Location = time.UTC
+	fd := &baidupcs.FileDirectory{
+		Path:  "/photos/2019 trip/IMG_0001.JPG",
+		Mtime: time.Date(2019, 7, 1, 8, 0, 0, 0, time.UTC).Unix(),
+	}
+
+	cases := []struct {
+		tmpl string
+		want string
+	}{
+		{"{name}{ext}", "IMG_0001.JPG"},
+		{"{parent}-{n:03}{ext}", "2019 trip-007.JPG"},
+		{"{mtime}_{name}{ext}", "2019-07-01_IMG_0001.JPG"},
+		{"{mtime:20060102-1504}{ext}", "20190701-0800.JPG"},
+		{"photo {n}", "photo 7"},
+	}
+	for _, c := range cases {
+		tmpl, err := pcsrename.ParseTemplate(c.tmpl)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%s): %s", c.tmpl, err)
+		}
+		got, _ := tmpl.NewName(fd, 7)
+		if got != c.want {
+			t.Fatalf("%s: got %s, want %s", c.tmpl, got, c.want)
+		}
+	}
+
+	for _, s := range []string{"{name", "name}", "{size}", "{n:x}", "{name:1}"} {
+		if _, err := pcsrename.ParseTemplate(s); err == nil {
+			t.Fatalf("ParseTemplate(%s) should fail", s)
+		}
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	files := baidupcs.FileDirectoryList{
+		{Path: "/a/x1.txt"},
+		{Path: "/a/x2.txt"},
+		{Path: "/a/y.txt"},
+		{Path: "/a/z.log"},
+	}
+	existing := map[string]bool{"/a/y.log": true}
+	exists := func(p string) bool { return existing[p] }
+
+	rr := &pcsrename.RegexpRenamer{Regexp: regexp.MustCompile(`^x(\d)\.txt$`), Replacement: "file-$1.txt"}
+	plan, err := pcsrename.NewPlan(files, rr, 1, exists)
+	if err != nil {
+		t.Fatalf("NewPlan: %s", err)
+	}
+	if len(plan) != 2 || plan.Conflicts() != 0 || plan[0].To != "/a/file-1.txt" || plan[1].To != "/a/file-2.txt" {
+		t.Fatalf("unexpected plan: %+v %+v", plan[0], plan[1])
+	}
+	if list := plan.CpMvJSONList(); len(list) != 2 || list[1].From != "/a/x2.txt" {
+		t.Fatalf("unexpected CpMvJSONList")
+	}
+
+	// 多个文件重命名为同一个文件名, 与已存在的文件冲突, 与其他待重命名的文件冲突
+	tmpl, _ := pcsrename.ParseTemplate("y.log")
+	plan, _ = pcsrename.NewPlan(files[:2], tmpl, 1, exists)
+	if plan.Conflicts() != 2 {
+		t.Fatalf("want 2 conflicts, got %d", plan.Conflicts())
+	}
+	tmpl, _ = pcsrename.ParseTemplate("{name}.md")
+	plan, _ = pcsrename.NewPlan(files, tmpl, 1, exists)
+	if plan.Conflicts() != 0 || len(plan) != 4 {
+		t.Fatalf("unexpected conflicts: %d", plan.Conflicts())
+	}
+	tmpl, _ = pcsrename.ParseTemplate("y{ext}")
+	plan, _ = pcsrename.NewPlan(files[2:], tmpl, 1, exists)
+	if len(plan) != 1 || plan[0].Conflict == "" {
+		t.Fatalf("want conflict with existing file, got %+v", plan)
+	}
+	tmpl, _ = pcsrename.ParseTemplate("x2.txt")
+	plan, _ = pcsrename.NewPlan(files[:1], tmpl, 1, nil)
+	if plan.Conflicts() != 0 {
+		t.Fatalf("unexpected conflict when target is not being renamed")
+	}
+	plan, _ = pcsrename.NewPlan(files[:2], tmpl, 1, nil)
+	if plan.Conflicts() != 1 {
+		t.Fatalf("want conflict with file being renamed, got %d", plan.Conflicts())
+	}
+	tmpl, _ = pcsrename.ParseTemplate("sub/{name}")
+	plan, _ = pcsrename.NewPlan(files[:1], tmpl, 1, nil)
+	if plan.Conflicts() != 1 {
+		t.Fatalf("want conflict for name with /")
+	}
+}
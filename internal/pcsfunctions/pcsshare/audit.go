@@ -0,0 +1,93 @@
+package pcsshare
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// FlagExpired 分享已过期
+	FlagExpired = "expired"
+	// FlagNoPassword 公开分享或没有提取码
+	FlagNoPassword = "public_no_password"
+	// FlagPathDeleted 分享的文件已被删除
+	FlagPathDeleted = "path_deleted"
+)
+
+type (
+	// CancelFilter 按条件筛选要取消的分享, 多个条件同时满足才匹配
+	CancelFilter struct {
+		Expired   bool      // 已过期
+		PathGlob  string    // 分享的路径或其上级目录匹配通配符
+		OlderThan time.Time // 创建时间早于
+	}
+)
+
+// IsExpired 分享是否已过期
+func IsExpired(record *baidupcs.ShareRecordInfo) bool {
+	return record.ExpireType == -1
+}
+
+// HasNoPassword 分享是否为公开分享或没有提取码, 私密分享需要先设置 record.Passwd
+func HasNoPassword(record *baidupcs.ShareRecordInfo) bool {
+	return record.Public != 0 || strings.TrimSpace(record.Passwd) == ""
+}
+
+// IsPathDeleted 根据分享的代表路径 (typicalPath) 在网盘中的元信息 fd 判断分享的文件是否已被删除, 文件不存在时 fd 为 nil.
+// 路径存在, 但 fs_id 不在分享的文件中时, 说明原文件已被删除, 同名文件是之后新建的.
+// 分享多个文件时只检查代表路径, 其他文件被删除时无法发现
+func IsPathDeleted(record *baidupcs.ShareRecordInfo, fd *baidupcs.FileDirectory) bool {
+	if fd == nil {
+		return true
+	}
+	if len(record.FsIds) == 0 {
+		return false
+	}
+	for _, fsID := range record.FsIds {
+		if fsID == fd.FsID {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmpty 是否没有任何条件
+func (cf *CancelFilter) IsEmpty() bool {
+	return cf == nil || (!cf.Expired && cf.PathGlob == "" && cf.OlderThan.IsZero())
+}
+
+// Match 分享是否匹配所有条件, PathGlob 格式错误时返回错误
+func (cf *CancelFilter) Match(record *baidupcs.ShareRecordInfo) (bool, error) {
+	if cf.IsEmpty() {
+		return false, nil
+	}
+	if cf.Expired && !IsExpired(record) {
+		return false, nil
+	}
+	if !cf.OlderThan.IsZero() && (record.Ctime == 0 || record.Ctime >= cf.OlderThan.Unix()) {
+		return false, nil
+	}
+	if cf.PathGlob != "" {
+		return matchPathOrParent(cf.PathGlob, record.TypicalPath)
+	}
+	return true, nil
+}
+
+// matchPathOrParent p 或 p 的上级目录是否匹配通配符 pattern
+func matchPathOrParent(pattern, p string) (bool, error) {
+	if p == "" {
+		return false, nil
+	}
+	pattern = path.Clean(pattern)
+	for p = path.Clean(p); ; p = path.Dir(p) {
+		ok, err := path.Match(pattern, p)
+		if err != nil || ok {
+			return ok, err
+		}
+		if p == baidupcs.PathSeparator || p == "." {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package pcsshare 从文本中解析分享链接和提取码, 转存结果的分类, 以及分享的检查和筛选
+package pcsshare
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	// StatusSuccess 转存成功
+	StatusSuccess = "success"
+	// StatusInvalidLink 链接无效
+	StatusInvalidLink = "invalid_link"
+	// StatusWrongCode 提取码错误
+	StatusWrongCode = "wrong_code"
+	// StatusExpired 分享已失效
+	StatusExpired = "expired"
+	// StatusQuotaFull 网盘容量不足或超过转存数量上限
+	StatusQuotaFull = "quota_full"
+	// StatusVerify 请求过于频繁, 触发验证
+	StatusVerify = "verify"
+	// StatusFailed 其他错误
+	StatusFailed = "failed"
+)
+
+var (
+	linkRegexp = regexp.MustCompile(`(?:https?://)?pan\.baidu\.com/(?:s/([0-9A-Za-z_-]+)|share/init\?surl=([0-9A-Za-z_-]+))(?:\?pwd=([0-9A-Za-z]{4}))?`)
+	// 提取码: xxxx, 密码：xxxx
+	codeRegexp = regexp.MustCompile(`(?:提取码|提取碼|密码|密碼|pwd|code)\s*[:：=]?\s*([0-9A-Za-z]{4})(?:[^0-9A-Za-z]|$)`)
+	// 链接后紧跟的提取码
+	followCodeRegexp = regexp.MustCompile(`^\s+([0-9A-Za-z]{4})(?:[^0-9A-Za-z]|$)`)
+
+	// 错误信息中的关键字, 按顺序匹配
+	statusKeywords = []struct {
+		status   string
+		keywords []string
+	}{
+		{StatusWrongCode, []string{"提取码错误"}},
+		{StatusExpired, []string{"分享链接已失效", "已过期", "已失效"}},
+		{StatusInvalidLink, []string{"链接地址或提取码非法", "页面不存在"}},
+		{StatusQuotaFull, []string{"超过当前用户上限", "容量不足"}},
+		{StatusVerify, []string{"已触发验证"}},
+	}
+)
+
+type (
+	// ShareLink 解析得到的分享链接
+	ShareLink struct {
+		Line int    // 所在的行号, 从 1 开始
+		Link string // 不含提取码的链接
+		Pwd  string // 提取码, 没有时为空
+	}
+)
+
+// ParseLine 解析一行文本中的分享链接, 提取码可以是 ?pwd=xxxx, 紧跟在链接后, 或 "提取码: xxxx".
+// 一行中只有一个链接时, "提取码: xxxx" 可以出现在链接之前
+func ParseLine(line string) []*ShareLink {
+	matches := linkRegexp.FindAllStringSubmatchIndex(line, -1)
+	links := make([]*ShareLink, 0, len(matches))
+	for i, m := range matches {
+		sl := &ShareLink{}
+		if m[2] >= 0 {
+			sl.Link = "https://pan.baidu.com/s/" + line[m[2]:m[3]]
+		} else {
+			sl.Link = "https://pan.baidu.com/s/1" + line[m[4]:m[5]]
+		}
+
+		// 当前链接到下一个链接之间的文本
+		rest := line[m[1]:]
+		if i+1 < len(matches) {
+			rest = line[m[1]:matches[i+1][0]]
+		}
+		switch {
+		case m[6] >= 0:
+			sl.Pwd = line[m[6]:m[7]]
+		case followCodeRegexp.MatchString(rest):
+			sl.Pwd = followCodeRegexp.FindStringSubmatch(rest)[1]
+		case codeRegexp.MatchString(rest):
+			sl.Pwd = codeRegexp.FindStringSubmatch(rest)[1]
+		case len(matches) == 1 && codeRegexp.MatchString(line[:m[0]]):
+			sl.Pwd = codeRegexp.FindStringSubmatch(line[:m[0]])[1]
+		}
+		links = append(links, sl)
+	}
+	return links
+}
+
+// ParseCode 解析一行文本中的 "提取码: xxxx"
+func ParseCode(line string) (code string, ok bool) {
+	sub := codeRegexp.FindStringSubmatch(line)
+	if sub == nil {
+		return "", false
+	}
+	return sub[1], true
+}
+
+// ParseLinks 逐行解析 r 中的分享链接, 重复的链接只保留第一个.
+// 链接所在行没有提取码时, 使用下一行中不含链接的 "提取码: xxxx"
+func ParseLinks(r io.Reader) ([]*ShareLink, error) {
+	var (
+		links   []*ShareLink
+		seen    = map[string]bool{}
+		pending *ShareLink // 等待下一行提取码的链接
+		scanner = bufio.NewScanner(r)
+		lineNum int
+	)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		found := ParseLine(line)
+		if len(found) == 0 {
+			if pending != nil {
+				if code, ok := ParseCode(line); ok {
+					pending.Pwd = code
+				}
+			}
+			if line != "" {
+				pending = nil
+			}
+			continue
+		}
+
+		pending = nil
+		for _, sl := range found {
+			if seen[sl.Link] {
+				continue
+			}
+			seen[sl.Link] = true
+			sl.Line = lineNum
+			links = append(links, sl)
+		}
+		if last := found[len(found)-1]; last.Pwd == "" {
+			pending = last
+		}
+	}
+	return links, scanner.Err()
+}
+
+// Classify 根据转存返回的错误信息分类, err 为 nil 时为 StatusSuccess
+func Classify(err error) string {
+	if err == nil {
+		return StatusSuccess
+	}
+	msg := err.Error()
+	for _, sk := range statusKeywords {
+		for _, keyword := range sk.keywords {
+			if strings.Contains(msg, keyword) {
+				return sk.status
+			}
+		}
+	}
+	return StatusFailed
+}
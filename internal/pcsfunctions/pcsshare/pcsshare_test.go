@@ -0,0 +1,153 @@
+package pcsshare_test
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsshare"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	cases := []struct {
+		line      string
+		link, pwd string
+	}{
+		{"https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e", "https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ", "704e"},
+		{"pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ?pwd=704e", "https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ", "704e"},
+		{"链接: https://pan.baidu.com/s/1abc-_d 提取码: ab12 复制这段内容后打开百度网盘手机App", "https://pan.baidu.com/s/1abc-_d", "ab12"},
+		{"链接：https://pan.baidu.com/s/1abc 提取码：AB12", "https://pan.baidu.com/s/1abc", "AB12"},
+		{"提取码:zz99 https://pan.baidu.com/s/1abc", "https://pan.baidu.com/s/1abc", "zz99"},
+		{"https://pan.baidu.com/share/init?surl=abc", "https://pan.baidu.com/s/1abc", ""},
+		{"https://pan.baidu.com/s/1abc 复制这段内容", "https://pan.baidu.com/s/1abc", ""},
+	}
+	for _, c := range cases {
+		links := pcsshare.ParseLine(c.line)
+		if len(links) != 1 {
+			t.Fatalf("ParseLine(%q) got %d links", c.line, len(links))
+		}
+		if links[0].Link != c.link || links[0].Pwd != c.pwd {
+			t.Fatalf("ParseLine(%q) = %s %s, want %s %s", c.line, links[0].Link, links[0].Pwd, c.link, c.pwd)
+		}
+	}
+
+	links := pcsshare.ParseLine("https://pan.baidu.com/s/1a 1111, https://pan.baidu.com/s/1b, https://pan.baidu.com/s/1c?pwd=3333")
+	if len(links) != 3 || links[0].Pwd != "1111" || links[1].Pwd != "" || links[2].Pwd != "3333" {
+		t.Fatalf("ParseLine multiple links: %+v %+v %+v", links[0], links[1], links[2])
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	text := `第一个
+https://pan.baidu.com/s/1a
+提取码: aaaa
+
+https://pan.baidu.com/s/1b
+其他文字
+提取码: xxxx
+https://pan.baidu.com/s/1a?pwd=aaaa
+链接: https://pan.baidu.com/s/1c 提取码: cccc`
+
+	links, err := pcsshare.ParseLinks(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseLinks: %s", err)
+	}
+	want := []pcsshare.ShareLink{
+		{Line: 2, Link: "https://pan.baidu.com/s/1a", Pwd: "aaaa"},
+		{Line: 5, Link: "https://pan.baidu.com/s/1b", Pwd: ""},
+		{Line: 9, Link: "https://pan.baidu.com/s/1c", Pwd: "cccc"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("ParseLinks got %d links, want %d", len(links), len(want))
+	}
+	for i := range want {
+		if *links[i] != want[i] {
+			t.Fatalf("ParseLinks[%d] = %+v, want %+v", i, *links[i], want[i])
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := map[string]string{
+		"":           pcsshare.StatusSuccess,
+		"提取码错误":      pcsshare.StatusWrongCode,
+		"分享链接已失效":    pcsshare.StatusExpired,
+		"页面不存在":      pcsshare.StatusInvalidLink,
+		"链接地址或提取码非法": pcsshare.StatusInvalidLink,
+		"转存文件数600超过当前用户上限, 当前用户单次最大转存数500": pcsshare.StatusQuotaFull,
+		"已触发验证, 请稍后再试":                     pcsshare.StatusVerify,
+		"未知错误, 错误码2":                       pcsshare.StatusFailed,
+	}
+	for msg, want := range cases {
+		var err error
+		if msg != "" {
+			err = errors.New(msg)
+		}
+		if got := pcsshare.Classify(err); got != want {
+			t.Fatalf("Classify(%q) = %s, want %s", msg, got, want)
+		}
+	}
+}
+
+func TestCancelFilter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	record := &baidupcs.ShareRecordInfo{
+		TypicalPath: "/公开/电影/a.mkv",
+		ExpireType:  -1,
+		Ctime:       now.Add(-10 * 24 * time.Hour).Unix(),
+	}
+	cases := []struct {
+		filter pcsshare.CancelFilter
+		want   bool
+	}{
+		{pcsshare.CancelFilter{Expired: true}, true},
+		{pcsshare.CancelFilter{PathGlob: "/公开"}, true},
+		{pcsshare.CancelFilter{PathGlob: "/公开/*/*.mkv"}, true},
+		{pcsshare.CancelFilter{PathGlob: "/私密"}, false},
+		{pcsshare.CancelFilter{OlderThan: now.Add(-7 * 24 * time.Hour)}, true},
+		{pcsshare.CancelFilter{OlderThan: now.Add(-30 * 24 * time.Hour)}, false},
+		{pcsshare.CancelFilter{Expired: true, PathGlob: "/私密"}, false},
+		{pcsshare.CancelFilter{}, false},
+	}
+	for i, c := range cases {
+		got, err := c.filter.Match(record)
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if got != c.want {
+			t.Fatalf("case %d: Match = %v, want %v", i, got, c.want)
+		}
+	}
+
+	record.ExpireType = 0
+	if ok, _ := (&pcsshare.CancelFilter{Expired: true}).Match(record); ok {
+		t.Fatalf("unexpired share matched --expired")
+	}
+	if _, err := (&pcsshare.CancelFilter{PathGlob: "["}).Match(record); err == nil {
+		t.Fatalf("expected error for bad pattern")
+	}
+}
+
+func TestIsPathDeleted(t *testing.T) {
+	record := &baidupcs.ShareRecordInfo{TypicalPath: "/a.mkv", FsIds: []int64{1, 2}}
+	cases := []struct {
+		fd   *baidupcs.FileDirectory
+		want bool
+	}{
+		{nil, true},
+		{&baidupcs.FileDirectory{Path: "/a.mkv", FsID: 2}, false},
+		{&baidupcs.FileDirectory{Path: "/a.mkv", FsID: 3}, true}, // 删除后新建的同名文件
+	}
+	for i, c := range cases {
+		if got := pcsshare.IsPathDeleted(record, c.fd); got != c.want {
+			t.Fatalf("case %d: IsPathDeleted = %v, want %v", i, got, c.want)
+		}
+	}
+
+	// 没有 fs_id 时只检查路径是否存在
+	record.FsIds = nil
+	if pcsshare.IsPathDeleted(record, &baidupcs.FileDirectory{Path: "/a.mkv", FsID: 3}) {
+		t.Fatalf("share without fs_id should not be flagged")
+	}
+}
@@ -0,0 +1,318 @@
+package pcssync
+
+import (
+	"BaiduPCS-Go/pcsutil"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// ConflictPolicy 双向同步冲突处理策略
+	ConflictPolicy string
+
+	// BisyncOptions 双向同步比较可选项
+	BisyncOptions struct {
+		CheckMD5 bool           // 两边都发生改变且大小一致时, 比较md5
+		LocalMD5 LocalMD5Func   // 计算本地文件md5
+		Policy   ConflictPolicy // 冲突处理策略
+		Now      time.Time      // 用于生成冲突文件名, 默认为当前时间
+	}
+)
+
+const (
+	// ConflictKeepBoth 保留两者, 本地文件重命名后上传
+	ConflictKeepBoth ConflictPolicy = "keep-both"
+	// ConflictNewer 保留修改时间较新的文件
+	ConflictNewer ConflictPolicy = "newer"
+	// ConflictLocal 以本地文件为准
+	ConflictLocal ConflictPolicy = "local"
+	// ConflictRemote 以网盘文件为准
+	ConflictRemote ConflictPolicy = "remote"
+)
+
+// ParseConflictPolicy 解析冲突处理策略
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictKeepBoth, ConflictNewer, ConflictLocal, ConflictRemote:
+		return p, nil
+	case "":
+		return ConflictKeepBoth, nil
+	}
+	return "", fmt.Errorf("未知的冲突处理策略: %s", s)
+}
+
+// ConflictName 生成冲突文件的文件名, 形如 a.conflict-20060102-150405.txt
+func ConflictName(p string, t time.Time) string {
+	ext := path.Ext(p)
+	return strings.TrimSuffix(p, ext) + ".conflict-" + t.Format("20060102-150405") + ext
+}
+
+// localChanged 本地文件相对于上次同步是否发生改变
+func localChanged(l *FileEntry, b *SyncedEntry) bool {
+	return l.Size != b.Size || l.Mtime != b.LocalMtime
+}
+
+// remoteChanged 网盘文件相对于上次同步是否发生改变
+func remoteChanged(r *FileEntry, b *SyncedEntry) bool {
+	if r.Size != b.Size || r.Mtime != b.RemoteMtime {
+		return true
+	}
+	return r.MD5 != "" && b.MD5 != "" && !strings.EqualFold(r.MD5, b.MD5)
+}
+
+// detectRenames 检测上次同步后的重命名或移动, 返回 旧路径 -> 新路径.
+// 网盘通过 fs_id 判断, 本地通过大小和修改时间判断, 仅在唯一匹配时生效
+func detectRenames(local, remote FileEntries, base SyncedEntries) (remoteRenames, localRenames map[string]string) {
+	remoteRenames, localRenames = map[string]string{}, map[string]string{}
+
+	remoteByFsID := map[int64]string{}
+	for p, r := range remote {
+		if _, ok := base[p]; ok || r.IsDir || r.FsID == 0 {
+			continue
+		}
+		remoteByFsID[r.FsID] = p
+	}
+
+	type sizeMtime struct{ size, mtime int64 }
+	localNew := map[sizeMtime][]string{}
+	for p, l := range local {
+		if _, ok := base[p]; ok || l.IsDir {
+			continue
+		}
+		key := sizeMtime{l.Size, l.Mtime}
+		localNew[key] = append(localNew[key], p)
+	}
+
+	usedLocal := map[string]bool{}
+	for p, b := range base {
+		if b.IsDir {
+			continue
+		}
+		_, inLocal := local[p]
+		_, inRemote := remote[p]
+
+		if !inRemote && inLocal && b.FsID != 0 {
+			if q, ok := remoteByFsID[b.FsID]; ok {
+				if _, exists := local[q]; !exists {
+					remoteRenames[p] = q
+				}
+			}
+		}
+
+		if inRemote && !inLocal {
+			candidates := localNew[sizeMtime{b.Size, b.LocalMtime}]
+			if len(candidates) == 1 && !usedLocal[candidates[0]] {
+				if _, exists := remote[candidates[0]]; !exists {
+					localRenames[p] = candidates[0]
+					usedLocal[candidates[0]] = true
+				}
+			}
+		}
+	}
+	return
+}
+
+// PlanBidirectional 根据上次同步的状态 base, 比较本地和网盘的文件, 生成双向同步的计划
+func PlanBidirectional(local, remote FileEntries, base SyncedEntries, opt *BisyncOptions) (plan Plan, err error) {
+	if opt == nil {
+		opt = &BisyncOptions{}
+	}
+	if opt.Policy == "" {
+		opt.Policy = ConflictKeepBoth
+	}
+	if opt.Now.IsZero() {
+		opt.Now = time.Now()
+	}
+	if base == nil {
+		base = SyncedEntries{}
+	}
+
+	// 已由重命名处理的路径
+	handled := map[string]bool{}
+	remoteRenames, localRenames := detectRenames(local, remote, base)
+	for from, to := range remoteRenames {
+		if localChanged(local[from], base[from]) {
+			// 本地已修改, 不跟随重命名
+			continue
+		}
+		plan = append(plan, &Action{Type: ActionMoveLocal, Path: from, NewPath: to, Local: local[from], Remote: remote[to], Reason: "网盘文件已移动"})
+		handled[from], handled[to] = true, true
+	}
+	for from, to := range localRenames {
+		if handled[from] || handled[to] || remoteChanged(remote[from], base[from]) {
+			continue
+		}
+		plan = append(plan, &Action{Type: ActionMoveRemote, Path: from, NewPath: to, Local: local[to], Remote: remote[from], Reason: "本地文件已移动"})
+		handled[from], handled[to] = true, true
+	}
+
+	paths := map[string]bool{}
+	for p := range local {
+		paths[p] = true
+	}
+	for p := range remote {
+		paths[p] = true
+	}
+	for p := range base {
+		paths[p] = true
+	}
+
+	// 先处理文件
+	for p := range paths {
+		if handled[p] {
+			continue
+		}
+		l, r, b := local[p], remote[p], base[p]
+		if (l != nil && l.IsDir) || (r != nil && r.IsDir) || (l == nil && r == nil) {
+			continue
+		}
+
+		var action *Action
+		switch {
+		case b == nil || b.IsDir:
+			switch {
+			case r == nil:
+				action = &Action{Type: ActionUpload, Reason: "本地新增"}
+			case l == nil:
+				action = &Action{Type: ActionDownload, Reason: "网盘新增"}
+			default:
+				action, err = resolveConflict(l, r, opt, true)
+			}
+		case l == nil:
+			if remoteChanged(r, b) {
+				action = &Action{Type: ActionDownload, Reason: "本地已删除, 网盘已修改"}
+			} else {
+				action = &Action{Type: ActionDeleteRemote, Reason: "本地已删除"}
+			}
+		case r == nil:
+			if localChanged(l, b) {
+				action = &Action{Type: ActionUpload, Reason: "网盘已删除, 本地已修改"}
+			} else {
+				action = &Action{Type: ActionDeleteLocal, Reason: "网盘已删除"}
+			}
+		default:
+			lc, rc := localChanged(l, b), remoteChanged(r, b)
+			switch {
+			case lc && rc:
+				action, err = resolveConflict(l, r, opt, false)
+			case lc:
+				action = &Action{Type: ActionOverwrite, Reason: "本地已修改"}
+			case rc:
+				action = &Action{Type: ActionDownloadOverwrite, Reason: "网盘已修改"}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if action == nil {
+			continue
+		}
+		action.Path, action.Local, action.Remote = p, l, r
+		if action.Type == ActionKeepBoth {
+			action.NewPath = ConflictName(p, opt.Now)
+		}
+		plan = append(plan, action)
+	}
+
+	// 再处理目录, 目录下仍有需要保留的文件时, 不删除目录
+	busy := map[string]bool{}
+	for _, action := range plan {
+		if action.Type == ActionDeleteLocal || action.Type == ActionDeleteRemote {
+			continue
+		}
+		for _, p := range []string{action.Path, action.NewPath} {
+			for dir := path.Dir(p); p != "" && dir != "." && dir != "/"; dir = path.Dir(dir) {
+				busy[dir] = true
+			}
+		}
+	}
+	for p := range paths {
+		l, r, b := local[p], remote[p], base[p]
+		isDir := (l != nil && l.IsDir) || (r != nil && r.IsDir) || (l == nil && r == nil && b != nil && b.IsDir)
+		if !isDir || handled[p] {
+			continue
+		}
+		switch {
+		case l != nil && r != nil:
+		case l != nil && r == nil:
+			if b != nil && !busy[p] {
+				plan = append(plan, &Action{Type: ActionDeleteLocal, Path: p, Local: l, Reason: "网盘已删除"})
+			} else if b == nil && !busy[p] {
+				plan = append(plan, &Action{Type: ActionMkdirRemote, Path: p, Local: l, Reason: "本地新增"})
+			}
+		case l == nil && r != nil:
+			if b != nil && !busy[p] {
+				plan = append(plan, &Action{Type: ActionDeleteRemote, Path: p, Remote: r, Reason: "本地已删除"})
+			} else if b == nil && !busy[p] {
+				plan = append(plan, &Action{Type: ActionMkdirLocal, Path: p, Remote: r, Reason: "网盘新增"})
+			}
+		}
+	}
+
+	plan = plan.pruneDeletes()
+	plan.sort()
+	return plan, nil
+}
+
+// pruneDeletes 删除目录时, 去除目录下文件的删除动作
+func (p Plan) pruneDeletes() (plan Plan) {
+	deletedDirs := map[ActionType]map[string]bool{
+		ActionDeleteLocal:  {},
+		ActionDeleteRemote: {},
+	}
+	for _, action := range p {
+		if action.Type != ActionDeleteLocal && action.Type != ActionDeleteRemote {
+			continue
+		}
+		if (action.Local != nil && action.Local.IsDir) || (action.Remote != nil && action.Remote.IsDir) {
+			deletedDirs[action.Type][action.Path] = true
+		}
+	}
+	for _, action := range p {
+		if dirs, ok := deletedDirs[action.Type]; ok && pcsutil.IsUnderDirs(action.Path, dirs) {
+			continue
+		}
+		plan = append(plan, action)
+	}
+	return
+}
+
+// resolveConflict 按策略处理两边都发生改变的文件, initial 为 true 表示两边都是新增的文件
+func resolveConflict(l, r *FileEntry, opt *BisyncOptions, initial bool) (action *Action, err error) {
+	if l.Size == r.Size {
+		if opt.CheckMD5 && opt.LocalMD5 != nil && r.MD5 != "" {
+			if l.MD5 == "" {
+				l.MD5, err = opt.LocalMD5(l)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if strings.EqualFold(l.MD5, r.MD5) {
+				// 内容一致, 只需记录状态
+				return nil, nil
+			}
+		} else if initial {
+			// 首次同步, 无法比较md5时, 认为大小一致的文件内容一致
+			return nil, nil
+		}
+	}
+
+	reason := "冲突: 两边都已修改"
+	if initial {
+		reason = "冲突: 两边都新增"
+	}
+	switch opt.Policy {
+	case ConflictLocal:
+		return &Action{Type: ActionOverwrite, Reason: reason + ", 以本地为准"}, nil
+	case ConflictRemote:
+		return &Action{Type: ActionDownloadOverwrite, Reason: reason + ", 以网盘为准"}, nil
+	case ConflictNewer:
+		if l.Mtime > r.Mtime {
+			return &Action{Type: ActionOverwrite, Reason: reason + ", 本地较新"}, nil
+		}
+		return &Action{Type: ActionDownloadOverwrite, Reason: reason + ", 网盘较新"}, nil
+	}
+	return &Action{Type: ActionKeepBoth, Reason: reason + ", 保留两者"}, nil
+}
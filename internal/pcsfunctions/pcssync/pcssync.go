@@ -0,0 +1,306 @@
+// Package pcssync 本地目录与网盘目录的同步计划
+package pcssync
+
+import (
+	"BaiduPCS-Go/pcsutil"
+	"sort"
+	"strings"
+)
+
+type (
+	// FileEntry 参与同步比较的文件或目录信息
+	FileEntry struct {
+		Path  string // 相对于同步根目录的路径, 以 / 分隔
+		Size  int64
+		Mtime int64
+		MD5   string
+		FsID  int64
+		IsDir bool
+	}
+
+	// FileEntries 以相对路径为键的文件信息
+	FileEntries map[string]*FileEntry
+
+	// ActionType 同步动作类型
+	ActionType int
+
+	// Action 同步动作
+	Action struct {
+		Type    ActionType
+		Path    string // 相对路径
+		NewPath string // 移动或重命名的目标相对路径
+		Local   *FileEntry
+		Remote  *FileEntry
+		Reason  string // 产生该动作的原因
+	}
+
+	// Plan 同步计划
+	Plan []*Action
+
+	// LocalMD5Func 计算本地文件的md5, 仅在需要比较md5时调用
+	LocalMD5Func func(entry *FileEntry) (string, error)
+
+	// CompareOptions 比较可选项
+	CompareOptions struct {
+		CheckMD5 bool         // 大小一致时, 比较md5
+		Delete   bool         // 删除目标中多余的文件
+		LocalMD5 LocalMD5Func // 计算本地文件md5
+	}
+)
+
+const (
+	// ActionUpload 上传新文件
+	ActionUpload ActionType = iota
+	// ActionOverwrite 覆盖网盘文件
+	ActionOverwrite
+	// ActionDeleteRemote 删除网盘文件
+	ActionDeleteRemote
+	// ActionDownload 下载新文件
+	ActionDownload
+	// ActionDownloadOverwrite 覆盖本地文件
+	ActionDownloadOverwrite
+	// ActionDeleteLocal 删除本地文件
+	ActionDeleteLocal
+	// ActionMoveRemote 移动网盘文件, 对应本地的重命名
+	ActionMoveRemote
+	// ActionMoveLocal 移动本地文件, 对应网盘的重命名
+	ActionMoveLocal
+	// ActionMkdirRemote 创建网盘目录
+	ActionMkdirRemote
+	// ActionMkdirLocal 创建本地目录
+	ActionMkdirLocal
+	// ActionKeepBoth 冲突, 本地文件重命名为 NewPath 后上传, 再下载网盘文件
+	ActionKeepBoth
+)
+
+func (at ActionType) String() string {
+	switch at {
+	case ActionUpload:
+		return "上传"
+	case ActionOverwrite:
+		return "覆盖"
+	case ActionDeleteRemote:
+		return "删除网盘文件"
+	case ActionDownload:
+		return "下载"
+	case ActionDownloadOverwrite:
+		return "更新本地文件"
+	case ActionDeleteLocal:
+		return "删除本地文件"
+	case ActionMoveRemote:
+		return "移动网盘文件"
+	case ActionMoveLocal:
+		return "移动本地文件"
+	case ActionMkdirRemote:
+		return "创建网盘目录"
+	case ActionMkdirLocal:
+		return "创建本地目录"
+	case ActionKeepBoth:
+		return "保留两者"
+	}
+	return "未知"
+}
+
+// Add 加入文件信息
+func (fe FileEntries) Add(entry *FileEntry) {
+	fe[entry.Path] = entry
+}
+
+// Count 统计某种动作的数量
+func (p Plan) Count(at ActionType) (n int) {
+	for _, action := range p {
+		if action.Type == at {
+			n++
+		}
+	}
+	return
+}
+
+// TotalSize 统计某种动作涉及的源文件总大小, 上传统计本地文件, 下载统计网盘文件
+func (p Plan) TotalSize(at ActionType) (size int64) {
+	for _, action := range p {
+		if action.Type != at {
+			continue
+		}
+		switch at {
+		case ActionDownload, ActionDownloadOverwrite, ActionDeleteRemote, ActionKeepBoth:
+			if action.Remote != nil {
+				size += action.Remote.Size
+			}
+		default:
+			if action.Local != nil {
+				size += action.Local.Size
+			}
+		}
+	}
+	return
+}
+
+// DeleteCount 统计计划将删除的文件和目录数量, 删除目录时包含目录下的全部文件和目录
+func (p Plan) DeleteCount(local, remote FileEntries) (n int) {
+	var (
+		localDirs  = map[string]bool{}
+		remoteDirs = map[string]bool{}
+	)
+	for _, action := range p {
+		switch action.Type {
+		case ActionDeleteLocal:
+			n++
+			localDirs[action.Path] = true
+		case ActionDeleteRemote:
+			n++
+			remoteDirs[action.Path] = true
+		}
+	}
+	for entryPath := range local {
+		if pcsutil.IsUnderDirs(entryPath, localDirs) {
+			n++
+		}
+	}
+	for entryPath := range remote {
+		if pcsutil.IsUnderDirs(entryPath, remoteDirs) {
+			n++
+		}
+	}
+	return
+}
+
+func (p Plan) sort() {
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].Path < p[j].Path
+	})
+}
+
+// PlanUpload 比较本地和网盘的文件, 生成将本地同步到网盘的计划
+func PlanUpload(local, remote FileEntries, opt *CompareOptions) (plan Plan, err error) {
+	if opt == nil {
+		opt = &CompareOptions{}
+	}
+
+	for p, l := range local {
+		if l.IsDir {
+			continue
+		}
+
+		r, ok := remote[p]
+		if !ok {
+			plan = append(plan, &Action{Type: ActionUpload, Path: p, Local: l, Reason: "网盘不存在"})
+			continue
+		}
+		if r.IsDir {
+			// 类型不一致, 交给用户处理
+			continue
+		}
+
+		reason, err := changed(l, r, opt, true)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			plan = append(plan, &Action{Type: ActionOverwrite, Path: p, Local: l, Remote: r, Reason: reason})
+		}
+	}
+
+	if opt.Delete {
+		plan = append(plan, planDelete(remote, local, ActionDeleteRemote, "本地不存在")...)
+	}
+
+	plan.sort()
+	return plan, nil
+}
+
+// PlanDownload 比较网盘和本地的文件, 生成将网盘镜像到本地的计划
+func PlanDownload(local, remote FileEntries, opt *CompareOptions) (plan Plan, err error) {
+	if opt == nil {
+		opt = &CompareOptions{}
+	}
+
+	for p, r := range remote {
+		if r.IsDir {
+			continue
+		}
+
+		l, ok := local[p]
+		if !ok {
+			plan = append(plan, &Action{Type: ActionDownload, Path: p, Remote: r, Reason: "本地不存在"})
+			continue
+		}
+		if l.IsDir {
+			// 类型不一致, 交给用户处理
+			continue
+		}
+
+		reason, err := changed(l, r, opt, false)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			plan = append(plan, &Action{Type: ActionDownloadOverwrite, Path: p, Local: l, Remote: r, Reason: reason})
+		}
+	}
+
+	if opt.Delete {
+		plan = append(plan, planDelete(local, remote, ActionDeleteLocal, "网盘不存在")...)
+	}
+
+	plan.sort()
+	return plan, nil
+}
+
+// planDelete 生成删除 target 中 source 不存在的文件的动作
+func planDelete(target, source FileEntries, at ActionType, reason string) (plan Plan) {
+	// 删除整个目录时, 不再逐个删除目录下的文件
+	deletedDirs := map[string]bool{}
+	paths := make([]string, 0, len(target))
+	for p := range target {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if _, ok := source[p]; ok || pcsutil.IsUnderDirs(p, deletedDirs) {
+			continue
+		}
+		entry := target[p]
+		if entry.IsDir {
+			deletedDirs[p] = true
+		}
+		action := &Action{Type: at, Path: p, Reason: reason}
+		if at == ActionDeleteLocal {
+			action.Local = entry
+		} else {
+			action.Remote = entry
+		}
+		plan = append(plan, action)
+	}
+	return
+}
+
+// changed 判断本地文件和网盘文件是否不一致, 返回原因,
+// upload 为 true 时以本地文件为准, 否则以网盘文件为准
+func changed(l, r *FileEntry, opt *CompareOptions, upload bool) (reason string, err error) {
+	if l.Size != r.Size {
+		return "大小不一致", nil
+	}
+	if opt.CheckMD5 && opt.LocalMD5 != nil && r.MD5 != "" {
+		if l.MD5 == "" {
+			l.MD5, err = opt.LocalMD5(l)
+			if err != nil {
+				return "", err
+			}
+		}
+		if !strings.EqualFold(l.MD5, r.MD5) {
+			return "md5不一致", nil
+		}
+		return "", nil
+	}
+	if upload && l.Mtime > r.Mtime {
+		return "本地文件较新", nil
+	}
+	if !upload && r.Mtime > l.Mtime {
+		return "网盘文件较新", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,183 @@
+package pcssync_test
+
+import (
+	"BaiduPCS-Go/internal/pcsfunctions/pcssync"
+	"testing"
+	"time"
+)
+
+func TestPlanUpload(t *testing.T) {
+	local := pcssync.FileEntries{}
+	local.Add(&pcssync.FileEntry{Path: "a.txt", Size: 1, Mtime: 100})
+	local.Add(&pcssync.FileEntry{Path: "b.txt", Size: 2, Mtime: 100})
+	local.Add(&pcssync.FileEntry{Path: "c.txt", Size: 3, Mtime: 300})
+	local.Add(&pcssync.FileEntry{Path: "d.txt", Size: 4, Mtime: 100})
+
+	remote := pcssync.FileEntries{}
+	remote.Add(&pcssync.FileEntry{Path: "b.txt", Size: 20, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "c.txt", Size: 3, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "d.txt", Size: 4, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "old", IsDir: true})
+	remote.Add(&pcssync.FileEntry{Path: "old/e.txt", Size: 5})
+
+	plan, err := pcssync.PlanUpload(local, remote, &pcssync.CompareOptions{Delete: true})
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	expected := []struct {
+		path string
+		at   pcssync.ActionType
+	}{
+		{"a.txt", pcssync.ActionUpload},
+		{"b.txt", pcssync.ActionOverwrite},
+		{"c.txt", pcssync.ActionOverwrite},
+		{"old", pcssync.ActionDeleteRemote},
+	}
+	if len(plan) != len(expected) {
+		t.Fatalf("plan length %d, expected %d", len(plan), len(expected))
+	}
+	for k, e := range expected {
+		if plan[k].Path != e.path || plan[k].Type != e.at {
+			t.Fatalf("plan[%d] = %s %s, expected %s %s", k, plan[k].Type, plan[k].Path, e.at, e.path)
+		}
+	}
+}
+
+func TestPlanUploadMD5(t *testing.T) {
+	local := pcssync.FileEntries{}
+	local.Add(&pcssync.FileEntry{Path: "a.txt", Size: 1, Mtime: 300})
+	remote := pcssync.FileEntries{}
+	remote.Add(&pcssync.FileEntry{Path: "a.txt", Size: 1, Mtime: 200, MD5: "ABCDEF"})
+
+	plan, err := pcssync.PlanUpload(local, remote, &pcssync.CompareOptions{
+		CheckMD5: true,
+		LocalMD5: func(entry *pcssync.FileEntry) (string, error) {
+			return "abcdef", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	if len(plan) != 0 {
+		t.Fatalf("unexpected plan: %s %s", plan[0].Type, plan[0].Path)
+	}
+}
+
+func TestPlanDownload(t *testing.T) {
+	local := pcssync.FileEntries{}
+	local.Add(&pcssync.FileEntry{Path: "a.txt", Size: 1, Mtime: 200})
+	local.Add(&pcssync.FileEntry{Path: "b.txt", Size: 2, Mtime: 100})
+	local.Add(&pcssync.FileEntry{Path: "tmp", IsDir: true})
+	local.Add(&pcssync.FileEntry{Path: "tmp/c.txt", Size: 3})
+
+	remote := pcssync.FileEntries{}
+	remote.Add(&pcssync.FileEntry{Path: "a.txt", Size: 1, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "b.txt", Size: 2, Mtime: 300})
+	remote.Add(&pcssync.FileEntry{Path: "d.txt", Size: 4, Mtime: 300})
+
+	plan, err := pcssync.PlanDownload(local, remote, &pcssync.CompareOptions{Delete: true})
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	if len(plan) != 3 ||
+		plan[0].Path != "b.txt" || plan[0].Type != pcssync.ActionDownloadOverwrite ||
+		plan[1].Path != "d.txt" || plan[1].Type != pcssync.ActionDownload ||
+		plan[2].Path != "tmp" || plan[2].Type != pcssync.ActionDeleteLocal {
+		for _, action := range plan {
+			t.Logf("%s %s", action.Type, action.Path)
+		}
+		t.Fatalf("unexpected plan")
+	}
+}
+
+func TestPlanBidirectional(t *testing.T) {
+	base := pcssync.SyncedEntries{
+		"same.txt":    {Size: 1, LocalMtime: 100, RemoteMtime: 200},
+		"local.txt":   {Size: 1, LocalMtime: 100, RemoteMtime: 200},
+		"remote.txt":  {Size: 1, LocalMtime: 100, RemoteMtime: 200},
+		"both.txt":    {Size: 1, LocalMtime: 100, RemoteMtime: 200},
+		"gone.txt":    {Size: 1, LocalMtime: 100, RemoteMtime: 200},
+		"moved.txt":   {Size: 1, LocalMtime: 100, RemoteMtime: 200, FsID: 42},
+		"renamed.txt": {Size: 7, LocalMtime: 700, RemoteMtime: 800, FsID: 43},
+	}
+
+	local := pcssync.FileEntries{}
+	local.Add(&pcssync.FileEntry{Path: "same.txt", Size: 1, Mtime: 100})
+	local.Add(&pcssync.FileEntry{Path: "local.txt", Size: 2, Mtime: 300})
+	local.Add(&pcssync.FileEntry{Path: "remote.txt", Size: 1, Mtime: 100})
+	local.Add(&pcssync.FileEntry{Path: "both.txt", Size: 2, Mtime: 300})
+	local.Add(&pcssync.FileEntry{Path: "moved.txt", Size: 1, Mtime: 100})
+	local.Add(&pcssync.FileEntry{Path: "new/renamed.txt", Size: 7, Mtime: 700})
+
+	remote := pcssync.FileEntries{}
+	remote.Add(&pcssync.FileEntry{Path: "same.txt", Size: 1, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "local.txt", Size: 1, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "remote.txt", Size: 3, Mtime: 400})
+	remote.Add(&pcssync.FileEntry{Path: "both.txt", Size: 3, Mtime: 400})
+	remote.Add(&pcssync.FileEntry{Path: "gone.txt", Size: 1, Mtime: 200})
+	remote.Add(&pcssync.FileEntry{Path: "dir/moved.txt", Size: 1, Mtime: 500, FsID: 42})
+	remote.Add(&pcssync.FileEntry{Path: "renamed.txt", Size: 7, Mtime: 800, FsID: 43})
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	plan, err := pcssync.PlanBidirectional(local, remote, base, &pcssync.BisyncOptions{Now: now})
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	expected := map[string]pcssync.ActionType{
+		"local.txt":   pcssync.ActionOverwrite,
+		"remote.txt":  pcssync.ActionDownloadOverwrite,
+		"both.txt":    pcssync.ActionKeepBoth,
+		"gone.txt":    pcssync.ActionDeleteRemote,
+		"moved.txt":   pcssync.ActionMoveLocal,
+		"renamed.txt": pcssync.ActionMoveRemote,
+	}
+	if len(plan) != len(expected) {
+		for _, action := range plan {
+			t.Logf("%s %s %s", action.Type, action.Path, action.NewPath)
+		}
+		t.Fatalf("plan length %d, expected %d", len(plan), len(expected))
+	}
+	for _, action := range plan {
+		if expected[action.Path] != action.Type {
+			t.Fatalf("%s: got %s, expected %s", action.Path, action.Type, expected[action.Path])
+		}
+		switch action.Path {
+		case "both.txt":
+			if action.NewPath != "both.conflict-20200102-030405.txt" {
+				t.Fatalf("unexpected conflict name: %s", action.NewPath)
+			}
+		case "moved.txt":
+			if action.NewPath != "dir/moved.txt" {
+				t.Fatalf("unexpected move target: %s", action.NewPath)
+			}
+		case "renamed.txt":
+			if action.NewPath != "new/renamed.txt" {
+				t.Fatalf("unexpected rename target: %s", action.NewPath)
+			}
+		}
+	}
+}
+
+func TestPlanDeleteCount(t *testing.T) {
+	local := pcssync.FileEntries{}
+	local.Add(&pcssync.FileEntry{Path: "a", IsDir: true})
+	local.Add(&pcssync.FileEntry{Path: "a/1.txt"})
+	local.Add(&pcssync.FileEntry{Path: "a/b", IsDir: true})
+	local.Add(&pcssync.FileEntry{Path: "a/b/2.txt"})
+	local.Add(&pcssync.FileEntry{Path: "ab.txt"})
+
+	remote := pcssync.FileEntries{}
+	remote.Add(&pcssync.FileEntry{Path: "c.txt"})
+	remote.Add(&pcssync.FileEntry{Path: "a2", IsDir: true})
+
+	plan := pcssync.Plan{
+		{Type: pcssync.ActionDeleteLocal, Path: "a", Local: local["a"]},
+		{Type: pcssync.ActionDeleteRemote, Path: "c.txt", Remote: remote["c.txt"]},
+		{Type: pcssync.ActionUpload, Path: "ab.txt", Local: local["ab.txt"]},
+	}
+	if n := plan.DeleteCount(local, remote); n != 5 {
+		t.Fatalf("delete count %d, expected 5", n)
+	}
+}
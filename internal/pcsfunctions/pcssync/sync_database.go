@@ -0,0 +1,175 @@
+package pcssync
+
+import (
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/jsonhelper"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SyncStateFileName 双向同步状态数据库的文件名
+	SyncStateFileName = "pcs_sync_state.json"
+)
+
+type (
+	// SyncedEntry 上次同步完成时文件的状态
+	SyncedEntry struct {
+		Size        int64  `json:"size"`
+		LocalMtime  int64  `json:"local_mtime"`
+		RemoteMtime int64  `json:"remote_mtime"`
+		MD5         string `json:"md5,omitempty"`
+		FsID        int64  `json:"fs_id,omitempty"`
+		IsDir       bool   `json:"isdir,omitempty"`
+	}
+
+	// SyncedEntries 以相对路径为键的同步状态
+	SyncedEntries map[string]*SyncedEntry
+
+	// SyncPair 一组同步的本地目录和网盘目录
+	SyncPair struct {
+		UID       uint64        `json:"uid"`
+		LocalDir  string        `json:"local_dir"`
+		PCSDir    string        `json:"pcs_dir"`
+		Entries   SyncedEntries `json:"entries"`
+		Timestamp int64         `json:"timestamp"`
+	}
+
+	// SyncStateDatabase 双向同步状态数据库
+	SyncStateDatabase struct {
+		lock      sync.Mutex
+		Pairs     []*SyncPair `json:"sync_state"`
+		Timestamp int64       `json:"timestamp"`
+
+		dataFile *os.File
+	}
+)
+
+// NewSyncStateDatabase 初始化双向同步状态数据库, 从库中读取内容
+func NewSyncStateDatabase() (sd *SyncStateDatabase, err error) {
+	file, err := os.OpenFile(filepath.Join(pcsconfig.GetConfigDir(), SyncStateFileName), os.O_CREATE|os.O_RDWR, 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	sd = &SyncStateDatabase{
+		dataFile: file,
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() <= 0 {
+		return sd, nil
+	}
+
+	err = jsonhelper.UnmarshalData(file, sd)
+	if err != nil {
+		// 数据损坏, 当作首次同步
+		sd.Pairs = nil
+	}
+
+	return sd, nil
+}
+
+// Pair 获取同步目录的状态, 不存在则新建
+func (sd *SyncStateDatabase) Pair(uid uint64, localDir, pcsDir string) *SyncPair {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if abs, err := filepath.Abs(localDir); err == nil {
+		localDir = abs
+	}
+	for _, pair := range sd.Pairs {
+		if pair.UID == uid && pair.LocalDir == localDir && pair.PCSDir == pcsDir {
+			if pair.Entries == nil {
+				pair.Entries = SyncedEntries{}
+			}
+			return pair
+		}
+	}
+
+	pair := &SyncPair{
+		UID:      uid,
+		LocalDir: localDir,
+		PCSDir:   pcsDir,
+		Entries:  SyncedEntries{},
+	}
+	sd.Pairs = append(sd.Pairs, pair)
+	return pair
+}
+
+// Update 更新同步目录的状态
+func (sd *SyncStateDatabase) Update(pair *SyncPair, entries SyncedEntries) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	pair.Entries = entries
+	pair.Timestamp = time.Now().Unix()
+}
+
+// Save 保存内容
+func (sd *SyncStateDatabase) Save() error {
+	if sd.dataFile == nil {
+		return errors.New("dataFile is nil")
+	}
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.Timestamp = time.Now().Unix()
+
+	var (
+		builder = &strings.Builder{}
+		err     = jsonhelper.MarshalData(builder, sd)
+	)
+	if err != nil {
+		return err
+	}
+
+	err = sd.dataFile.Truncate(int64(builder.Len()))
+	if err != nil {
+		return err
+	}
+
+	_, err = sd.dataFile.WriteAt(converter.ToBytes(builder.String()), 0)
+	return err
+}
+
+// Close 关闭数据库
+func (sd *SyncStateDatabase) Close() error {
+	return sd.dataFile.Close()
+}
+
+// NewSyncedEntries 根据同步完成后两边的文件信息生成同步状态,
+// 只记录两边都存在且大小一致的文件, skip 中的路径沿用旧的状态
+func NewSyncedEntries(local, remote FileEntries, old SyncedEntries, skip map[string]bool) SyncedEntries {
+	entries := SyncedEntries{}
+	for p := range skip {
+		if b, ok := old[p]; ok {
+			entries[p] = b
+		}
+	}
+	for p, l := range local {
+		if skip[p] {
+			continue
+		}
+		r, ok := remote[p]
+		if !ok || l.IsDir != r.IsDir || (!l.IsDir && l.Size != r.Size) {
+			continue
+		}
+		entries[p] = &SyncedEntry{
+			Size:        r.Size,
+			LocalMtime:  l.Mtime,
+			RemoteMtime: r.Mtime,
+			MD5:         r.MD5,
+			FsID:        r.FsID,
+			IsDir:       r.IsDir,
+		}
+	}
+	return entries
+}
@@ -0,0 +1,38 @@
+package pcsupload
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/requester/rio"
+	"BaiduPCS-Go/requester/uploader"
+)
+
+// UploadReaderAt 不经过秒传, 将 r 中的数据分片上传到网盘的 savePath, 用于上传不在本地磁盘的数据.
+// 上传完成或失败后返回, statusFunc 可为 nil
+func UploadReaderAt(pcs *baidupcs.BaiduPCS, savePath, policy string, r rio.ReaderAtLen64, parallel int, statusFunc uploader.UploadStatusFunc) (err error) {
+	pcsError, jsonData := pcs.FakeRapidUpload(savePath, policy, r.Len())
+	if pcsError != nil {
+		return pcsError
+	}
+
+	if parallel < 1 {
+		parallel = 1
+	}
+	muer := uploader.NewMultiUploader(NewPCSUpload(pcs, savePath), r, &uploader.MultiUploaderConfig{
+		Parallel:  parallel,
+		BlockSize: getBlockSize(r.Len()),
+		MaxRate:   pcsconfig.Config.MaxUploadRate,
+		Policy:    policy,
+	}, savePath)
+	muer.SetInstanceState(&uploader.InstanceState{
+		Uploadid: jsonData.UploadID,
+	})
+	if statusFunc != nil {
+		muer.OnUploadStatusEvent(statusFunc)
+	}
+	muer.OnError(func(uperr error) {
+		err = uperr
+	})
+	muer.Execute()
+	return
+}
@@ -7,6 +7,7 @@ import (
 	"BaiduPCS-Go/internal/pcsfunctions"
 	"BaiduPCS-Go/pcsutil/checksum"
 	"BaiduPCS-Go/pcsutil/converter"
+	"BaiduPCS-Go/pcsutil/e2ee"
 	"BaiduPCS-Go/pcsutil/taskframework"
 	"BaiduPCS-Go/requester/rio"
 	"BaiduPCS-Go/requester/uploader"
@@ -34,9 +35,15 @@ type (
 		PCS               *baidupcs.BaiduPCS
 		UploadingDatabase *UploadingDatabase // 数据库
 		Parallel          int
-		NoRapidUpload     bool   // 禁用秒传
-		NoSplitFile       bool   // 禁用分片上传
-		Policy            string // 上传重名文件策略
+		NoRapidUpload     bool         // 禁用秒传
+		NoSplitFile       bool         // 禁用分片上传
+		Policy            string       // 上传重名文件策略
+		Cipher            *e2ee.Cipher // 不为nil时, 加密上传, 禁用秒传和断点续传
+
+		// StatusFunc 不为nil时, 上传状态交给 StatusFunc 处理, 不输出上传进度
+		StatusFunc func(status uploader.Status)
+		// OnStartUpload 开始上传时调用, 可用于取消上传
+		OnStartUpload func(muer *uploader.MultiUploader)
 
 		UploadStatistic *UploadStatistic
 
@@ -117,6 +124,38 @@ func (utu *UploadTaskUnit) prepareFile() {
 	utu.Step = StepUploadRapidUpload
 }
 
+// prepareEncryptedFile 加密上传的准备阶段, 加密后的数据无法秒传, 直接获取上传id
+func (utu *UploadTaskUnit) prepareEncryptedFile() (result *taskframework.TaskUnitRunResult) {
+	var (
+		panDir, panFile = path.Split(utu.SavePath)
+	)
+	utu.panDir = path.Clean(panDir)
+	utu.panFile = panFile
+
+	fmt.Printf("[%s] 加密上传, 跳过秒传...\n", utu.taskInfo.Id())
+	pcsError, jsonData := utu.PCS.FakeRapidUpload(utu.SavePath, utu.Policy, e2ee.EncryptedSize(utu.LocalFileChecksum.Length))
+	if pcsError != nil {
+		result = &taskframework.TaskUnitRunResult{}
+		switch pcsError.GetRemoteErrCode() {
+		case 114514, 1919810:
+			result.Succeed = true
+			result.ResultMessage = fmt.Sprintf("%s 目标已存在, 跳过", utu.SavePath)
+			fmt.Printf("[%s] 目标文件已存在, 跳过...\n", utu.taskInfo.Id())
+		default:
+			result.ResultMessage = "预上传失败"
+			result.Err = pcsError
+			result.NeedRetry = true
+		}
+		return
+	}
+
+	utu.state = &uploader.InstanceState{
+		Uploadid: jsonData.UploadID,
+	}
+	utu.Step = StepUploadUpload
+	return nil
+}
+
 // rapidUpload 执行秒传
 func (utu *UploadTaskUnit) rapidUpload() (isContinue bool, result *taskframework.TaskUnitRunResult) {
 	utu.Step = StepUploadRapidUpload
@@ -273,7 +312,20 @@ func (utu *UploadTaskUnit) upload() (result *taskframework.TaskUnitRunResult) {
 
 	blockSize := getBlockSize(utu.LocalFileChecksum.Length)
 
-	muer := uploader.NewMultiUploader(NewPCSUpload(utu.PCS, utu.SavePath), rio.NewFileReaderAtLen64(utu.LocalFileChecksum.GetFile()), &uploader.MultiUploaderConfig{
+	file := rio.NewFileReaderAtLen64(utu.LocalFileChecksum.GetFile())
+	if utu.Cipher != nil {
+		encryptReader, err := utu.Cipher.NewEncryptReaderAt(file, utu.LocalFileChecksum.Length)
+		if err != nil {
+			return &taskframework.TaskUnitRunResult{
+				ResultMessage: "初始化加密错误",
+				Err:           err,
+			}
+		}
+		file = encryptReader
+		blockSize = getBlockSize(encryptReader.Len())
+	}
+
+	muer := uploader.NewMultiUploader(NewPCSUpload(utu.PCS, utu.SavePath), file, &uploader.MultiUploaderConfig{
 		Parallel:  utu.Parallel,
 		BlockSize: blockSize,
 		MaxRate:   pcsconfig.Config.MaxUploadRate,
@@ -287,13 +339,18 @@ func (utu *UploadTaskUnit) upload() (result *taskframework.TaskUnitRunResult) {
 	muer.OnUploadStatusEvent(func(status uploader.Status, updateChan <-chan struct{}) {
 		select {
 		case <-updateChan:
-			if utu.state.Uploadid != "" {
+			// 加密上传每次的密钥不同, 不保存断点信息
+			if utu.state.Uploadid != "" && utu.Cipher == nil {
 				utu.UploadingDatabase.UpdateUploading(&utu.LocalFileChecksum.LocalFileMeta, muer.InstanceState())
 				utu.UploadingDatabase.Save()
 			}
 		default:
 		}
 
+		if utu.StatusFunc != nil {
+			utu.StatusFunc(status)
+			return
+		}
 		fmt.Printf(utu.PrintFormat, utu.taskInfo.Id(),
 			converter.ConvertFileSize(status.Uploaded(), 2),
 			converter.ConvertFileSize(status.TotalSize(), 2),
@@ -388,6 +445,9 @@ func (utu *UploadTaskUnit) upload() (result *taskframework.TaskUnitRunResult) {
 		}
 		return
 	})
+	if utu.OnStartUpload != nil {
+		utu.OnStartUpload(muer)
+	}
 	muer.Execute()
 
 	return
@@ -439,7 +499,14 @@ func (utu *UploadTaskUnit) Run() (result *taskframework.TaskUnitRunResult) {
 	defer utu.LocalFileChecksum.Close() // 关闭文件
 
 	// 准备文件
-	utu.prepareFile()
+	if utu.Cipher != nil {
+		result = utu.prepareEncryptedFile()
+		if result != nil {
+			return
+		}
+	} else {
+		utu.prepareFile()
+	}
 
 	switch utu.Step {
 	case StepUploadRapidUpload:
@@ -0,0 +1,269 @@
+package pcswebdav
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	errNotSupported = errors.New("operation not supported")
+)
+
+type (
+	// readFile 只读的文件, 读取时从网盘下载, 支持 Seek
+	readFile struct {
+		fs *FileSystem
+		fd *baidupcs.FileDirectory
+
+		offset     int64
+		body       io.ReadCloser
+		bodyOffset int64
+		dlink      string
+	}
+
+	// dirFile 目录
+	dirFile struct {
+		fs *FileSystem
+		fd *baidupcs.FileDirectory
+
+		pos int
+	}
+
+	// writeFile 写入的文件, 先写入本地临时文件, 关闭时上传
+	writeFile struct {
+		fs   *FileSystem
+		path string
+		tmp  *os.File
+		body *uploadBody // PUT 请求体, 未完整读取时不上传
+		err  error       // 写入临时文件的错误
+	}
+)
+
+// openBody 从 offset 开始下载
+func (rf *readFile) openBody(offset int64) error {
+	if rf.dlink == "" {
+		dlinks, err := pcsdownload.GetLocateDownloadLinks(rf.fs.PCS, rf.fd.Path)
+		if err != nil {
+			return err
+		}
+		dlink := dlinks[0]
+		// 跳过nb.cache这种还没有证书的
+		if strings.HasPrefix(dlink.Host, "nb.cache") && len(dlinks) > 1 {
+			dlink = dlinks[1]
+		}
+		pcsdownload.FixHTTPLinkURL(dlink)
+		rf.dlink = dlink.String()
+	}
+
+	client := pcsconfig.Config.PanHTTPClient()
+	client.SetTimeout(0)
+	client.SetResponseHeaderTimeout(30 * time.Second)
+	jar, err := pcsdownload.CloneJarWithDomain(rf.fs.PCS.GetClient().Jar, rf.dlink)
+	if err == nil {
+		client.SetCookiejar(jar)
+	}
+
+	resp, err := client.Req(http.MethodGet, rf.dlink, nil, map[string]string{
+		"Range": fmt.Sprintf("bytes=%d-", offset),
+	})
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		// 链接可能已失效, 下次重新获取
+		rf.dlink = ""
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		if offset != 0 {
+			resp.Body.Close()
+			return errors.New("server does not support range requests")
+		}
+	default:
+		resp.Body.Close()
+		rf.dlink = ""
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	rf.body = resp.Body
+	rf.bodyOffset = offset
+	return nil
+}
+
+func (rf *readFile) closeBody() {
+	if rf.body != nil {
+		rf.body.Close()
+		rf.body = nil
+	}
+}
+
+func (rf *readFile) Read(p []byte) (n int, err error) {
+	if rf.offset >= rf.fd.Size {
+		return 0, io.EOF
+	}
+
+	// 位置改变, 重新发起请求
+	if rf.body == nil || rf.bodyOffset != rf.offset {
+		rf.closeBody()
+		err = rf.openBody(rf.offset)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = rf.body.Read(p)
+	rf.offset += int64(n)
+	rf.bodyOffset += int64(n)
+	if err == io.EOF && rf.offset < rf.fd.Size {
+		// 连接提前结束, 下次读取时重新请求
+		rf.closeBody()
+		err = nil
+	}
+	return n, err
+}
+
+func (rf *readFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += rf.offset
+	case io.SeekEnd:
+		offset += rf.fd.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	rf.offset = offset
+	return offset, nil
+}
+
+func (rf *readFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errNotSupported
+}
+
+func (rf *readFile) Stat() (os.FileInfo, error) {
+	return &fileInfo{fd: rf.fd}, nil
+}
+
+func (rf *readFile) Write(p []byte) (int, error) {
+	return 0, errNotSupported
+}
+
+func (rf *readFile) Close() error {
+	rf.closeBody()
+	return nil
+}
+
+func (df *dirFile) Read(p []byte) (int, error) {
+	return 0, errNotSupported
+}
+
+func (df *dirFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, errNotSupported
+}
+
+// Readdir 列出目录, 与 os.File 的 Readdir 行为一致
+func (df *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	fdl, err := df.fs.list(df.fd.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if df.pos >= len(fdl) {
+		if count > 0 {
+			return nil, io.EOF
+		}
+		return []os.FileInfo{}, nil
+	}
+
+	end := len(fdl)
+	if count > 0 && df.pos+count < end {
+		end = df.pos + count
+	}
+	infos := make([]os.FileInfo, 0, end-df.pos)
+	for _, fd := range fdl[df.pos:end] {
+		infos = append(infos, &fileInfo{fd: fd})
+	}
+	df.pos = end
+	return infos, nil
+}
+
+func (df *dirFile) Stat() (os.FileInfo, error) {
+	return &fileInfo{fd: df.fd}, nil
+}
+
+func (df *dirFile) Write(p []byte) (int, error) {
+	return 0, errNotSupported
+}
+
+func (df *dirFile) Close() error {
+	return nil
+}
+
+func (wf *writeFile) Read(p []byte) (int, error) {
+	return wf.tmp.Read(p)
+}
+
+func (wf *writeFile) Seek(offset int64, whence int) (int64, error) {
+	return wf.tmp.Seek(offset, whence)
+}
+
+func (wf *writeFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errNotSupported
+}
+
+func (wf *writeFile) Stat() (os.FileInfo, error) {
+	info, err := wf.tmp.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{
+		fd: &baidupcs.FileDirectory{
+			Path:     wf.path,
+			Filename: path.Base(wf.path),
+			Size:     info.Size(),
+			Mtime:    info.ModTime().Unix(),
+		},
+	}, nil
+}
+
+func (wf *writeFile) Write(p []byte) (n int, err error) {
+	n, err = wf.tmp.Write(p)
+	if err != nil {
+		wf.err = err
+	}
+	return
+}
+
+// Close 上传临时文件, 上传结束后删除. 写入失败或请求体不完整时不上传
+func (wf *writeFile) Close() error {
+	defer os.Remove(wf.tmp.Name())
+	err := wf.tmp.Close()
+	if err != nil {
+		return err
+	}
+	if wf.err != nil {
+		return wf.err
+	}
+	if wf.body != nil {
+		err = wf.body.complete()
+		if err != nil {
+			return err
+		}
+	}
+	return wf.fs.upload(wf.tmp.Name(), wf.path)
+}
@@ -0,0 +1,205 @@
+package pcswebdav
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+const (
+	// uploadBodyKey 请求 context 中保存 PUT 请求体的键
+	uploadBodyKey contextKey = "uploadBody"
+)
+
+type (
+	// Handler WebDAV 服务, COPY 直接调用网盘的拷贝接口, 不经过本地中转
+	Handler struct {
+		FileSystem *FileSystem
+		Username   string // 为空时不验证
+		Password   string
+
+		webdav *webdav.Handler
+	}
+
+	contextKey string
+
+	// uploadBody 记录 PUT 请求体的读取情况, 未完整读取时不上传
+	uploadBody struct {
+		io.ReadCloser
+		expected int64 // Content-Length, -1 为未知
+		n        int64
+		eof      bool
+		err      error
+	}
+)
+
+func (ub *uploadBody) Read(p []byte) (n int, err error) {
+	n, err = ub.ReadCloser.Read(p)
+	ub.n += int64(n)
+	switch err {
+	case nil:
+	case io.EOF:
+		ub.eof = true
+	default:
+		ub.err = err
+	}
+	return
+}
+
+// complete 请求体是否已完整读取
+func (ub *uploadBody) complete() error {
+	if ub.err != nil {
+		return ub.err
+	}
+	if !ub.eof || (ub.expected >= 0 && ub.n != ub.expected) {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// uploadBodyFromContext 获取 PUT 请求体, 不是 PUT 请求时返回 nil
+func uploadBodyFromContext(ctx context.Context) *uploadBody {
+	if ctx == nil {
+		return nil
+	}
+	ub, _ := ctx.Value(uploadBodyKey).(*uploadBody)
+	return ub
+}
+
+// NewHandler 初始化 Handler
+func NewHandler(fs *FileSystem, username, password string) *Handler {
+	return &Handler{
+		FileSystem: fs,
+		Username:   username,
+		Password:   password,
+		webdav: &webdav.Handler{
+			FileSystem: fs,
+			LockSystem: webdav.NewMemLS(),
+			Logger: func(r *http.Request, err error) {
+				if err != nil {
+					pcsWebDAVVerbose.Warnf("%s %s: %s\n", r.Method, r.URL.Path, err)
+					return
+				}
+				pcsWebDAVVerbose.Infof("%s %s\n", r.Method, r.URL.Path)
+			},
+		},
+	}
+}
+
+// checkAuth 检查 Basic 认证
+func (h *Handler) checkAuth(r *http.Request) bool {
+	if h.Username == "" {
+		return true
+	}
+	username, password, ok := r.BasicAuth()
+	return ok && subtle.ConstantTimeCompare([]byte(username), []byte(h.Username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(h.Password)) == 1
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAuth(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="BaiduPCS-Go"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		ub := &uploadBody{
+			ReadCloser: r.Body,
+			expected:   r.ContentLength,
+		}
+		r.Body = ub
+		r = r.WithContext(context.WithValue(r.Context(), uploadBodyKey, ub))
+	}
+
+	// 需要检查锁或不是递归拷贝时, 交给 webdav.Handler 处理
+	if r.Method == "COPY" && r.Header.Get("If") == "" && isInfiniteDepth(r.Header.Get("Depth")) {
+		status, err := h.handleCopy(r)
+		if err != nil {
+			pcsWebDAVVerbose.Warnf("%s %s: %s\n", r.Method, r.URL.Path, err)
+		}
+		w.WriteHeader(status)
+		return
+	}
+
+	h.webdav.ServeHTTP(w, r)
+}
+
+// isInfiniteDepth Depth 头是否为递归
+func isInfiniteDepth(depth string) bool {
+	return depth == "" || depth == "infinity"
+}
+
+// handleCopy 处理没有 If 头的递归 COPY 请求
+func (h *Handler) handleCopy(r *http.Request) (status int, err error) {
+	dest, err := url.Parse(r.Header.Get("Destination"))
+	if err != nil || dest.Path == "" {
+		return http.StatusBadRequest, err
+	}
+	if dest.Host != "" && dest.Host != r.Host {
+		return http.StatusBadGateway, nil
+	}
+
+	var (
+		ctx       = r.Context()
+		src       = path.Clean("/" + r.URL.Path)
+		dst       = path.Clean("/" + dest.Path)
+		overwrite = r.Header.Get("Overwrite") != "F"
+	)
+	if src == dst {
+		return http.StatusForbidden, nil
+	}
+
+	// 与 webdav.Handler 一致, 创建临时锁, 确认目标没有被其他客户端锁定
+	ls := h.webdav.LockSystem
+	token, err := ls.Create(time.Now(), webdav.LockDetails{
+		Root:      dst,
+		Duration:  -1,
+		ZeroDepth: true,
+	})
+	if err != nil {
+		if err == webdav.ErrLocked {
+			return http.StatusLocked, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	defer ls.Unlock(time.Now(), token)
+
+	if _, err = h.FileSystem.Stat(ctx, src); err != nil {
+		if os.IsNotExist(err) {
+			return http.StatusNotFound, err
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	created := true
+	if _, err = h.FileSystem.Stat(ctx, dst); err == nil {
+		if !overwrite {
+			return http.StatusPreconditionFailed, nil
+		}
+		created = false
+		err = h.FileSystem.RemoveAll(ctx, dst)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	err = h.FileSystem.Copy(ctx, src, dst)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return http.StatusConflict, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	if created {
+		return http.StatusCreated, nil
+	}
+	return http.StatusNoContent, nil
+}
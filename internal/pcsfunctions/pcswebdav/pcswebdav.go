@@ -0,0 +1,303 @@
+// Package pcswebdav 通过 WebDAV 访问网盘
+package pcswebdav
+
+import (
+	"BaiduPCS-Go/baidupcs"
+	"BaiduPCS-Go/baidupcs/expires"
+	"BaiduPCS-Go/baidupcs/expires/cachemap"
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsupload"
+	"BaiduPCS-Go/pcsutil/checksum"
+	"BaiduPCS-Go/pcsutil/taskframework"
+	"BaiduPCS-Go/pcsverbose"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+const (
+	// DefaultCacheExpires 默认目录列表缓存时间
+	DefaultCacheExpires = 30 * time.Second
+
+	opList = "webdav_list"
+)
+
+var (
+	pcsWebDAVVerbose = pcsverbose.New("PCSWEBDAV")
+
+	// ErrUploadFailed 上传失败
+	ErrUploadFailed = errors.New("upload failed")
+)
+
+type (
+	// FileSystem 实现 webdav.FileSystem, 将网盘目录 Root 作为 WebDAV 的根目录
+	FileSystem struct {
+		PCS               *baidupcs.BaiduPCS
+		Root              string
+		CacheExpires      time.Duration // 目录列表缓存时间
+		UploadingDatabase *pcsupload.UploadingDatabase
+		UploadParallel    int
+		UploadMaxRetry    int
+
+		cacheOpMap cachemap.CacheOpMap
+	}
+
+	// fileInfo 实现 os.FileInfo
+	fileInfo struct {
+		fd *baidupcs.FileDirectory
+	}
+)
+
+// NewFileSystem 初始化 FileSystem
+func NewFileSystem(pcs *baidupcs.BaiduPCS, root string, uploadingDatabase *pcsupload.UploadingDatabase) *FileSystem {
+	return &FileSystem{
+		PCS:               pcs,
+		Root:              path.Clean("/" + root),
+		CacheExpires:      DefaultCacheExpires,
+		UploadingDatabase: uploadingDatabase,
+		UploadParallel:    1,
+	}
+}
+
+// pcsPath WebDAV 路径转换为网盘路径
+func (fs *FileSystem) pcsPath(name string) string {
+	return path.Join(fs.Root, name)
+}
+
+// list 获取目录列表, 结果会被缓存
+func (fs *FileSystem) list(dir string) (baidupcs.FileDirectoryList, error) {
+	data, err := fs.cacheOpMap.CacheOperationWithError(opList, dir, func() (expires.DataExpires, error) {
+		fdl, pcsError := fs.PCS.FilesDirectoriesList(dir, baidupcs.DefaultOrderOptions)
+		if pcsError != nil {
+			return nil, convertError(pcsError)
+		}
+		return expires.NewDataExpires(fdl, fs.CacheExpires), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, os.ErrNotExist
+	}
+	return data.Data().(baidupcs.FileDirectoryList), nil
+}
+
+// expire 删除 p 所在目录, p 和 p 的子目录的列表缓存
+func (fs *FileSystem) expire(paths ...string) {
+	cache := fs.cacheOpMap.LazyInitCachePoolOp(opList)
+	for _, p := range paths {
+		cache.Delete(path.Dir(p))
+		cache.Range(func(key interface{}, _ expires.DataExpires) bool {
+			k := key.(string)
+			if k == p || strings.HasPrefix(k, p+"/") {
+				cache.Delete(k)
+			}
+			return true
+		})
+	}
+}
+
+// stat 获取网盘路径 p 的信息
+func (fs *FileSystem) stat(p string) (*baidupcs.FileDirectory, error) {
+	if p == "/" {
+		return &baidupcs.FileDirectory{
+			Path:  "/",
+			Isdir: true,
+		}, nil
+	}
+
+	fdl, err := fs.list(path.Dir(p))
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(p)
+	for _, fd := range fdl {
+		if fd.Filename == name {
+			return fd, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// Mkdir 创建目录
+func (fs *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	p := fs.pcsPath(name)
+	pcsError := fs.PCS.Mkdir(p)
+	fs.expire(p)
+	if pcsError != nil {
+		return convertError(pcsError)
+	}
+	return nil
+}
+
+// OpenFile 打开文件, 只读时从网盘下载, 写入时先缓存到本地临时文件, 关闭时上传
+func (fs *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	p := fs.pcsPath(name)
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		tmp, err := ioutil.TempFile("", "BaiduPCS-Go-webdav-")
+		if err != nil {
+			return nil, err
+		}
+		return &writeFile{
+			fs:   fs,
+			path: p,
+			tmp:  tmp,
+			body: uploadBodyFromContext(ctx),
+		}, nil
+	}
+
+	fd, err := fs.stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if fd.Isdir {
+		return &dirFile{
+			fs: fs,
+			fd: fd,
+		}, nil
+	}
+	return &readFile{
+		fs: fs,
+		fd: fd,
+	}, nil
+}
+
+// RemoveAll 删除文件或目录
+func (fs *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	p := fs.pcsPath(name)
+	if p == fs.Root {
+		return os.ErrPermission
+	}
+	pcsError := fs.PCS.Remove(p)
+	fs.expire(p)
+	if pcsError != nil {
+		return convertError(pcsError)
+	}
+	return nil
+}
+
+// Rename 移动文件或目录
+func (fs *FileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	from, to := fs.pcsPath(oldName), fs.pcsPath(newName)
+	if from == fs.Root {
+		return os.ErrPermission
+	}
+	pcsError := fs.PCS.Move(&baidupcs.CpMvJSON{
+		From: from,
+		To:   to,
+	})
+	fs.expire(from, to)
+	if pcsError != nil {
+		return convertError(pcsError)
+	}
+	return nil
+}
+
+// Copy 拷贝文件或目录
+func (fs *FileSystem) Copy(ctx context.Context, oldName, newName string) error {
+	from, to := fs.pcsPath(oldName), fs.pcsPath(newName)
+	pcsError := fs.PCS.Copy(&baidupcs.CpMvJSON{
+		From: from,
+		To:   to,
+	})
+	fs.expire(to)
+	if pcsError != nil {
+		return convertError(pcsError)
+	}
+	return nil
+}
+
+// Stat 获取文件或目录信息
+func (fs *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	fd, err := fs.stat(fs.pcsPath(name))
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{fd: fd}, nil
+}
+
+// upload 上传本地文件, 覆盖网盘中的同名文件
+func (fs *FileSystem) upload(localPath, savePath string) error {
+	executor := &taskframework.TaskExecutor{
+		IsFailedDeque: true,
+	}
+	executor.Append(&pcsupload.UploadTaskUnit{
+		LocalFileChecksum: checksum.NewLocalFileChecksum(localPath, int(baidupcs.SliceMD5Size)),
+		SavePath:          savePath,
+		PCS:               fs.PCS,
+		UploadingDatabase: fs.UploadingDatabase,
+		Parallel:          fs.UploadParallel,
+		PrintFormat:       pcsupload.DefaultPrintFormat,
+		UploadStatistic:   &pcsupload.UploadStatistic{},
+		Policy:            baidupcs.OverWritePolicy,
+	}, fs.UploadMaxRetry)
+	executor.Execute()
+	fs.expire(savePath)
+
+	failedList := executor.FailedDeque()
+	if failedList != nil && failedList.Size() != 0 {
+		return ErrUploadFailed
+	}
+	return nil
+}
+
+// convertError 转换网盘错误, 使 webdav 能够返回正确的状态码
+func convertError(pcsError pcserror.Error) error {
+	if pcsError.GetErrType() == pcserror.ErrTypeRemoteError {
+		switch pcsError.GetRemoteErrCode() {
+		case 31066, 31297, -9:
+			// file does not exist
+			return os.ErrNotExist
+		case 31061, -8:
+			// file already exists
+			return os.ErrExist
+		}
+	}
+	return pcsError
+}
+
+func (fi *fileInfo) Name() string {
+	if fi.fd.Path == "/" {
+		return "/"
+	}
+	return fi.fd.Filename
+}
+
+func (fi *fileInfo) Size() int64 {
+	return fi.fd.Size
+}
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.fd.Isdir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fi *fileInfo) ModTime() time.Time {
+	return time.Unix(fi.fd.Mtime, 0)
+}
+
+func (fi *fileInfo) IsDir() bool {
+	return fi.fd.Isdir
+}
+
+func (fi *fileInfo) Sys() interface{} {
+	return fi.fd
+}
+
+// ContentType 根据扩展名判断, 避免为了检测类型而下载文件
+func (fi *fileInfo) ContentType(ctx context.Context) (string, error) {
+	ctype := mime.TypeByExtension(path.Ext(fi.fd.Filename))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	return ctype, nil
+}
@@ -0,0 +1,83 @@
+package pcswebdav_test
+
+import (
+	"BaiduPCS-Go/internal/pcsfunctions/pcswebdav"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type brokenReader struct {
+	data string
+}
+
+func (br *brokenReader) Read(p []byte) (int, error) {
+	if br.data == "" {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, br.data)
+	br.data = br.data[n:]
+	return n, nil
+}
+
+func newTestHandler() *pcswebdav.Handler {
+	// PCS 为 nil, 任何上传或网盘请求都会 panic
+	return pcswebdav.NewHandler(pcswebdav.NewFileSystem(nil, "/", nil), "user", "pass")
+}
+
+func TestAuth(t *testing.T) {
+	h := newTestHandler()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PROPFIND", "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, expected %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestPutIncompleteBody(t *testing.T) {
+	h := newTestHandler()
+
+	// 读取请求体出错
+	r := httptest.NewRequest(http.MethodPut, "/a.txt", &brokenReader{data: "hello"})
+	r.SetBasicAuth("user", "pass")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code < 400 {
+		t.Fatalf("status %d, expected error", w.Code)
+	}
+
+	// 请求体比 Content-Length 短
+	r = httptest.NewRequest(http.MethodPut, "/b.txt", strings.NewReader("hello"))
+	r.ContentLength = 100
+	r.SetBasicAuth("user", "pass")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code < 400 {
+		t.Fatalf("status %d, expected error", w.Code)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	h := newTestHandler()
+
+	r := httptest.NewRequest("COPY", "/a", nil)
+	r.Header.Set("Destination", "/a/")
+	r.SetBasicAuth("user", "pass")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status %d, expected %d", w.Code, http.StatusForbidden)
+	}
+
+	r = httptest.NewRequest("COPY", "/a", nil)
+	r.Header.Set("Destination", "http://example.com/b")
+	r.Header.Set("Depth", "1")
+	r.SetBasicAuth("user", "pass")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, expected %d", w.Code, http.StatusBadRequest)
+	}
+}
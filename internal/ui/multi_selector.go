@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrSelectCanceled 用户取消选择
+	ErrSelectCanceled = errors.New("用户取消操作")
+)
+
+// SelectFiles 显示文件列表并获取用户选择的多个文件, action 为提示中的操作名称, 例如 "转存"
+func (fs *FileSelector) SelectFiles(action string) ([]*FileSearchItem, error) {
+	if len(fs.files) == 0 {
+		return nil, fmt.Errorf("没有找到匹配的文件")
+	}
+
+	fmt.Printf("\n🔍 共 %d 个文件/目录:\n", len(fs.files))
+	fmt.Println(strings.Repeat("=", 80))
+	for i, file := range fs.files {
+		if file.IsDir != 0 {
+			fmt.Printf("  %d. 📁 %s/\n", i+1, file.Path)
+			continue
+		}
+		fmt.Printf("  %d. 📄 %s  (%s)\n", i+1, file.Path, FormatFileSize(file.Size))
+	}
+	fmt.Println(strings.Repeat("-", 80))
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("\n请选择要%s的文件 (例如 1,3,5-7, 输入 a 全选, 输入 0 取消): ", action)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("读取输入失败: %v", err)
+		}
+
+		indexes, err := ParseSelection(input, len(fs.files))
+		if err == ErrSelectCanceled {
+			return nil, err
+		}
+		if err != nil {
+			fmt.Printf("❌ %s\n", err)
+			continue
+		}
+
+		selected := make([]*FileSearchItem, 0, len(indexes))
+		for _, i := range indexes {
+			selected = append(selected, &fs.files[i])
+		}
+		fmt.Printf("✅ 已选择 %d 个文件/目录\n", len(selected))
+		return selected, nil
+	}
+}
+
+// ParseSelection 解析用户输入的序号, 序号从 1 开始, 支持逗号或空格分隔和范围 (如 5-7), a 表示全选.
+// 返回从 0 开始的下标, 按输入顺序去重
+func ParseSelection(input string, n int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	switch strings.ToLower(input) {
+	case "0":
+		return nil, ErrSelectCanceled
+	case "a", "all":
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	var (
+		indexes []int
+		seen    = map[int]bool{}
+	)
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' '
+	})
+	for _, field := range fields {
+		begin, end, err := parseSelectionRange(field)
+		if err != nil {
+			return nil, err
+		}
+		if begin < 1 || end > n || begin > end {
+			return nil, fmt.Errorf("请输入 1-%d 之间的数字: %s", n, field)
+		}
+		for i := begin; i <= end; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i-1)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("请输入有效的数字")
+	}
+	return indexes, nil
+}
+
+func parseSelectionRange(field string) (begin, end int, err error) {
+	parts := strings.SplitN(field, "-", 2)
+	begin, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("请输入有效的数字: %s", field)
+	}
+	if len(parts) == 1 {
+		return begin, begin, nil
+	}
+	end, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("请输入有效的数字: %s", field)
+	}
+	return begin, end, nil
+}
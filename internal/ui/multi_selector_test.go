@@ -0,0 +1,38 @@
+package ui_test
+
+import (
+	"BaiduPCS-Go/internal/ui"
+	"reflect"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []int
+	}{
+		{"1", []int{0}},
+		{" 1,3 ", []int{0, 2}},
+		{"2-4 1", []int{1, 2, 3, 0}},
+		{"3，3,2-3", []int{2, 1}},
+		{"a", []int{0, 1, 2, 3, 4}},
+	}
+	for _, c := range cases {
+		got, err := ui.ParseSelection(c.input, 5)
+		if err != nil {
+			t.Fatalf("ParseSelection(%q): %s", c.input, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("ParseSelection(%q) = %v, want %v", c.input, got, c.want)
+		}
+	}
+
+	if _, err := ui.ParseSelection("0", 5); err != ui.ErrSelectCanceled {
+		t.Fatalf("ParseSelection(0) err = %v, want ErrSelectCanceled", err)
+	}
+	for _, input := range []string{"", "6", "x", "4-2", "1-"} {
+		if _, err := ui.ParseSelection(input, 5); err == nil {
+			t.Fatalf("ParseSelection(%q) expected error", input)
+		}
+	}
+}
@@ -11,16 +11,21 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"BaiduPCS-Go/baidupcs"
 	"BaiduPCS-Go/internal/pcscommand"
 	"BaiduPCS-Go/internal/pcsconfig"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsdedupe"
 	"BaiduPCS-Go/internal/pcsfunctions/pcsdownload"
+	"BaiduPCS-Go/internal/pcsfunctions/pcsshare"
+	"BaiduPCS-Go/internal/pcsfunctions/pcswebdav"
 	_ "BaiduPCS-Go/internal/pcsinit"
 	"BaiduPCS-Go/internal/pcsupdate"
 	"BaiduPCS-Go/pcsliner"
 	"BaiduPCS-Go/pcsliner/args"
+	"BaiduPCS-Go/pcsoutput"
 	"BaiduPCS-Go/pcstable"
 	"BaiduPCS-Go/pcsutil"
 	"BaiduPCS-Go/pcsutil/checksum"
@@ -73,6 +78,70 @@ var (
 		}
 		return nil
 	}
+	recycleMatchOptionsFn = func(c *cli.Context) (opt *pcscommand.RecycleMatchOptions, err error) {
+		opt = &pcscommand.RecycleMatchOptions{
+			Yes: c.Bool("y"),
+		}
+		now := time.Now()
+		for _, t := range []struct {
+			name string
+			ptr  *time.Time
+		}{{"deleted-after", &opt.DeletedAfter}, {"deleted-before", &opt.DeletedBefore}} {
+			if c.String(t.name) == "" {
+				continue
+			}
+			*t.ptr, err = pcstime.ParseTimeOption(c.String(t.name), now)
+			if err != nil {
+				return nil, fmt.Errorf("解析 --%s 失败: %s", t.name, err)
+			}
+		}
+		return opt, nil
+	}
+	recycleMatchFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "deleted-after",
+			Usage: "删除时间不早于, 如 7d, 12h, 2023-01-02",
+		},
+		cli.StringFlag{
+			Name:  "deleted-before",
+			Usage: "删除时间早于, 如 7d, 12h, 2023-01-02",
+		},
+		cli.BoolFlag{
+			Name:  "y",
+			Usage: "按路径匹配时不需要确认",
+		},
+	}
+
+	// headTailOptionsFn 解析 head 和 tail 的 -n, -c, -z 参数
+	headTailOptionsFn = func(c *cli.Context) (*pcscommand.HeadTailOptions, error) {
+		opt := &pcscommand.HeadTailOptions{
+			Lines:  c.Int("n"),
+			Gunzip: c.Bool("z"),
+		}
+		if c.IsSet("c") {
+			size, err := converter.ParseFileSizeStr(c.String("c"))
+			if err != nil {
+				return nil, fmt.Errorf("解析 -c 失败: %s", err)
+			}
+			opt.Bytes = size
+		}
+		return opt, nil
+	}
+	headTailFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  "n",
+			Usage: "输出的行数",
+			Value: pcscommand.DefaultHeadTailLines,
+		},
+		cli.StringFlag{
+			Name:  "c",
+			Usage: "输出的大小, 如 100, 1KB, 2MB, 指定时忽略 -n",
+		},
+		cli.BoolFlag{
+			Name:  "z",
+			Usage: "解压 gzip 文件, 行数和大小按解压后的数据计算",
+		},
+	}
 
 	isCli bool
 )
@@ -123,6 +192,20 @@ func main() {
 			EnvVar:      pcsverbose.EnvVerbose,
 			Destination: &pcsverbose.IsVerbose,
 		},
+		cli.StringFlag{
+			Name:        "format",
+			Usage:       "列表类命令的输出格式, 可选: table, json, ndjson, csv",
+			Value:       pcsoutput.Format,
+			EnvVar:      pcsoutput.EnvFormat,
+			Destination: &pcsoutput.Format,
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		err := pcsoutput.CheckFormat(pcsoutput.Format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--format: %s\n", err)
+		}
+		return err
 	}
 	app.Action = func(c *cli.Context) {
 		if c.NArg() != 0 {
@@ -155,7 +238,7 @@ func main() {
 				lineArgs                   = args.Parse(line)
 				numArgs                    = len(lineArgs)
 				acceptCompleteFileCommands = []string{
-					"cd", "cp", "download", "export", "locate", "ls", "meta", "mkdir", "mv", "rm", "setastoken", "share", "transfer", "tree", "upload",
+					"cat", "cd", "cp", "download", "export", "head", "locate", "ls", "meta", "mkdir", "mv", "rm", "setastoken", "share", "tail", "transfer", "tree", "upload",
 				}
 				closed = strings.LastIndex(line, " ") == len(line)-1
 			)
@@ -190,7 +273,6 @@ func main() {
 
 			var (
 				activeUser  = pcsconfig.Config.ActiveUser()
-				pcs         = pcsconfig.Config.ActiveUserBaiduPCS()
 				runeFunc    = unicode.IsSpace
 				pcsRuneFunc = func(r rune) bool {
 					switch r {
@@ -232,7 +314,7 @@ func main() {
 					targetDir = path.Dir(targetDir)
 				}
 			}
-			files, err := pcs.CacheFilesDirectoriesList(targetDir, baidupcs.DefaultOrderOptions)
+			files, err := pcscommand.CacheFilesDirectoriesList(targetDir)
 			if err != nil {
 				return
 			}
@@ -730,6 +812,12 @@ func main() {
 
 	使用通配符
 	BaiduPCS-Go ls /我的*
+
+	显示加密上传的文件解密后的文件名和大小
+	BaiduPCS-Go ls -decrypt /备份
+
+	从离线索引列出, 不访问网络
+	BaiduPCS-Go ls --offline /我的资源
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
@@ -756,7 +844,9 @@ func main() {
 				}
 
 				pcscommand.RunLs(c.Args().Get(0), &pcscommand.LsOptions{
-					Total: c.Bool("l") || c.Parent().Args().Get(0) == "ll",
+					Total:   c.Bool("l") || c.Parent().Args().Get(0) == "ll",
+					Decrypt: c.Bool("decrypt"),
+					Offline: c.Bool("offline"),
 				}, orderOptions)
 
 				return nil
@@ -786,6 +876,14 @@ func main() {
 					Name:  "size",
 					Usage: "根据大小排序",
 				},
+				cli.BoolFlag{
+					Name:  "decrypt",
+					Usage: "显示解密后的文件名, 需要设置加密口令",
+				},
+				cli.BoolFlag{
+					Name:  "offline, cached",
+					Usage: "从离线索引列出, 需要先运行 index update",
+				},
 			},
 		},
 		{
@@ -807,6 +905,9 @@ func main() {
 
 	递归搜索当前工作目录的文件
 	BaiduPCS-Go search -r 关键字
+
+	从离线索引递归搜索整个网盘
+	BaiduPCS-Go search --offline -r -path=/ 关键字
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
@@ -819,6 +920,7 @@ func main() {
 				pcscommand.RunSearch(c.String("path"), c.Args().Get(0), &pcscommand.SearchOptions{
 					Total:   c.Bool("l"),
 					Recurse: c.Bool("r"),
+					Offline: c.Bool("offline"),
 				})
 
 				return nil
@@ -837,6 +939,80 @@ func main() {
 					Usage: "需要检索的目录",
 					Value: ".",
 				},
+				cli.BoolFlag{
+					Name:  "offline, cached",
+					Usage: "从离线索引搜索, 需要先运行 index update",
+				},
+			},
+		},
+		{
+			Name:      "find",
+			Usage:     "按条件递归查找文件/目录",
+			UsageText: app.Name + " find [-y] [--offline] [目录1] [目录2] ... [表达式]",
+			Description: `
+	递归遍历网盘目录, 输出满足表达式的文件和目录, 用法与 find 命令相似.
+	默认在当前工作目录查找, 没有指定动作时默认为 -print.
+	使用 --offline (或 --cached) 时从离线索引查找, 需要先运行 index update.
+
+	条件:
+		-name <通配符>       文件名匹配通配符, -iname 忽略大小写
+		-path <通配符>       完整路径匹配通配符, -ipath 忽略大小写
+		-regex <正则>        完整路径匹配正则表达式, -iregex 忽略大小写
+		-type f|d            文件或目录
+		-size [+-]N[ckMGT]   文件大小大于(+), 小于(-)或等于N, 单位默认为字节, 以单位向上取整后比较
+		-mtime [+-]N         修改时间距离现在大于(+), 小于(-)或等于N天, -mmin 单位为分钟
+		-md5 <md5>           文件的md5值
+		-maxdepth N          最多遍历到第N层, 起始目录为第0层, -mindepth 最少从第N层开始匹配
+	组合:
+		( 表达式 )           分组
+		! 或 -not            非
+		-a 或 -and           与, 可以省略
+		-o 或 -or            或
+	动作:
+		-print               输出路径
+		-print0              输出路径, 以 \0 分隔
+		-json                以 JSON 格式输出文件信息, 一行一个
+		-delete              删除, 遍历结束后列出并确认, 使用 -y 时不确认
+
+	示例:
+
+	查找 /我的资源 中大于 1GB 的 mkv 文件
+	BaiduPCS-Go find /我的资源 -iname "*.mkv" -size +1G
+
+	查找最近7天修改过的 log 或 txt 文件
+	BaiduPCS-Go find /logs -type f ( -name "*.log" -o -name "*.txt" ) -mtime -7
+
+	以 JSON 格式输出当前工作目录第一层的目录
+	BaiduPCS-Go find -maxdepth 1 -type d -json
+
+	删除 /备份 中30天前的 tmp 文件
+	BaiduPCS-Go find /备份 -name "*.tmp" -mtime +30 -delete
+`,
+			Category:        "百度网盘",
+			Before:          reloadFn,
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				args := []string(c.Args())
+				if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				opt := &pcscommand.FindOptions{}
+			options:
+				for len(args) > 0 {
+					switch args[0] {
+					case "-y":
+						opt.Yes = true
+					case "--offline", "--cached":
+						opt.Offline = true
+					default:
+						break options
+					}
+					args = args[1:]
+				}
+				pcscommand.RunFind(args, opt)
+				return nil
 			},
 		},
 		{
@@ -858,6 +1034,9 @@ func main() {
 
 	同时显示文件名和fsid
 	BaiduPCS-Go tree --fsid
+
+	从离线索引列出, 不访问网络
+	BaiduPCS-Go tree --offline /
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
@@ -865,6 +1044,7 @@ func main() {
 				pcscommand.RunTree(c.Args().Get(0), 0, &pcscommand.TreeOptions{
 					Depth:    c.Int("depth"),
 					ShowFsid: c.Bool("fsid"),
+					Offline:  c.Bool("offline"),
 				})
 				return nil
 			},
@@ -878,6 +1058,10 @@ func main() {
 					Name:  "fsid",
 					Usage: "带fsid显示",
 				},
+				cli.BoolFlag{
+					Name:  "offline, cached",
+					Usage: "从离线索引列出, 需要先运行 index update",
+				},
 			},
 		},
 		{
@@ -892,206 +1076,788 @@ func main() {
 			},
 		},
 		{
-			Name:        "meta",
-			Usage:       "获取文件/目录的元信息",
-			UsageText:   app.Name + " meta <文件/目录1> <文件/目录2> <文件/目录3> ...",
-			Description: "默认获取工作目录元信息",
-			Category:    "百度网盘",
-			Before:      reloadFn,
-			Action: func(c *cli.Context) error {
-				var (
-					ca = c.Args()
-					as []string
-				)
-				if len(ca) == 0 {
-					as = []string{""}
-				} else {
-					as = ca
-				}
-
-				pcscommand.RunGetMeta(as...)
-				return nil
-			},
-		},
-		{
-			Name:      "rm",
-			Usage:     "删除文件/目录",
-			UsageText: app.Name + " rm <文件/目录的路径1> <文件/目录2> <文件/目录3> ...",
+			Name:      "du",
+			Usage:     "统计目录占用的空间",
+			UsageText: app.Name + " du [-d depth] [-h] [--sort size] <目录>",
 			Description: `
-	注意: 删除多个文件和目录时, 请确保每一个文件和目录都存在, 否则删除操作会失败.
-	被删除的文件或目录可在网盘文件回收站找回.
+	递归统计目录下各个子目录的大小, 文件数和目录数, 默认按大小从大到小排序.
+	默认在当前工作目录统计. 使用 "help du" 查看帮助.
 
 	示例:
 
-	删除 /我的资源/1.mp4
-	BaiduPCS-Go rm /我的资源/1.mp4
-
-	删除 /我的资源/1.mp4 和 /我的资源/2.mp4
-	BaiduPCS-Go rm /我的资源/1.mp4 /我的资源/2.mp4
+	以易读的单位统计 / 下各个目录的大小
+	BaiduPCS-Go du -h /
 
-	删除 /我的资源 内的所有文件和目录, 但不删除该目录
-	BaiduPCS-Go rm /我的资源/*
+	统计 /我的资源 下两层的目录和文件, 并按扩展名统计
+	BaiduPCS-Go du -d 2 -a -h --ext /我的资源
 
-	删除 /我的资源 整个目录 !!
-	BaiduPCS-Go rm /我的资源
+	以 JSON 格式输出
+	BaiduPCS-Go du --json /
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
+			HideHelp: true,
 			Action: func(c *cli.Context) error {
-				if c.NArg() == 0 {
-					cli.ShowCommandHelp(c, c.Command.Name)
-					return nil
+				pcspath := "."
+				if c.NArg() > 0 {
+					pcspath = c.Args().Get(0)
 				}
 
-				pcscommand.RunRemove(c.Args()...)
-				return nil
-			},
-		},
-		{
-			Name:      "mkdir",
-			Usage:     "创建目录",
-			UsageText: app.Name + " mkdir <目录>",
-			Category:  "百度网盘",
-			Before:    reloadFn,
-			Action: func(c *cli.Context) error {
-				if c.NArg() == 0 {
-					cli.ShowCommandHelp(c, c.Command.Name)
+				switch c.String("sort") {
+				case "size", "name", "count":
+				default:
+					fmt.Println("排序方式只支持 size, name, count")
 					return nil
 				}
 
-				pcscommand.RunMkdir(c.Args().Get(0))
+				pcscommand.RunDu(pcspath, &pcscommand.DuOptions{
+					Depth:    c.Int("d"),
+					Human:    c.Bool("h"),
+					All:      c.Bool("a"),
+					Sort:     c.String("sort"),
+					Ext:      c.Bool("ext"),
+					JSON:     c.Bool("json"),
+					Parallel: c.Int("p"),
+				})
 				return nil
 			},
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "d",
+					Usage: "输出的最大深度, 为0时只输出总计",
+					Value: 1,
+				},
+				cli.BoolFlag{
+					Name:  "h",
+					Usage: "以易读的单位输出大小",
+				},
+				cli.BoolFlag{
+					Name:  "a",
+					Usage: "同时输出文件",
+				},
+				cli.StringFlag{
+					Name:  "sort",
+					Usage: "排序方式, 可选值: size, name, count",
+					Value: "size",
+				},
+				cli.BoolFlag{
+					Name:  "ext",
+					Usage: "按扩展名统计文件大小",
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "以 JSON 格式输出",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "获取目录列表的并发数",
+					Value: pcscommand.DefaultDuParallel,
+				},
+			},
 		},
 		{
-			Name:  "cp",
-			Usage: "拷贝文件/目录",
-			UsageText: `BaiduPCS-Go cp <文件/目录> <目标文件/目录>
-	BaiduPCS-Go cp <文件/目录1> <文件/目录2> <文件/目录3> ... <目标目录>`,
+			Name:      "dedupe",
+			Usage:     "查找和删除重复文件",
+			UsageText: app.Name + " dedupe [--keep oldest|newest|shortest-path|interactive] <目录1> <目录2> ...",
 			Description: `
-	注意: 拷贝多个文件和目录时, 请确保每一个文件和目录都存在, 否则拷贝操作会失败.
+	递归获取目录下的全部文件, 按 md5 和文件大小分组, 列出重复的文件和浪费的空间.
+	默认查找整个网盘. 设置了 --keep 时, 按保留策略删除多余的文件,
+	删除的文件可在网盘文件回收站找回.
+
+	保留策略:
+		oldest: 保留最早上传的文件
+		newest: 保留最新上传的文件
+		shortest-path: 保留路径最短的文件
+		interactive: 逐组选择保留的文件
 
 	示例:
 
-	将 /我的资源/1.mp4 复制到 根目录 /
-	BaiduPCS-Go cp /我的资源/1.mp4 /
+	列出整个网盘中的重复文件
+	BaiduPCS-Go dedupe
 
-	将 /我的资源/1.mp4 和 /我的资源/2.mp4 复制到 根目录 /
-	BaiduPCS-Go cp /我的资源/1.mp4 /我的资源/2.mp4 /
+	列出 /我的资源 中大于 10MB 的重复文件
+	BaiduPCS-Go dedupe --min-size 10MB /我的资源
+
+	删除 /我的资源 中的重复文件, 保留最早上传的文件
+	BaiduPCS-Go dedupe --keep oldest /我的资源
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
 			Action: func(c *cli.Context) error {
-				if c.NArg() <= 1 {
-					cli.ShowCommandHelp(c, c.Command.Name)
-					return nil
+				opt := &pcscommand.DedupeOptions{
+					Parallel: c.Int("p"),
+					Yes:      c.Bool("y"),
 				}
 
-				pcscommand.RunCopy(c.Args()...)
+				var err error
+				if c.String("keep") != "" {
+					opt.Keep, err = pcsdedupe.ParseKeepPolicy(c.String("keep"))
+					if err != nil {
+						fmt.Println(err)
+						return nil
+					}
+				}
+				if c.String("min-size") != "" {
+					opt.MinSize, err = converter.ParseFileSizeStr(c.String("min-size"))
+					if err != nil {
+						fmt.Printf("解析 --min-size 失败: %s\n", err)
+						return nil
+					}
+				}
+
+				pcscommand.RunDedupe(c.Args(), opt)
 				return nil
 			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "keep",
+					Usage: "保留策略, 可选值: oldest, newest, shortest-path, interactive, 不设置时只列出重复文件",
+				},
+				cli.StringFlag{
+					Name:  "min-size",
+					Usage: "忽略小于该大小的文件, 如 1MB",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "获取目录列表的并发数",
+					Value: pcscommand.DefaultDuParallel,
+				},
+				cli.BoolFlag{
+					Name:  "y",
+					Usage: "删除时不需要确认",
+				},
+			},
 		},
 		{
-			Name:  "mv",
-			Usage: "移动/重命名文件/目录",
-			UsageText: `移动:
-	BaiduPCS-Go mv <文件/目录1> <文件/目录2> <文件/目录3> ... <目标目录>
-
-	重命名:
-	BaiduPCS-Go mv <文件/目录> <重命名的文件/目录>`,
+			Name:      "changes",
+			Usage:     "获取网盘中的文件变更",
+			UsageText: app.Name + " changes [--ndjson] [-f] [--path <目录>]",
 			Description: `
-	注意: 移动多个文件和目录时, 请确保每一个文件和目录都存在, 否则移动操作会失败.
+	获取上次调用以来网盘中新增, 修改和删除的文件, 获取的位置 (cursor) 按帐号保存在配置中.
+	首次获取时会列出网盘中的全部文件, 可使用 --init 只记录当前状态.
+	以 NDJSON 格式输出时, 每行一个变更, type 为 added, modified, deleted 或 reset,
+	reset 表示 cursor 已失效, 之后输出的是网盘中的全部文件.
 
 	示例:
 
-	将 /我的资源/1.mp4 移动到 根目录 /
-	BaiduPCS-Go mv /我的资源/1.mp4 /
+	记录当前状态, 不输出变更
+	BaiduPCS-Go changes --init
 
-	将 /我的资源/1.mp4 重命名为 /我的资源/3.mp4
-	BaiduPCS-Go mv /我的资源/1.mp4 /我的资源/3.mp4
+	列出上次调用以来的文件变更
+	BaiduPCS-Go changes
+
+	持续获取 /我的资源 中的文件变更, 以 NDJSON 格式输出
+	BaiduPCS-Go changes -f --ndjson --path /我的资源
+
+	清除已记录的 cursor
+	BaiduPCS-Go changes --reset
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
 			Action: func(c *cli.Context) error {
-				if c.NArg() <= 1 {
-					cli.ShowCommandHelp(c, c.Command.Name)
-					return nil
-				}
-
-				pcscommand.RunMove(c.Args()...)
+				pcscommand.RunChanges(&pcscommand.ChangesOptions{
+					NDJSON:   c.Bool("ndjson"),
+					Follow:   c.Bool("f"),
+					Interval: c.Duration("interval"),
+					Init:     c.Bool("init"),
+					Reset:    c.Bool("reset"),
+					Path:     c.String("path"),
+				})
 				return nil
 			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "ndjson",
+					Usage: "以 NDJSON 格式输出, 每行一个变更",
+				},
+				cli.BoolFlag{
+					Name:  "f",
+					Usage: "持续获取文件变更",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "持续获取文件变更的间隔",
+					Value: pcscommand.DefaultChangesInterval,
+				},
+				cli.BoolFlag{
+					Name:  "init",
+					Usage: "只记录当前状态, 不输出变更",
+				},
+				cli.BoolFlag{
+					Name:  "reset",
+					Usage: "清除已记录的 cursor",
+				},
+				cli.StringFlag{
+					Name:  "path",
+					Usage: "只输出该目录下的变更",
+				},
+			},
 		},
 		{
-			Name:      "download",
-			Aliases:   []string{"d"},
-			Usage:     "下载文件/目录",
-			UsageText: app.Name + " download <文件/目录路径1> <文件/目录2> <文件/目录3> ...",
+			Name:      "index",
+			Usage:     "网盘文件的离线索引",
+			UsageText: app.Name + " index <update|status|clear>",
 			Description: `
-	下载的文件默认保存到, 程序所在目录的 download/ 目录.
-	通过 BaiduPCS-Go config set -savedir <savedir>, 自定义保存的目录.
-	支持多个文件或目录下载.
-	支持下载完成后自动校验文件, 但并不是所有的文件都支持校验!
-	自动跳过下载重名的文件!
-
-	下载模式说明:
-		pcs: 通过百度网盘的 PCS API 下载, locate模式提示user is not authorized可尝试此模式
-		stream: 通过百度网盘的 PCS API, 以流式文件的方式下载, 效果同 pcs
-		locate: 默认的下载模式。从百度网盘 Android 客户端, 获取下载链接的方式来下载
+	将网盘中全部文件和目录的元信息保存到配置目录中的离线索引, 每个帐号一个索引.
+	ls, tree, search, find 使用 --offline (或 --cached) 时从离线索引获取, 不访问网络,
+	设置 config set -completion_index=true 后, tab 补全也从离线索引获取.
+	更新索引时优先通过文件变更 (cursor) 增量更新, 不可用时重新列出修改日期有变化的目录.
 
 	示例:
 
-	设置保存目录, 保存到 D:\Downloads
-	注意区别反斜杠 "\" 和 斜杠 "/" !!!
-	BaiduPCS-Go config set -savedir D:\\Downloads
-	或者
-	BaiduPCS-Go config set -savedir D:/Downloads
-
-	下载 /我的资源/1.mp4
-	BaiduPCS-Go d /我的资源/1.mp4
+	建立或更新离线索引
+	BaiduPCS-Go index update
 
-	下载 /我的资源 整个目录!!
-	BaiduPCS-Go d /我的资源
+	重新列出全部目录, 重建离线索引
+	BaiduPCS-Go index update --full
 
-	下载网盘内的全部文件!!
-	BaiduPCS-Go d /
-	BaiduPCS-Go d *
+	显示离线索引的状态
+	BaiduPCS-Go index status
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
 			Action: func(c *cli.Context) error {
-				if c.NArg() == 0 {
-					cli.ShowCommandHelp(c, c.Command.Name)
-					return nil
-				}
-
-				// 处理saveTo
-				var (
-					saveTo string
-				)
-				if c.Bool("save") {
-					saveTo = "."
-				} else if c.String("saveto") != "" {
-					saveTo = filepath.Clean(c.String("saveto"))
-				}
-
-				// 处理解析downloadMode
-				var (
-					downloadMode pcsdownload.DownloadMode
-				)
-				switch c.String("mode") {
-				case "pcs":
-					downloadMode = pcsdownload.DownloadModePCS
-				case "stream":
-					downloadMode = pcsdownload.DownloadModeStreaming
-				case "locate":
-					downloadMode = pcsdownload.DownloadModeLocate
+				pcscommand.RunIndexStatus()
+				return nil
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "update",
+					Usage:     "建立或更新离线索引",
+					UsageText: app.Name + " index update [--relist] [--full]",
+					Action: func(c *cli.Context) error {
+						pcscommand.RunIndexUpdate(&pcscommand.IndexUpdateOptions{
+							Relist: c.Bool("relist"),
+							Full:   c.Bool("full"),
+						})
+						return nil
+					},
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "relist",
+							Usage: "不使用文件变更, 重新列出修改日期有变化的目录",
+						},
+						cli.BoolFlag{
+							Name:  "full",
+							Usage: "重新列出全部目录",
+						},
+					},
+				},
+				{
+					Name:      "status",
+					Usage:     "显示离线索引的状态",
+					UsageText: app.Name + " index status",
+					Action: func(c *cli.Context) error {
+						pcscommand.RunIndexStatus()
+						return nil
+					},
+				},
+				{
+					Name:      "clear",
+					Usage:     "删除离线索引",
+					UsageText: app.Name + " index clear",
+					Action: func(c *cli.Context) error {
+						pcscommand.RunIndexClear()
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:        "meta",
+			Usage:       "获取文件/目录的元信息",
+			UsageText:   app.Name + " meta <文件/目录1> <文件/目录2> <文件/目录3> ...",
+			Description: "默认获取工作目录元信息",
+			Category:    "百度网盘",
+			Before:      reloadFn,
+			Action: func(c *cli.Context) error {
+				var (
+					ca = c.Args()
+					as []string
+				)
+				if len(ca) == 0 {
+					as = []string{""}
+				} else {
+					as = ca
+				}
+
+				pcscommand.RunGetMeta(as...)
+				return nil
+			},
+		},
+		{
+			Name:      "cat",
+			Usage:     "输出网盘文件的内容",
+			UsageText: app.Name + " cat [-z] <文件1> <文件2> ...",
+			Description: `
+	通过下载链接读取网盘文件, 直接输出到标准输出, 不保存到本地.
+
+	示例:
+
+	输出 /日志/app.log 的内容
+	BaiduPCS-Go cat /日志/app.log
+
+	解压并输出 /日志/app.log.gz 的内容
+	BaiduPCS-Go cat -z /日志/app.log.gz
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() == 0 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+				pcscommand.RunCat(c.Args(), &pcscommand.CatOptions{
+					Gunzip: c.Bool("z"),
+				})
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "z",
+					Usage: "解压 gzip 文件",
+				},
+			},
+		},
+		{
+			Name:      "head",
+			Usage:     "输出网盘文件开头的内容",
+			UsageText: app.Name + " head [-n 行数 | -c 大小] [-z] <文件>",
+			Description: `
+	通过 Range 请求只读取需要的部分, 默认输出前 10 行.
+
+	示例:
+
+	输出 /日志/app.log 的前 20 行
+	BaiduPCS-Go head -n 20 /日志/app.log
+
+	输出 /data.bin 的前 1KB
+	BaiduPCS-Go head -c 1KB /data.bin
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+				opt, err := headTailOptionsFn(c)
+				if err != nil {
+					fmt.Println(err)
+					return nil
+				}
+				pcscommand.RunHead(c.Args().Get(0), opt)
+				return nil
+			},
+			Flags: headTailFlags,
+		},
+		{
+			Name:      "tail",
+			Usage:     "输出网盘文件末尾的内容",
+			UsageText: app.Name + " tail [-n 行数 | -c 大小] [-z] [-f [-interval 间隔]] <文件>",
+			Description: `
+	通过 Range 请求从文件末尾读取, 默认输出最后 10 行. 解压 gzip 文件时需要读取整个文件.
+	使用 -f 时定时检查文件大小, 持续输出新增的内容, 按 Ctrl+C 退出.
+
+	示例:
+
+	输出 /日志/app.log 的最后 50 行, 并持续输出新增的内容
+	BaiduPCS-Go tail -n 50 -f /日志/app.log
+
+	输出 /data.bin 的最后 1KB
+	BaiduPCS-Go tail -c 1KB /data.bin
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+				opt, err := headTailOptionsFn(c)
+				if err != nil {
+					fmt.Println(err)
+					return nil
+				}
+				opt.Follow = c.Bool("f")
+				opt.Interval = c.Duration("interval")
+				pcscommand.RunTail(c.Args().Get(0), opt)
+				return nil
+			},
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "f",
+					Usage: "持续输出文件新增的内容",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "持续输出时检查文件大小的间隔",
+					Value: pcscommand.DefaultTailFollowInterval,
+				},
+			}, headTailFlags...),
+		},
+		{
+			Name:      "rm",
+			Usage:     "删除文件/目录",
+			UsageText: app.Name + " rm <文件/目录的路径1> <文件/目录2> <文件/目录3> ...",
+			Description: `
+	注意: 删除多个文件和目录时, 请确保每一个文件和目录都存在, 否则删除操作会失败.
+	被删除的文件或目录可在网盘文件回收站找回.
+
+	示例:
+
+	删除 /我的资源/1.mp4
+	BaiduPCS-Go rm /我的资源/1.mp4
+
+	删除 /我的资源/1.mp4 和 /我的资源/2.mp4
+	BaiduPCS-Go rm /我的资源/1.mp4 /我的资源/2.mp4
+
+	删除 /我的资源 内的所有文件和目录, 但不删除该目录
+	BaiduPCS-Go rm /我的资源/*
+
+	删除 /我的资源 整个目录 !!
+	BaiduPCS-Go rm /我的资源
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() == 0 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				pcscommand.RunRemove(c.Args()...)
+				return nil
+			},
+		},
+		{
+			Name:      "mkdir",
+			Usage:     "创建目录",
+			UsageText: app.Name + " mkdir <目录>",
+			Category:  "百度网盘",
+			Before:    reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() == 0 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				pcscommand.RunMkdir(c.Args().Get(0))
+				return nil
+			},
+		},
+		{
+			Name:  "cp",
+			Usage: "拷贝文件/目录",
+			UsageText: `BaiduPCS-Go cp <文件/目录> <目标文件/目录>
+	BaiduPCS-Go cp <文件/目录1> <文件/目录2> <文件/目录3> ... <目标目录>`,
+			Description: `
+	注意: 拷贝多个文件和目录时, 请确保每一个文件和目录都存在, 否则拷贝操作会失败.
+
+	示例:
+
+	将 /我的资源/1.mp4 复制到 根目录 /
+	BaiduPCS-Go cp /我的资源/1.mp4 /
+
+	将 /我的资源/1.mp4 和 /我的资源/2.mp4 复制到 根目录 /
+	BaiduPCS-Go cp /我的资源/1.mp4 /我的资源/2.mp4 /
+
+	跨帐号拷贝: 指定 --from-user 或 --to-user 时, 在两个已登录的帐号之间拷贝, 最后一个路径为目标帐号中的目录, 不存在时自动创建.
+	依次尝试源帐号临时私密分享 + 目标帐号转存, 秒传, 最后在两个帐号之间流式传输, 数据不经过本地磁盘.
+	临时分享在转存结束或中断后取消. 目标帐号中的同名文件按 --policy 处理, 默认使用配置中的上传策略.
+
+	将帐号 123 的 /我的资源 复制到帐号 456 的 /备份 目录
+	BaiduPCS-Go cp --from-user 123 --to-user 456 /我的资源 /备份
+
+	将当前帐号的 /我的资源/1.mp4 复制到帐号 456 的根目录, 不使用临时分享
+	BaiduPCS-Go cp --to-user 456 --no-share /我的资源/1.mp4 /
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() <= 1 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				if c.IsSet("from-user") || c.IsSet("to-user") {
+					pcscommand.RunCopyCrossUser(&pcscommand.CrossUserCopyOptions{
+						FromUID:  c.Uint64("from-user"),
+						ToUID:    c.Uint64("to-user"),
+						NoShare:  c.Bool("no-share"),
+						Parallel: c.Int("p"),
+						Policy:   c.String("policy"),
+					}, c.Args()...)
+					return nil
+				}
+
+				pcscommand.RunCopy(c.Args()...)
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.Uint64Flag{
+					Name:  "from-user",
+					Usage: "跨帐号拷贝的源帐号 uid, 默认为当前帐号",
+				},
+				cli.Uint64Flag{
+					Name:  "to-user",
+					Usage: "跨帐号拷贝的目标帐号 uid, 默认为当前帐号",
+				},
+				cli.BoolFlag{
+					Name:  "no-share",
+					Usage: "跨帐号拷贝时不尝试临时分享转存",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "跨帐号流式传输的上传并发量, 默认为上传最大并发量",
+				},
+				cli.StringFlag{
+					Name:  "policy",
+					Usage: fmt.Sprintf("跨帐号拷贝时对同名文件的处理策略 (default: %s), %s, %s", baidupcs.SkipPolicy, baidupcs.OverWritePolicy, baidupcs.RsyncPolicy),
+				},
+			},
+		},
+		{
+			Name:  "mv",
+			Usage: "移动/重命名文件/目录",
+			UsageText: `移动:
+	BaiduPCS-Go mv <文件/目录1> <文件/目录2> <文件/目录3> ... <目标目录>
+
+	重命名:
+	BaiduPCS-Go mv <文件/目录> <重命名的文件/目录>`,
+			Description: `
+	注意: 移动多个文件和目录时, 请确保每一个文件和目录都存在, 否则移动操作会失败.
+
+	示例:
+
+	将 /我的资源/1.mp4 移动到 根目录 /
+	BaiduPCS-Go mv /我的资源/1.mp4 /
+
+	将 /我的资源/1.mp4 重命名为 /我的资源/3.mp4
+	BaiduPCS-Go mv /我的资源/1.mp4 /我的资源/3.mp4
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() <= 1 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				pcscommand.RunMove(c.Args()...)
+				return nil
+			},
+		},
+		{
+			Name:      "rename",
+			Usage:     "批量重命名文件/目录",
+			UsageText: app.Name + " rename [-e <正则表达式>] <通配符> <模板或替换内容>",
+			Description: `
+	按通配符匹配文件/目录, 使用模板或正则表达式生成新的文件名, 只修改文件名, 不移动目录.
+	先输出预览, 检测冲突 (新文件名重复或已存在), 确认后分批提交重命名.
+
+	模板变量:
+		{name}                文件名, 不含扩展名
+		{ext}                 扩展名, 含 "."
+		{mtime:2006-01-02}    修改时间, 格式同 Go 的时间格式, 默认为 2006-01-02
+		{n:03}                序号, 03 为补零的宽度, 从 --start 开始
+		{parent}              所在目录名
+
+	使用 -e 时, 对文件名进行正则替换, 替换内容支持 $1, ${1} 等.
+
+	示例:
+
+	将 /照片 中的 jpg 文件重命名为 修改日期_序号.jpg
+	BaiduPCS-Go rename "/照片/*.jpg" "{mtime:20060102}_{n:04}{ext}"
+
+	将 /剧集 中的 S01E01.mkv 重命名为 第01集.mkv
+	BaiduPCS-Go rename -e "S\d+E(\d+)" "/剧集/*.mkv" "第${1}集"
+
+	只预览
+	BaiduPCS-Go rename --dry-run "/照片/*" "{parent}-{n:03}{ext}"
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				pcscommand.RunRename(c.Args().Get(0), c.Args().Get(1), &pcscommand.RenameOptions{
+					Regexp: c.String("e"),
+					Start:  c.Int("start"),
+					DryRun: c.Bool("dry-run"),
+					Yes:    c.Bool("y"),
+				})
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "e",
+					Usage: "使用正则表达式替换文件名",
+				},
+				cli.IntFlag{
+					Name:  "start",
+					Usage: "{n} 的起始序号",
+					Value: 1,
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "只预览, 不执行重命名",
+				},
+				cli.BoolFlag{
+					Name:  "y",
+					Usage: "不需要确认",
+				},
+			},
+		},
+		{
+			Name:      "download",
+			Aliases:   []string{"d"},
+			Usage:     "下载文件/目录",
+			UsageText: app.Name + " download <文件/目录路径1> <文件/目录2> <文件/目录3> ...",
+			Description: `
+	下载的文件默认保存到, 程序所在目录的 download/ 目录.
+	通过 BaiduPCS-Go config set -savedir <savedir>, 自定义保存的目录.
+	支持多个文件或目录下载.
+	支持下载完成后自动校验文件, 但并不是所有的文件都支持校验!
+	自动跳过下载重名的文件!
+	设置了加密口令时, 自动解密使用 upload --encrypt 加密上传的文件和文件名.
+
+	下载模式说明:
+		pcs: 通过百度网盘的 PCS API 下载, locate模式提示user is not authorized可尝试此模式
+		stream: 通过百度网盘的 PCS API, 以流式文件的方式下载, 效果同 pcs
+		locate: 默认的下载模式。从百度网盘 Android 客户端, 获取下载链接的方式来下载
+
+	示例:
+
+	设置保存目录, 保存到 D:\Downloads
+	注意区别反斜杠 "\" 和 斜杠 "/" !!!
+	BaiduPCS-Go config set -savedir D:\\Downloads
+	或者
+	BaiduPCS-Go config set -savedir D:/Downloads
+
+	下载 /我的资源/1.mp4
+	BaiduPCS-Go d /我的资源/1.mp4
+
+	下载 /我的资源 整个目录!!
+	BaiduPCS-Go d /我的资源
+
+	下载网盘内的全部文件!!
+	BaiduPCS-Go d /
+	BaiduPCS-Go d *
+
+	过滤:
+	下载目录时, 可以通过 --include, --exclude 指定通配符, 不含 / 的通配符匹配文件名, 含 / 的通配符匹配相对于下载目录的路径,
+	--exclude 匹配的目录整个跳过. --min-size, --max-size 限制文件大小, --newer-than, --older-than 限制文件修改时间,
+	时间可以是距离现在的时长 (如 30m, 12h, 7d, 2w) 或日期 (如 2020-01-01, 2020-01-01 12:00:00).
+	不满足条件的文件不会加入下载队列.
+
+	只下载 /我的资源 中的 mkv 文件, 跳过 sample 目录
+	BaiduPCS-Go d --include "*.mkv" --exclude "sample" /我的资源
+
+	下载 /logs 中最近一周, 不超过 100MB 的日志
+	BaiduPCS-Go d --include "*.log" --newer-than 7d --max-size 100MB /logs
+
+	下载任务:
+	每次下载的文件列表, 各文件的下载状态和下载选项, 会记录到配置目录的下载任务中,
+	全部下载成功后自动删除. 下载中断或有文件下载失败时, 可继续下载未完成的文件.
+
+	列出未完成的下载任务
+	BaiduPCS-Go d --jobs
+
+	继续下载 ID 为 1 的下载任务
+	BaiduPCS-Go d --resume-job 1
+
+	丢弃 ID 为 1 的下载任务, 不删除已下载的文件
+	BaiduPCS-Go d --discard-job 1
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				switch {
+				case c.Bool("jobs"):
+					pcscommand.RunDownloadJobList()
+					return nil
+				case c.String("resume-job") != "":
+					pcscommand.RunDownloadResumeJob(c.String("resume-job"))
+					return nil
+				case len(c.StringSlice("discard-job")) > 0:
+					pcscommand.RunDownloadJobDiscard(c.StringSlice("discard-job"))
+					return nil
+				}
+
+				if c.NArg() == 0 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				// 处理saveTo
+				var (
+					saveTo string
+				)
+				if c.Bool("save") {
+					saveTo = "."
+				} else if c.String("saveto") != "" {
+					saveTo = filepath.Clean(c.String("saveto"))
+				}
+
+				// 处理解析downloadMode
+				var (
+					downloadMode pcsdownload.DownloadMode
+				)
+				switch c.String("mode") {
+				case "pcs":
+					downloadMode = pcsdownload.DownloadModePCS
+				case "stream":
+					downloadMode = pcsdownload.DownloadModeStreaming
+				case "locate":
+					downloadMode = pcsdownload.DownloadModeLocate
 				default:
 					fmt.Println("下载方式解析失败")
 					cli.ShowCommandHelp(c, c.Command.Name)
 					return nil
 				}
 
+				// 处理过滤条件
+				filter := &pcsdownload.DownloadFilter{
+					Include: c.StringSlice("include"),
+					Exclude: c.StringSlice("exclude"),
+				}
+				err := filter.Validate()
+				if err != nil {
+					fmt.Println(err)
+					return nil
+				}
+				for _, size := range []struct {
+					name string
+					ptr  *int64
+				}{{"min-size", &filter.MinSize}, {"max-size", &filter.MaxSize}} {
+					if c.String(size.name) == "" {
+						continue
+					}
+					*size.ptr, err = converter.ParseFileSizeStr(c.String(size.name))
+					if err != nil {
+						fmt.Printf("解析 --%s 失败: %s\n", size.name, err)
+						return nil
+					}
+				}
+				now := time.Now()
+				for _, t := range []struct {
+					name string
+					ptr  *time.Time
+				}{{"newer-than", &filter.NewerThan}, {"older-than", &filter.OlderThan}} {
+					if c.String(t.name) == "" {
+						continue
+					}
+					*t.ptr, err = pcstime.ParseTimeOption(c.String(t.name), now)
+					if err != nil {
+						fmt.Printf("解析 --%s 失败: %s\n", t.name, err)
+						return nil
+					}
+				}
+
 				do := &pcscommand.DownloadOptions{
 					IsTest:               c.Bool("test"),
 					IsPrintStatus:        c.Bool("status"),
@@ -1107,6 +1873,9 @@ func main() {
 					ModifyMTime:          c.Bool("mtime"),
 					FullPath:             c.Bool("fullpath"),
 				}
+				if !filter.IsEmpty() {
+					do.Filter = filter
+				}
 
 				pcscommand.RunDownload(c.Args(), do)
 
@@ -1171,6 +1940,131 @@ func main() {
 					Name:  "fullpath",
 					Usage: "以网盘完整路径保存到本地",
 				},
+				cli.StringSliceFlag{
+					Name:  "include",
+					Usage: "只下载文件名或相对路径匹配通配符的文件, 可指定多个",
+				},
+				cli.StringSliceFlag{
+					Name:  "exclude",
+					Usage: "不下载文件名或相对路径匹配通配符的文件和目录, 可指定多个",
+				},
+				cli.StringFlag{
+					Name:  "min-size",
+					Usage: "只下载不小于该大小的文件, 如 100MB",
+				},
+				cli.StringFlag{
+					Name:  "max-size",
+					Usage: "只下载不大于该大小的文件, 如 2GB",
+				},
+				cli.StringFlag{
+					Name:  "newer-than",
+					Usage: "只下载修改时间在此之后的文件, 如 7d, 12h, 2020-01-01",
+				},
+				cli.StringFlag{
+					Name:  "older-than",
+					Usage: "只下载修改时间在此之前的文件, 如 30d, 2020-01-01",
+				},
+				cli.BoolFlag{
+					Name:  "jobs",
+					Usage: "列出未完成的下载任务",
+				},
+				cli.StringFlag{
+					Name:  "resume-job",
+					Usage: "继续下载中断的下载任务, 值为任务 ID, 使用任务记录的下载选项",
+				},
+				cli.StringSliceFlag{
+					Name:  "discard-job",
+					Usage: "丢弃下载任务, 不删除已下载的文件, 值为任务 ID, 可指定多个",
+				},
+			},
+		},
+		{
+			Name:      "mirror",
+			Usage:     "将网盘目录镜像到本地",
+			UsageText: app.Name + " mirror <网盘目录> <本地目录>",
+			Description: `
+	比较网盘文件和本地文件的大小, 修改时间 (可选md5), 只下载新增或发生改变的文件,
+	下载的文件修改时间将会设置为与网盘一致. 使用 --delete 时删除本地中网盘不存在的文件.
+
+	示例:
+
+	1. 预览将网盘 /团队资料 镜像到本地 D:/团队资料 的计划
+	BaiduPCS-Go mirror --dry-run /团队资料 D:/团队资料
+
+	2. 镜像, 并删除本地中多余的文件
+	BaiduPCS-Go mirror --delete /团队资料 D:/团队资料
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				var (
+					downloadMode pcsdownload.DownloadMode
+				)
+				switch c.String("mode") {
+				case "pcs":
+					downloadMode = pcsdownload.DownloadModePCS
+				case "stream":
+					downloadMode = pcsdownload.DownloadModeStreaming
+				case "locate":
+					downloadMode = pcsdownload.DownloadModeLocate
+				default:
+					fmt.Println("下载方式解析失败")
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				pcscommand.RunMirror(c.Args().Get(0), c.Args().Get(1), &pcscommand.MirrorOptions{
+					Parallel:     c.Int("p"),
+					Load:         c.Int("l"),
+					MaxRetry:     c.Int("retry"),
+					NoCheck:      c.Bool("nocheck"),
+					DownloadMode: downloadMode,
+					DryRun:       c.Bool("dry-run"),
+					CheckMD5:     c.Bool("md5"),
+					Delete:       c.Bool("delete"),
+				})
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "只输出镜像计划, 不执行",
+				},
+				cli.BoolFlag{
+					Name:  "md5",
+					Usage: "文件大小一致时, 比较md5",
+				},
+				cli.BoolFlag{
+					Name:  "delete",
+					Usage: "删除本地中网盘不存在的文件",
+				},
+				cli.StringFlag{
+					Name:  "mode",
+					Usage: "下载模式, 可选值: pcs, stream, locate",
+					Value: "locate",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "指定下载线程数",
+				},
+				cli.IntFlag{
+					Name:  "l",
+					Usage: "指定同时进行下载文件的数量",
+				},
+				cli.IntFlag{
+					Name:  "retry",
+					Usage: "下载失败最大重试次数",
+					Value: pcsdownload.DefaultDownloadMaxRetry,
+				},
+				cli.BoolFlag{
+					Name:  "nocheck",
+					Usage: "下载文件完成后不校验文件",
+				},
 			},
 		},
 		{
@@ -1205,6 +2099,18 @@ func main() {
 
 	4. 使用相对路径
 	BaiduPCS-Go upload 1.mp4 /视频
+
+	5. 上传本地目录 ~/照片 到网盘 /备份 目录, 之后持续监听目录变化, 自动上传新建和修改的文件
+	BaiduPCS-Go upload --watch ~/照片 /备份
+
+	6. 同上, 并将本地的删除和重命名同步到网盘
+	BaiduPCS-Go upload --watch --sync-delete ~/照片 /备份
+
+	7. 加密上传, 需要先设置加密口令: config set -encrypt_passphrase <口令>
+	加密后的文件名会加上 .bpe 后缀, 使用 --encrypt-name 同时加密文件名
+	加密上传禁用秒传和断点续传, 下载时会自动解密
+	BaiduPCS-Go upload --encrypt C:/Users/Administrator/Desktop/1.mp4 /视频
+	BaiduPCS-Go upload --encrypt-name C:/Users/Administrator/Desktop /视频
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
@@ -1215,13 +2121,33 @@ func main() {
 				}
 
 				subArgs := c.Args()
-				pcscommand.RunUpload(subArgs[:c.NArg()-1], subArgs[c.NArg()-1], &pcscommand.UploadOptions{
+				opt := &pcscommand.UploadOptions{
 					Parallel:      c.Int("p"),
 					MaxRetry:      c.Int("retry"),
 					Load:          c.Int("l"),
 					NoRapidUpload: c.Bool("norapid"),
 					Policy:        c.String("policy"),
-				})
+					Encrypt:       c.Bool("encrypt"),
+					EncryptName:   c.Bool("encrypt-name"),
+				}
+
+				if c.Bool("watch") {
+					if opt.Encrypt || opt.EncryptName {
+						fmt.Printf("监听上传不支持加密\n")
+						return nil
+					}
+					if c.NArg() != 2 {
+						fmt.Printf("监听上传只支持单个本地目录\n")
+						return nil
+					}
+					pcscommand.RunUploadWatch(subArgs[0], subArgs[1], opt, &pcscommand.WatchOptions{
+						Debounce:   c.Duration("debounce"),
+						SyncDelete: c.Bool("sync-delete"),
+					})
+					return nil
+				}
+
+				pcscommand.RunUpload(subArgs[:c.NArg()-1], subArgs[c.NArg()-1], opt)
 				return nil
 			},
 			Flags: []cli.Flag{
@@ -1242,9 +2168,136 @@ func main() {
 					Name:  "norapid",
 					Usage: "跳过秒传",
 				},
-				cli.StringFlag{
-					Name:  "policy",
-					Usage: fmt.Sprintf("对同名文件的处理策略 (default: %s), %s, %s", baidupcs.SkipPolicy, baidupcs.OverWritePolicy, baidupcs.RsyncPolicy),
+				cli.StringFlag{
+					Name:  "policy",
+					Usage: fmt.Sprintf("对同名文件的处理策略 (default: %s), %s, %s", baidupcs.SkipPolicy, baidupcs.OverWritePolicy, baidupcs.RsyncPolicy),
+				},
+				cli.BoolFlag{
+					Name:  "encrypt",
+					Usage: "使用加密口令加密文件内容后上传",
+				},
+				cli.BoolFlag{
+					Name:  "encrypt-name",
+					Usage: "加密文件内容和文件名后上传",
+				},
+				cli.BoolFlag{
+					Name:  "watch",
+					Usage: "上传后持续监听本地目录, 自动上传新建和修改的文件",
+				},
+				cli.BoolFlag{
+					Name:  "sync-delete",
+					Usage: "监听上传时, 同步删除和重命名到网盘",
+				},
+				cli.DurationFlag{
+					Name:  "debounce",
+					Usage: "监听上传时, 文件停止写入多久后上传",
+					Value: pcscommand.DefaultWatchDebounce,
+				},
+			},
+		},
+		{
+			Name:      "sync",
+			Usage:     "将本地目录单向同步到网盘",
+			UsageText: app.Name + " sync <本地目录> <网盘目录>",
+			Description: `
+	比较本地目录和网盘目录中文件的大小, 修改时间 (可选md5), 生成同步计划:
+	网盘中不存在的文件将会上传, 发生改变的文件将会覆盖, 使用 --delete 时删除网盘中本地不存在的文件.
+
+	示例:
+
+	1. 预览将本地的 C:/Users/Administrator/Desktop/project 同步到网盘 /project 的计划
+	BaiduPCS-Go sync --dry-run C:/Users/Administrator/Desktop/project /project
+
+	2. 同步, 并删除网盘中多余的文件
+	BaiduPCS-Go sync --delete C:/Users/Administrator/Desktop/project /project
+
+	3. 大小一致时, 通过md5判断文件是否改变
+	BaiduPCS-Go sync --md5 project /project
+
+	双向同步:
+	使用 --two-way 进行双向同步, 每次同步完成后的状态保存在配置目录, 下次同步时据此判断两边的修改,
+	删除和重命名 (网盘通过 fs_id, 本地通过文件大小和修改时间判断).
+	两边都修改了同一个文件时, 按 --conflict 指定的策略处理:
+	keep-both: 保留两者, 本地文件重命名为 文件名.conflict-时间.扩展名 后上传 (默认)
+	newer: 保留修改时间较新的文件
+	local: 以本地文件为准
+	remote: 以网盘文件为准
+
+	网盘目录不存在或将删除的文件数超过 --max-delete 时, 取消同步, 以免误删文件.
+
+	4. 双向同步, 冲突时保留较新的文件
+	BaiduPCS-Go sync --two-way --conflict newer project /project
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				pcscommand.RunSync(c.Args().Get(0), c.Args().Get(1), &pcscommand.SyncOptions{
+					Parallel:      c.Int("p"),
+					MaxRetry:      c.Int("retry"),
+					Load:          c.Int("l"),
+					NoRapidUpload: c.Bool("norapid"),
+					NoSplitFile:   c.Bool("nosplit"),
+					DryRun:        c.Bool("dry-run"),
+					CheckMD5:      c.Bool("md5"),
+					Delete:        c.Bool("delete"),
+					TwoWay:        c.Bool("two-way"),
+					Conflict:      c.String("conflict"),
+					MaxDelete:     c.Int("max-delete"),
+				})
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "只输出同步计划, 不执行",
+				},
+				cli.BoolFlag{
+					Name:  "two-way",
+					Usage: "双向同步",
+				},
+				cli.StringFlag{
+					Name:  "conflict",
+					Usage: "双向同步的冲突处理策略, 可选值: keep-both, newer, local, remote",
+					Value: "keep-both",
+				},
+				cli.IntFlag{
+					Name:  "max-delete",
+					Usage: "双向同步一次最多删除的文件数, 超过时取消同步, 0 为不限制",
+					Value: pcscommand.DefaultSyncMaxDelete,
+				},
+				cli.BoolFlag{
+					Name:  "md5",
+					Usage: "文件大小一致时, 比较md5",
+				},
+				cli.BoolFlag{
+					Name:  "delete",
+					Usage: "删除网盘中本地不存在的文件, 不能用于双向同步",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "指定单个文件上传的最大线程数",
+				},
+				cli.IntFlag{
+					Name:  "retry",
+					Usage: "上传或下载失败最大重试次数",
+					Value: pcscommand.DefaultUploadMaxRetry,
+				},
+				cli.IntFlag{
+					Name:  "l",
+					Usage: "指定同时上传的最大文件数",
+				},
+				cli.BoolFlag{
+					Name:  "norapid",
+					Usage: "跳过秒传",
+				},
+				cli.BoolFlag{
+					Name:  "nosplit",
+					Usage: "禁用分片上传",
 				},
 			},
 		},
@@ -1340,29 +2393,103 @@ func main() {
 			Before:    reloadFn,
 			Description: `
 			转存文件/目录
-	如果没有提取码或为整合式链接，则第二个位置留空；只能转存到当前网盘目录下，
+	如果没有提取码或为整合式链接，则第二个位置留空；默认转存到当前网盘目录下，
 	分享链接支持常规百度云链接, 支持长短秒传链接
+
+	可以只转存分享中的部分文件, 路径相对于分享根目录 (可通过 share ls 查看):
+	-path 指定路径, -match 指定通配符, 可以多次使用; -select 交互式选择.
+	同名文件的处理方式 -ondup: skip 跳过, rename 自动重命名, overwrite 覆盖, 默认转存失败. skip 不能与 -collect 同时使用.
 	
 	实例：
 	BaiduPCS-Go transfer pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e
 	BaiduPCS-Go transfer https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e
 	BaiduPCS-Go transfer https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ?pwd=704e
 
+	只转存分享中的 /dir1/sub 目录到网盘的 /save 目录, 跳过已存在的文件:
+	BaiduPCS-Go transfer -path /dir1/sub -saveto /save -ondup skip https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e
+
+	只转存分享中 /dir1 目录下的 mkv 文件:
+	BaiduPCS-Go transfer -match "/dir1/*.mkv" https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e
+
+	交互式选择 /dir1 目录下要转存的文件:
+	BaiduPCS-Go transfer -match "/dir1/*" -select https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e
+
+	批量转存 links.txt 中的分享链接, 每行可以是 "链接 提取码", "链接?pwd=提取码", 或含有 "提取码: xxxx" 的文本,
+	结果报告保存到 report.csv, 包含每个链接的状态: success 成功, invalid_link 链接无效, wrong_code 提取码错误,
+	expired 已失效, quota_full 容量不足, verify 多次重试后仍触发验证, failed 其他错误.
+	触发验证时自动加倍转存间隔并重试:
+	BaiduPCS-Go transfer -from-file links.txt -report report.csv
 	`,
 			Action: func(c *cli.Context) error {
-				if c.NArg() < 1 || c.NArg() > 2 {
+				fromFile := c.String("from-file")
+				if (fromFile == "" && (c.NArg() < 1 || c.NArg() > 2)) || (fromFile != "" && c.NArg() > 0) {
 					cli.ShowCommandHelp(c, c.Command.Name)
 					return nil
 				}
 				opt := &baidupcs.TransferOption{
-					Download: c.Bool("download"),
-					Collect:  c.Bool("collect"),
-					Rname:    c.Bool("rname"),
+					Download:    c.Bool("download"),
+					Collect:     c.Bool("collect"),
+					Rname:       c.Bool("rname"),
+					SaveTo:      c.String("saveto"),
+					Paths:       c.StringSlice("path"),
+					Patterns:    c.StringSlice("match"),
+					Interactive: c.Bool("select"),
+					OnDup:       c.String("ondup"),
+				}
+				if err := pcscommand.CheckTransferOption(opt); err != nil {
+					fmt.Println(err)
+					return nil
+				}
+				if fromFile != "" {
+					pcscommand.RunShareTransferFromFile(fromFile, opt, &pcscommand.BatchTransferOptions{
+						Parallel: c.Int("p"),
+						Interval: c.Duration("interval"),
+						Report:   c.String("report"),
+					})
+					return nil
 				}
 				pcscommand.RunShareTransfer(c.Args(), opt)
 				return nil
 			},
 			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from-file",
+					Usage: "从文件中读取分享链接批量转存, - 为标准输入",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "批量转存的并发数",
+					Value: pcscommand.DefaultBatchTransferParallel,
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "批量转存时两次转存的最小间隔, 避免触发验证",
+					Value: pcscommand.DefaultBatchTransferInterval,
+				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "批量转存的结果报告文件, 扩展名为 .json 或 .ndjson 时输出对应格式, 否则输出 csv",
+				},
+				cli.StringFlag{
+					Name:  "saveto",
+					Usage: "转存到的网盘目录, 不存在时自动创建, 默认为当前工作目录",
+				},
+				cli.StringSliceFlag{
+					Name:  "path",
+					Usage: "只转存分享中的路径, 相对于分享根目录, 可多次使用",
+				},
+				cli.StringSliceFlag{
+					Name:  "match",
+					Usage: "只转存分享中匹配通配符的路径, 相对于分享根目录, 可多次使用",
+				},
+				cli.BoolFlag{
+					Name:  "select",
+					Usage: "交互式选择要转存的文件",
+				},
+				cli.StringFlag{
+					Name:  "ondup",
+					Usage: "同名文件的处理方式: skip, rename, overwrite",
+				},
 				cli.BoolFlag{
 					Name:  "download",
 					Usage: "转存后直接下载到本地默认目录",
@@ -1442,12 +2569,44 @@ func main() {
 					},
 				},
 				{
-					Name:        "cancel",
-					Aliases:     []string{"c"},
-					Usage:       "取消分享文件/目录",
-					UsageText:   app.Name + " share cancel <shareid_1> <shareid_2> ...",
-					Description: `目前只支持通过分享id (shareid) 来取消分享.`,
+					Name:      "cancel",
+					Aliases:   []string{"c"},
+					Usage:     "取消分享文件/目录",
+					UsageText: app.Name + " share cancel <shareid_1> <shareid_2> ...\n   " + app.Name + " share cancel [--expired] [--path-glob <通配符>] [--older-than <时间>] [-y]",
+					Description: `
+	通过分享id (shareid) 取消分享, 或按条件批量取消分享, 多个条件同时满足才会取消.
+	--path-glob 匹配分享的路径或其上级目录.
+
+	示例:
+
+	取消所有已过期的分享:
+	BaiduPCS-Go share cancel --expired
+
+	取消 /公开 目录下, 创建时间早于 30 天前的分享:
+	BaiduPCS-Go share cancel --path-glob /公开 --older-than 30d
+`,
 					Action: func(c *cli.Context) error {
+						filter := &pcsshare.CancelFilter{
+							Expired:  c.Bool("expired"),
+							PathGlob: c.String("path-glob"),
+						}
+						if c.String("older-than") != "" {
+							var err error
+							filter.OlderThan, err = pcstime.ParseTimeOption(c.String("older-than"), time.Now())
+							if err != nil {
+								fmt.Printf("解析 --older-than 失败: %s\n", err)
+								return nil
+							}
+						}
+						if !filter.IsEmpty() {
+							if c.NArg() > 0 {
+								cli.ShowCommandHelp(c, c.Command.Name)
+								return nil
+							}
+							pcscommand.RunShareCancelFilter(filter, c.Bool("y"))
+							return nil
+						}
+
 						if c.NArg() < 1 {
 							cli.ShowCommandHelp(c, c.Command.Name)
 							return nil
@@ -1455,6 +2614,103 @@ func main() {
 						pcscommand.RunShareCancel(converter.SliceStringToInt64(c.Args()))
 						return nil
 					},
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "expired",
+							Usage: "取消已过期的分享",
+						},
+						cli.StringFlag{
+							Name:  "path-glob",
+							Usage: "取消路径或上级目录匹配通配符的分享",
+						},
+						cli.StringFlag{
+							Name:  "older-than",
+							Usage: "取消创建时间早于指定时间的分享, 如 30d, 12h, 2023-01-02",
+						},
+						cli.BoolFlag{
+							Name:  "y",
+							Usage: "按条件取消时不需要确认",
+						},
+					},
+				},
+				{
+					Name:      "audit",
+					Usage:     "检查全部分享",
+					UsageText: app.Name + " share audit [-flagged] [-no-check-path] [-o <文件>]",
+					Description: `
+	获取全部分享和提取码, 标记有问题的分享: 已过期 (expired), 公开分享或无提取码 (public_no_password),
+	分享的文件已被删除 (path_deleted). path_deleted 只检查分享的代表路径, 路径不存在或 fs_id 不同时视为已删除,
+	分享多个文件时其他文件被删除无法发现.
+	-o 导出检查结果, 扩展名为 .json 或 .ndjson 时输出对应格式, 否则输出 csv. 也支持 --format 输出.
+
+	示例:
+
+	导出全部分享到 shares.csv:
+	BaiduPCS-Go share audit -o shares.csv
+
+	只列出有问题的分享:
+	BaiduPCS-Go share audit -flagged
+`,
+					Action: func(c *cli.Context) error {
+						pcscommand.RunShareAudit(&pcscommand.ShareAuditOptions{
+							OnlyFlagged: c.Bool("flagged"),
+							NoCheckPath: c.Bool("no-check-path"),
+							Output:      c.String("o"),
+						})
+						return nil
+					},
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "flagged",
+							Usage: "只列出有问题的分享",
+						},
+						cli.BoolFlag{
+							Name:  "no-check-path",
+							Usage: "不检查分享的文件是否已被删除",
+						},
+						cli.StringFlag{
+							Name:  "o",
+							Usage: "导出到文件",
+						},
+					},
+				},
+				{
+					Name:      "ls",
+					Usage:     "列出分享链接中的文件, 不转存",
+					UsageText: app.Name + " share ls <分享链接> [提取码] [子目录]",
+					Description: `
+	列出分享链接中的文件和目录, 显示文件大小和md5, 不会转存到自己的网盘.
+	子目录为相对于分享根目录的路径, 以 / 开头.
+	支持 --format 输出 json, ndjson, csv.
+
+	示例:
+
+	列出分享链接的根目录:
+	BaiduPCS-Go share ls https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e
+
+	递归列出分享链接中的子目录:
+	BaiduPCS-Go share ls -r https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ 704e /dir1
+
+	以 json 格式输出:
+	BaiduPCS-Go --format json share ls -r https://pan.baidu.com/s/1VYzSl7465sdrQXe8GT5RdQ?pwd=704e
+`,
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 1 || c.NArg() > 3 {
+							cli.ShowCommandHelp(c, c.Command.Name)
+							return nil
+						}
+						link, extraCode, subPath := pcscommand.ParseShareLsArgs(c.Args())
+						pcscommand.RunShareLs(link, extraCode, subPath, &pcscommand.ShareLsOptions{
+							Recursive: c.Bool("r"),
+						})
+						return nil
+					},
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "r",
+							Usage: "递归列出子目录",
+						},
+					},
 				},
 			},
 		},
@@ -1560,14 +2816,43 @@ func main() {
 					Name:      "add",
 					Aliases:   []string{"a"},
 					Usage:     "添加离线下载任务",
-					UsageText: app.Name + " offlinedl add -path=<离线下载文件保存的路径> 资源地址1 地址2 ...",
+					UsageText: app.Name + " offlinedl add -path=<离线下载文件保存的路径> [--wait [--download-to <本地目录>]] 资源地址1 地址2 ...",
+					Description: `
+	--wait 等待任务完成, 显示已完成大小, 速度和状态. 任务失败时以非零退出码退出:
+	1 添加或查询任务失败, 2 系统错误, 3 资源不存在, 4 下载超时, 5 资源存在但下载失败 (如版权限制),
+	6 存储空间不足, 7 任务取消, 8 等待超时, 9 下载到本地失败.
+	连续 5 次查询失败时放弃等待.
+	--download-to 任务完成后下载到本地目录, 下载成功且本地文件大小与网盘一致时删除网盘中的文件, 使用 --keep 保留.
+
+	示例:
+
+	等待离线下载完成后下载到本地 ./downloads:
+	BaiduPCS-Go offlinedl add -path=/离线 --wait --download-to ./downloads magnet:?xt=urn:btih:xxx
+`,
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 1 {
 							cli.ShowCommandHelp(c, c.Command.Name)
 							return nil
 						}
 
-						pcscommand.RunCloudDlAddTask(c.Args(), c.String("path"))
+						if !c.Bool("wait") {
+							if c.String("download-to") != "" {
+								fmt.Println("--download-to 需要与 --wait 一起使用")
+								return nil
+							}
+							pcscommand.RunCloudDlAddTask(c.Args(), c.String("path"))
+							return nil
+						}
+
+						code := pcscommand.RunCloudDlAddTaskWait(c.Args(), c.String("path"), &pcscommand.CloudDlWaitOptions{
+							Timeout:    c.Duration("timeout"),
+							DownloadTo: c.String("download-to"),
+							Keep:       c.Bool("keep"),
+						})
+						// 交互模式下不退出程序
+						if code != 0 && !isCli {
+							return cli.NewExitError("", code)
+						}
 						return nil
 					},
 					Flags: []cli.Flag{
@@ -1575,6 +2860,22 @@ func main() {
 							Name:  "path",
 							Usage: "离线下载文件保存的路径, 默认为工作目录",
 						},
+						cli.BoolFlag{
+							Name:  "wait",
+							Usage: "等待任务完成, 任务失败时以非零退出码退出",
+						},
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "--wait 最长等待时间, 0 为不限制",
+						},
+						cli.StringFlag{
+							Name:  "download-to",
+							Usage: "任务完成后下载到本地目录, 需要与 --wait 一起使用",
+						},
+						cli.BoolFlag{
+							Name:  "keep",
+							Usage: "下载到本地后保留网盘中的文件",
+						},
 					},
 				},
 				{
@@ -1684,6 +2985,15 @@ func main() {
 
 	3. 清空回收站, 程序不会进行二次确认, 谨慎操作!!!
 	BaiduPCS-Go recycle delete -all
+
+	4. 从回收站还原 /work/2023 中的全部 psd 文件, ** 匹配任意层目录
+	BaiduPCS-Go recycle restore "/work/2023/**/*.psd"
+
+	5. 从回收站还原最近 3 天删除的文件
+	BaiduPCS-Go recycle restore --deleted-after 3d
+
+	6. 从回收站删除 2023-01-01 之前删除的 /tmp 中的文件
+	BaiduPCS-Go recycle delete --deleted-before 2023-01-01 "/tmp/**"
 `,
 			Category: "百度网盘",
 			Before:   reloadFn,
@@ -1712,26 +3022,41 @@ func main() {
 					},
 				},
 				{
-					Name:        "restore",
-					Aliases:     []string{"r"},
-					Usage:       baidupcs.OperationRecycleRestore,
-					UsageText:   app.Name + " recycle restore <fs_id 1> <fs_id 2> <fs_id 3> ...",
-					Description: `根据文件/目录的 fs_id, 还原回收站指定的文件或目录`,
+					Name:      "restore",
+					Aliases:   []string{"r"},
+					Usage:     baidupcs.OperationRecycleRestore,
+					UsageText: app.Name + " recycle restore [--deleted-after 7d] [--deleted-before 1d] [-y] <fs_id 或原路径/通配符 1> <fs_id 或原路径/通配符 2> ...",
+					Description: `根据文件/目录的 fs_id, 或原路径/通配符和删除时间, 还原回收站指定的文件或目录.
+	按原路径匹配时, 自动获取回收站的全部文件, 列出匹配的文件并确认后, 分批还原.
+	通配符中的 ** 匹配任意层目录. 删除时间以回收站中文件的修改日期为准.`,
 					Action: func(c *cli.Context) error {
-						if c.NArg() <= 0 {
-							cli.ShowCommandHelp(c, c.Command.Name)
+						opt, err := recycleMatchOptionsFn(c)
+						if err != nil {
+							fmt.Println(err)
 							return nil
 						}
-						pcscommand.RunRecycleRestore(c.Args()...)
+						if opt.DeletedAfter.IsZero() && opt.DeletedBefore.IsZero() {
+							if c.NArg() <= 0 {
+								cli.ShowCommandHelp(c, c.Command.Name)
+								return nil
+							}
+							if pcscommand.IsRecycleFsIDList(c.Args()) {
+								pcscommand.RunRecycleRestore(c.Args()...)
+								return nil
+							}
+						}
+						pcscommand.RunRecycleRestoreByPattern(c.Args(), opt)
 						return nil
 					},
+					Flags: recycleMatchFlags,
 				},
 				{
-					Name:        "delete",
-					Aliases:     []string{"d"},
-					Usage:       baidupcs.OperationRecycleDelete + "/" + baidupcs.OperationRecycleClear,
-					UsageText:   app.Name + " recycle delete [-all] <fs_id 1> <fs_id 2> <fs_id 3> ...",
-					Description: `根据文件/目录的 fs_id 或 -all 参数, 删除回收站指定的文件或目录或清空回收站`,
+					Name:      "delete",
+					Aliases:   []string{"d"},
+					Usage:     baidupcs.OperationRecycleDelete + "/" + baidupcs.OperationRecycleClear,
+					UsageText: app.Name + " recycle delete [-all] [--deleted-after 7d] [--deleted-before 1d] [-y] <fs_id 或原路径/通配符 1> <fs_id 或原路径/通配符 2> ...",
+					Description: `根据文件/目录的 fs_id, 原路径/通配符和删除时间, 或 -all 参数, 删除回收站指定的文件或目录或清空回收站.
+	按原路径匹配时, 自动获取回收站的全部文件, 列出匹配的文件并确认后, 分批删除.`,
 					Action: func(c *cli.Context) error {
 						if c.Bool("all") {
 							// 清空回收站
@@ -1739,19 +3064,30 @@ func main() {
 							return nil
 						}
 
-						if c.NArg() <= 0 {
-							cli.ShowCommandHelp(c, c.Command.Name)
+						opt, err := recycleMatchOptionsFn(c)
+						if err != nil {
+							fmt.Println(err)
 							return nil
 						}
-						pcscommand.RunRecycleDelete(c.Args()...)
+						if opt.DeletedAfter.IsZero() && opt.DeletedBefore.IsZero() {
+							if c.NArg() <= 0 {
+								cli.ShowCommandHelp(c, c.Command.Name)
+								return nil
+							}
+							if pcscommand.IsRecycleFsIDList(c.Args()) {
+								pcscommand.RunRecycleDelete(c.Args()...)
+								return nil
+							}
+						}
+						pcscommand.RunRecycleDeleteByPattern(c.Args(), opt)
 						return nil
 					},
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.BoolFlag{
 							Name:  "all",
 							Usage: "清空回收站, 程序不会进行二次确认, 谨慎操作!!!",
 						},
-					},
+					}, recycleMatchFlags...),
 				},
 			},
 		},
@@ -1801,6 +3137,9 @@ func main() {
 						if c.IsSet("ignore_illegal") {
 							pcsconfig.Config.SetIgnoreIllegal(c.Bool("ignore_illegal"))
 						}
+						if c.IsSet("completion_index") {
+							pcsconfig.Config.SetCompletionIndex(c.Bool("completion_index"))
+						}
 						if c.IsSet("force_login_username") {
 							pcsconfig.Config.SetForceLogin(c.String("force_login_username"))
 						}
@@ -1868,6 +3207,9 @@ func main() {
 						if c.IsSet("local_addrs") {
 							pcsconfig.Config.SetLocalAddrs(c.String("local_addrs"))
 						}
+						if c.IsSet("encrypt_passphrase") {
+							pcsconfig.Config.SetEncryptPassphrase(c.String("encrypt_passphrase"))
+						}
 
 						err := pcsconfig.Config.Save()
 						if err != nil {
@@ -1925,6 +3267,10 @@ func main() {
 							Name:  "ignore_illegal",
 							Usage: "忽略上传时文件名中的非法字符",
 						},
+						cli.BoolFlag{
+							Name:  "completion_index",
+							Usage: "tab 补全从离线索引获取",
+						},
 						cli.StringFlag{
 							Name:  "force_login_username",
 							Usage: "强制登录指定用户名, 只适用于tieba接口失效的情况",
@@ -1965,6 +3311,10 @@ func main() {
 							Name:  "local_addrs",
 							Usage: "设置本地网卡地址, 多个地址用逗号隔开",
 						},
+						cli.StringFlag{
+							Name:  "encrypt_passphrase",
+							Usage: "设置客户端加密口令, 修改后将无法解密之前加密上传的文件",
+						},
 					},
 				},
 				{
@@ -1986,6 +3336,212 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:     "serve",
+			Usage:    "启动服务",
+			Category: "百度网盘",
+			Action: func(c *cli.Context) error {
+				cli.ShowCommandHelp(c, c.Command.Name)
+				return nil
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "webdav",
+					Usage:     "通过 WebDAV 访问网盘",
+					UsageText: app.Name + " serve webdav [arguments...]",
+					Description: `
+	启动 WebDAV 服务, 将当前帐号的网盘目录提供给 WebDAV 客户端访问.
+	目录列表会被缓存, 通过 --cache 设置缓存时间.
+	上传的文件先保存到本地临时目录, 传输完成后上传到网盘.
+
+	示例:
+
+	1. 在 127.0.0.1:8080 启动 WebDAV 服务
+	BaiduPCS-Go serve webdav
+
+	2. 将网盘目录 /我的资源 作为根目录, 并设置用户名和密码
+	BaiduPCS-Go serve webdav --addr :8080 --root /我的资源 --user admin --pass 123456
+`,
+					Before: reloadFn,
+					Action: func(c *cli.Context) error {
+						pcscommand.RunServeWebDAV(&pcscommand.WebDAVOptions{
+							Addr:         c.String("addr"),
+							Root:         c.String("root"),
+							Username:     c.String("user"),
+							Password:     c.String("pass"),
+							CacheExpires: c.Duration("cache"),
+						})
+						return nil
+					},
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "addr",
+							Usage: "监听地址",
+							Value: "127.0.0.1:8080",
+						},
+						cli.StringFlag{
+							Name:  "root",
+							Usage: "作为根目录的网盘目录, 默认为当前工作目录",
+						},
+						cli.StringFlag{
+							Name:  "user",
+							Usage: "认证用户名, 为空时不认证",
+						},
+						cli.StringFlag{
+							Name:  "pass",
+							Usage: "认证密码",
+						},
+						cli.DurationFlag{
+							Name:  "cache",
+							Usage: "目录列表缓存时间",
+							Value: pcswebdav.DefaultCacheExpires,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:      "daemon",
+			Usage:     "启动后台传输服务",
+			UsageText: app.Name + " daemon [arguments...]",
+			Description: `
+	启动后台服务, 常驻执行下载和上传任务, 通过 JSON-RPC 2.0 (HTTP POST /jsonrpc) 添加和控制任务.
+	必须设置 --token, 请求需要带上 HTTP 头 Authorization: Bearer <token> 和 Content-Type: application/json.
+
+	支持的方法:
+	task.add         添加任务, 参数 {"type": "download|upload", "paths": [...], "save_to": "...", "priority": 0}
+	task.list        列出所有任务及传输进度
+	task.status      获取任务信息, 参数 {"id": "1"}
+	task.pause       暂停任务, 参数 {"id": "1"}
+	task.resume      恢复任务, 参数 {"id": "1"}
+	task.cancel      取消任务, 参数 {"id": "1"}
+	task.setPriority 修改优先级, 参数 {"id": "1", "priority": 10}, 数值越大越先执行
+	task.purge       删除已结束的任务
+
+	示例:
+
+	1. 启动后台服务
+	BaiduPCS-Go daemon --token 123456
+
+	2. 添加下载任务
+	curl -H "Authorization: Bearer 123456" -H "Content-Type: application/json" -d '{"jsonrpc":"2.0","id":1,"method":"task.add","params":{"paths":["/我的资源"]}}' http://127.0.0.1:6900/jsonrpc
+
+	使用 --aria2 时, 在 --aria2-addr (默认 127.0.0.1:6800) 的 /jsonrpc 提供部分 aria2 RPC 接口,
+	可以使用 AriaNg 等 aria2 前端添加和管理下载任务, RPC 密钥为 --token.
+	在浏览器中使用 aria2 前端时, 需要使用 --aria2-allow-origin-all 允许跨域访问.
+	aria2.addUri 的链接为网盘路径或分享链接, 分享链接会先转存到当前工作目录再下载.
+	支持的方法: aria2.addUri, aria2.tellStatus, aria2.tellActive, aria2.tellWaiting, aria2.tellStopped,
+	aria2.pause, aria2.unpause, aria2.remove, aria2.getGlobalStat, aria2.getVersion, system.multicall 等.
+`,
+			Category: "百度网盘",
+			Before:   reloadFn,
+			Action: func(c *cli.Context) error {
+				var (
+					downloadMode pcsdownload.DownloadMode
+				)
+				switch c.String("mode") {
+				case "pcs":
+					downloadMode = pcsdownload.DownloadModePCS
+				case "stream":
+					downloadMode = pcsdownload.DownloadModeStreaming
+				case "locate":
+					downloadMode = pcsdownload.DownloadModeLocate
+				default:
+					fmt.Println("下载方式解析失败")
+					cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				}
+
+				var aria2Addr string
+				if c.Bool("aria2") {
+					aria2Addr = c.String("aria2-addr")
+				}
+
+				pcscommand.RunDaemon(&pcscommand.DaemonOptions{
+					Addr:                c.String("addr"),
+					Token:               c.String("token"),
+					Aria2Addr:           aria2Addr,
+					Aria2AllowOriginAll: c.Bool("aria2-allow-origin-all"),
+					Version:             app.Version,
+					Load:                c.Int("l"),
+					MaxRetry:            c.Int("retry"),
+					Download: &pcscommand.DownloadOptions{
+						DownloadMode: downloadMode,
+						SaveTo:       c.String("saveto"),
+						Parallel:     c.Int("p"),
+						NoCheck:      c.Bool("nocheck"),
+						ModifyMTime:  c.Bool("mtime"),
+					},
+					Upload: &pcscommand.UploadOptions{
+						NoRapidUpload: c.Bool("norapid"),
+						Policy:        c.String("policy"),
+					},
+				})
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "addr",
+					Usage: "监听地址",
+					Value: pcscommand.DefaultDaemonAddr,
+				},
+				cli.StringFlag{
+					Name:  "token",
+					Usage: "访问令牌, 必须设置",
+				},
+				cli.BoolFlag{
+					Name:  "aria2",
+					Usage: "同时提供 aria2 RPC 接口",
+				},
+				cli.StringFlag{
+					Name:  "aria2-addr",
+					Usage: "aria2 RPC 监听地址",
+					Value: pcscommand.DefaultAria2Addr,
+				},
+				cli.BoolFlag{
+					Name:  "aria2-allow-origin-all",
+					Usage: "aria2 RPC 允许浏览器跨域访问",
+				},
+				cli.IntFlag{
+					Name:  "l",
+					Usage: "指定同时进行的任务数量",
+				},
+				cli.IntFlag{
+					Name:  "retry",
+					Usage: "任务失败最大重试次数",
+					Value: pcsdownload.DefaultDownloadMaxRetry,
+				},
+				cli.StringFlag{
+					Name:  "saveto",
+					Usage: "下载的默认保存目录",
+				},
+				cli.StringFlag{
+					Name:  "mode",
+					Usage: "下载模式, 可选值: pcs, stream, locate",
+					Value: "locate",
+				},
+				cli.IntFlag{
+					Name:  "p",
+					Usage: "指定下载线程数",
+				},
+				cli.BoolFlag{
+					Name:  "nocheck",
+					Usage: "下载文件完成后不校验文件",
+				},
+				cli.BoolFlag{
+					Name:  "mtime",
+					Usage: "将本地文件的修改时间设置为服务器上的修改时间",
+				},
+				cli.BoolFlag{
+					Name:  "norapid",
+					Usage: "上传时不检测秒传",
+				},
+				cli.StringFlag{
+					Name:  "policy",
+					Usage: "上传对于同名文件的处理策略, 可选值: skip, overwrite, rsync",
+				},
+			},
+		},
 		{
 			Name:      "match",
 			Usage:     "测试通配符",
@@ -0,0 +1,284 @@
+// Package pcsoutput 机器可读的输出格式包, 支持 json, ndjson, csv
+package pcsoutput
+
+import (
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvFormat 输出格式环境变量
+	EnvFormat = "BAIDUPCS_GO_FORMAT"
+
+	// FormatTable 默认的表格输出
+	FormatTable = "table"
+	// FormatJSON 输出一个 JSON 数组或对象
+	FormatJSON = "json"
+	// FormatNDJSON 每行输出一个 JSON 对象
+	FormatNDJSON = "ndjson"
+	// FormatCSV 输出带表头的 CSV
+	FormatCSV = "csv"
+)
+
+var (
+	// Format 当前的输出格式, 为空时使用表格输出
+	Format = os.Getenv(EnvFormat)
+
+	// ErrUnknownFormat 未知的输出格式
+	ErrUnknownFormat = errors.New("未知的输出格式, 可选: table, json, ndjson, csv")
+
+	errTypeNames = map[pcserror.ErrType]string{
+		pcserror.ErrTypeInternalError:  "internal",
+		pcserror.ErrTypeRemoteError:    "remote",
+		pcserror.ErrTypeNetError:       "net",
+		pcserror.ErrTypeJSONParseError: "json_parse",
+		pcserror.ErrTypeOthers:         "others",
+	}
+)
+
+type (
+	// ErrorRecord 结构化的错误信息
+	ErrorRecord struct {
+		Command   string `json:"command"`
+		Operation string `json:"operation,omitempty"`
+		Type      string `json:"type"`
+		Errno     int    `json:"errno,omitempty"`
+		Message   string `json:"message"`
+	}
+)
+
+// CheckFormat 检查输出格式是否有效
+func CheckFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", FormatTable, FormatJSON, FormatNDJSON, FormatCSV:
+		return nil
+	}
+	return ErrUnknownFormat
+}
+
+// IsStructured 当前是否使用机器可读的输出格式
+func IsStructured() bool {
+	switch strings.ToLower(Format) {
+	case FormatJSON, FormatNDJSON, FormatCSV:
+		return true
+	}
+	return false
+}
+
+// Messages 提示信息的输出位置, 使用机器可读的输出格式时为标准错误, 以免混入输出结果
+func Messages() io.Writer {
+	if IsStructured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Print 按当前的输出格式输出到标准输出
+func Print(v interface{}) error {
+	return Write(os.Stdout, Format, v)
+}
+
+// Write 将 v 按 format 格式写入 w, v 为结构体, 结构体指针或它们的切片.
+// 字段名取自 json tag, csv 中非基本类型的字段以 JSON 字符串输出
+func Write(w io.Writer, format string, v interface{}) error {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, elem := range elements(v) {
+			err := enc.Encode(elem.Interface())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return writeCSV(w, v)
+	}
+	return ErrUnknownFormat
+}
+
+// elements 将 v 展开为元素列表, v 不是切片时返回只有 v 的列表
+func elements(v interface{}) (elems []reflect.Value) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []reflect.Value{rv}
+	}
+	elems = make([]reflect.Value, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elems = append(elems, rv.Index(i))
+	}
+	return
+}
+
+// elemType 切片元素或 v 本身的结构体类型
+func elemType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// csvField csv 的一列
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFields 按结构体字段顺序获取列, 展开匿名结构体
+func csvFields(t reflect.Type) (fields []csvField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for _, sub := range csvFields(ft) {
+				sub.index = append([]int{i}, sub.index...)
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, csvField{name: name, index: []int{i}})
+	}
+	return
+}
+
+// fieldByIndex 与 reflect.Value.FieldByIndex 相同, 遇到 nil 指针时返回无效值
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+func csvValue(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func writeCSV(w io.Writer, v interface{}) error {
+	t := elemType(v)
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("csv 不支持的类型: %s", t)
+	}
+
+	fields := csvFields(t)
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(fields))
+	for _, f := range fields {
+		header = append(header, f.name)
+	}
+	err := cw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	row := make([]string, len(fields))
+	for _, elem := range elements(v) {
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			continue
+		}
+		for i, f := range fields {
+			row[i], err = csvValue(fieldByIndex(elem, f.index))
+			if err != nil {
+				return err
+			}
+		}
+		err = cw.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// NewErrorRecord 根据错误生成结构化的错误信息
+func NewErrorRecord(command string, err error) *ErrorRecord {
+	record := &ErrorRecord{
+		Command: command,
+		Type:    "others",
+		Message: err.Error(),
+	}
+	if pcsError, ok := err.(pcserror.Error); ok {
+		record.Operation = pcsError.GetOperation()
+		if name, ok := errTypeNames[pcsError.GetErrType()]; ok {
+			record.Type = name
+		}
+		if pcsError.GetErrType() == pcserror.ErrTypeRemoteError {
+			record.Errno = pcsError.GetRemoteErrCode()
+		}
+	}
+	return record
+}
+
+// WriteError 将错误以一行 JSON 对象写入 w, 与输出格式无关
+func WriteError(w io.Writer, command string, err error) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(NewErrorRecord(command, err))
+}
+
+// PrintError 将错误以 JSON 对象输出到标准错误
+func PrintError(command string, err error) {
+	WriteError(os.Stderr, command, err)
+}
@@ -0,0 +1,80 @@
+package pcsoutput_test
+
+import (
+	"BaiduPCS-Go/baidupcs/pcserror"
+	"BaiduPCS-Go/pcsoutput"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type (
+	base struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	record struct {
+		base
+		Isdir bool    `json:"isdir"`
+		Tags  []int64 `json:"tags"`
+		Skip  string  `json:"-"`
+	}
+)
+
+func TestWrite(t *testing.T) {
+	records := []*record{
+		{base: base{1, "a,b"}, Isdir: true, Tags: []int64{1, 2}, Skip: "x"},
+		nil,
+		{base: base{2, "c"}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := pcsoutput.Write(buf, pcsoutput.FormatCSV, records); err != nil {
+		t.Fatalf("csv: %s", err)
+	}
+	want := "id,name,isdir,tags\n1,\"a,b\",true,\"[1,2]\"\n2,c,false,\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected csv:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := pcsoutput.Write(buf, pcsoutput.FormatNDJSON, records[:1]); err != nil {
+		t.Fatalf("ndjson: %s", err)
+	}
+	want = `{"id":1,"name":"a,b","isdir":true,"tags":[1,2]}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected ndjson: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := pcsoutput.Write(buf, pcsoutput.FormatJSON, records[2:]); err != nil {
+		t.Fatalf("json: %s", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0]["name"] != "c" {
+		t.Fatalf("unexpected json: %s, %v", buf.String(), err)
+	}
+
+	if err := pcsoutput.Write(buf, "xml", records); err != pcsoutput.ErrUnknownFormat {
+		t.Fatalf("want ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestNewErrorRecord(t *testing.T) {
+	errInfo := pcserror.NewPCSErrorInfo("获取目录下的文件列表")
+	errInfo.ErrType = pcserror.ErrTypeRemoteError
+	errInfo.ErrCode = 31066
+	errInfo.ErrMsg = "文件或目录不存在"
+
+	record := pcsoutput.NewErrorRecord("ls", errInfo)
+	if record.Command != "ls" || record.Type != "remote" || record.Errno != 31066 || record.Operation != "获取目录下的文件列表" {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+
+	record = pcsoutput.NewErrorRecord("ls", errors.New("boom"))
+	if record.Type != "others" || record.Errno != 0 || record.Message != "boom" {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+}
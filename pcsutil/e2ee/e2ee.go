@@ -0,0 +1,237 @@
+// Package e2ee 客户端加密, 文件内容按块使用 AES-GCM 加密, 支持随机读取和分段下载.
+//
+// 加密文件格式: 文件头 | 块0 | 块1 | ... | 最后一块
+//
+// 文件头为 32 字节: 标识(4) | 版本(1) | 保留(3) | 盐值(16) | nonce 前缀(8).
+// 每块明文 BlockSize 字节 (最后一块可能不足), 加密后附加 16 字节的认证标签.
+// 每个文件使用独立的密钥, 由主密钥和文件头中的盐值经 HKDF 生成,
+// 块的 nonce 为 nonce 前缀加上块序号, 最后一块的附加数据与其他块不同, 防止截断.
+package e2ee
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// BlockSize 每块明文的大小
+	BlockSize = 64 * 1024
+	// Overhead 每块密文附加的认证标签大小
+	Overhead = 16
+	// HeaderSize 文件头大小
+	HeaderSize = 32
+	// Suffix 未加密文件名时, 加密文件的后缀
+	Suffix = ".bpe"
+
+	version        = 1
+	saltSize       = 16
+	noncePrefixLen = 8
+	nameIVSize     = 12
+	encBlockSize   = BlockSize + Overhead
+)
+
+var (
+	magic = []byte("BPE\x00")
+
+	// 主密钥派生使用固定的盐值, 保证同一口令在不同设备上得到相同的密钥
+	masterKeySalt = []byte("BaiduPCS-Go e2ee master key")
+
+	nameEncoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+	// ErrInvalidHeader 文件头不合法
+	ErrInvalidHeader = errors.New("e2ee: invalid header")
+	// ErrInvalidSize 加密文件大小不合法
+	ErrInvalidSize = errors.New("e2ee: invalid encrypted size")
+	// ErrAuthFailed 解密认证失败, 口令错误或数据已损坏
+	ErrAuthFailed = errors.New("e2ee: message authentication failed")
+	// ErrIncomplete 加密文件数据不完整
+	ErrIncomplete = errors.New("e2ee: incomplete encrypted data")
+	// ErrInvalidName 不是加密的文件名
+	ErrInvalidName = errors.New("e2ee: invalid encrypted name")
+)
+
+type (
+	// Cipher 由口令生成的加解密器
+	Cipher struct {
+		masterKey []byte
+		nameAEAD  cipher.AEAD
+		nameIVKey []byte
+	}
+)
+
+// NewCipher 使用口令初始化 Cipher
+func NewCipher(passphrase string) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, errors.New("e2ee: empty passphrase")
+	}
+
+	masterKey, err := scrypt.Key([]byte(passphrase), masterKeySalt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cipher{
+		masterKey: masterKey,
+		nameIVKey: deriveKey(masterKey, nil, "name iv"),
+	}
+	c.nameAEAD, err = newAEAD(deriveKey(masterKey, nil, "name key"))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// deriveKey 使用 HKDF 从主密钥派生 32 字节的子密钥
+func deriveKey(masterKey, salt []byte, info string) []byte {
+	key := make([]byte, 32)
+	_, err := io.ReadFull(hkdf.New(sha256.New, masterKey, salt, []byte(info)), key)
+	if err != nil {
+		// HKDF 输出长度远小于上限, 不会出错
+		panic(err)
+	}
+	return key
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// fileAEAD 根据文件头生成文件密钥
+func (c *Cipher) fileAEAD(header []byte) (aead cipher.AEAD, noncePrefix []byte, err error) {
+	if len(header) != HeaderSize || string(header[:len(magic)]) != string(magic) || header[4] != version {
+		return nil, nil, ErrInvalidHeader
+	}
+	salt := header[8 : 8+saltSize]
+	aead, err = newAEAD(deriveKey(c.masterKey, salt, "file key"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, header[8+saltSize:], nil
+}
+
+// newHeader 生成随机的文件头
+func newHeader() ([]byte, error) {
+	header := make([]byte, HeaderSize)
+	copy(header, magic)
+	header[4] = version
+	_, err := io.ReadFull(rand.Reader, header[8:])
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// blockNonce 第 index 块的 nonce
+func blockNonce(noncePrefix []byte, index int64) []byte {
+	nonce := make([]byte, noncePrefixLen+4)
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixLen:], uint32(index))
+	return nonce
+}
+
+// blockAD 块的附加数据, 区分最后一块
+func blockAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// numBlocks 明文分块数量, 空文件也有一个空的最后一块
+func numBlocks(plainSize int64) int64 {
+	n := plainSize / BlockSize
+	if plainSize%BlockSize != 0 || plainSize == 0 {
+		n++
+	}
+	return n
+}
+
+// EncryptedSize 返回明文大小为 plainSize 的文件加密后的大小
+func EncryptedSize(plainSize int64) int64 {
+	return HeaderSize + plainSize + numBlocks(plainSize)*Overhead
+}
+
+// PlainSize 返回加密后大小为 encryptedSize 的文件的明文大小
+func PlainSize(encryptedSize int64) (int64, error) {
+	body := encryptedSize - HeaderSize
+	if body < Overhead {
+		return 0, ErrInvalidSize
+	}
+	n, rem := body/encBlockSize, body%encBlockSize
+	switch {
+	case rem == 0:
+	case rem > Overhead || (rem == Overhead && n == 0):
+		n++
+	default:
+		return 0, ErrInvalidSize
+	}
+	return body - n*Overhead, nil
+}
+
+// EncryptName 加密文件名, 相同的文件名加密结果相同
+func (c *Cipher) EncryptName(name string) string {
+	mac := hmac.New(sha256.New, c.nameIVKey)
+	mac.Write([]byte(name))
+	iv := mac.Sum(nil)[:nameIVSize]
+	return strings.ToLower(nameEncoding.EncodeToString(c.nameAEAD.Seal(iv, iv, []byte(name), nil)))
+}
+
+// DecryptName 解密文件名
+func (c *Cipher) DecryptName(name string) (string, error) {
+	data, err := nameEncoding.DecodeString(strings.ToUpper(name))
+	if err != nil || len(data) < nameIVSize+Overhead {
+		return "", ErrInvalidName
+	}
+	plain, err := c.nameAEAD.Open(nil, data[:nameIVSize], data[nameIVSize:], nil)
+	if err != nil {
+		return "", ErrInvalidName
+	}
+	return string(plain), nil
+}
+
+// EncryptPath 加密路径中的每一部分, 路径分隔符为 "/"
+func (c *Cipher) EncryptPath(p string) string {
+	parts := strings.Split(p, "/")
+	for k := range parts {
+		if parts[k] != "" && parts[k] != "." && parts[k] != ".." {
+			parts[k] = c.EncryptName(parts[k])
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// DecryptPath 解密路径中能够解密的部分, sep 为路径分隔符
+func (c *Cipher) DecryptPath(p string, sep string) string {
+	parts := strings.Split(p, sep)
+	for k := range parts {
+		parts[k], _ = c.DecryptFileName(parts[k])
+	}
+	return strings.Join(parts, sep)
+}
+
+// DecryptFileName 解密文件名, encrypted 表示该文件名是否为加密文件的文件名
+func (c *Cipher) DecryptFileName(name string) (plain string, encrypted bool) {
+	if strings.HasSuffix(name, Suffix) && len(name) > len(Suffix) {
+		return strings.TrimSuffix(name, Suffix), true
+	}
+	plain, err := c.DecryptName(name)
+	if err != nil {
+		return name, false
+	}
+	return plain, true
+}
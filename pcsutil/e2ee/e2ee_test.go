@@ -0,0 +1,129 @@
+package e2ee_test
+
+import (
+	"BaiduPCS-Go/pcsutil/e2ee"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// memWriterAt 内存中的 io.WriterAt
+type memWriterAt struct {
+	buf []byte
+}
+
+func (m *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	return copy(m.buf[off:], p), nil
+}
+
+func TestSize(t *testing.T) {
+	for _, size := range []int64{0, 1, e2ee.BlockSize - 1, e2ee.BlockSize, e2ee.BlockSize + 1, 3*e2ee.BlockSize + 100} {
+		plainSize, err := e2ee.PlainSize(e2ee.EncryptedSize(size))
+		if err != nil || plainSize != size {
+			t.Fatalf("size %d, got %d, %v", size, plainSize, err)
+		}
+	}
+	if _, err := e2ee.PlainSize(e2ee.HeaderSize + 1); err == nil {
+		t.Fatalf("want invalid size error")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := e2ee.NewCipher("test passphrase")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	for _, size := range []int{0, 100, e2ee.BlockSize, 3*e2ee.BlockSize + 12345} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		er, err := c.NewEncryptReaderAt(bytes.NewReader(plain), int64(size))
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		encrypted, err := ioutil.ReadAll(io.NewSectionReader(er, 0, er.Len()))
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if int64(len(encrypted)) != e2ee.EncryptedSize(int64(size)) {
+			t.Fatalf("encrypted size %d, want %d", len(encrypted), e2ee.EncryptedSize(int64(size)))
+		}
+
+		// 分段乱序写入, 模拟多线程下载
+		out := &memWriterAt{}
+		dw, err := c.NewDecryptWriterAt(out, int64(len(encrypted)))
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		const piece = 10000
+		offsets := rand.Perm(len(encrypted)/piece + 1)
+		for _, k := range offsets {
+			begin, end := k*piece, (k+1)*piece
+			if end > len(encrypted) {
+				end = len(encrypted)
+			}
+			_, err = dw.WriteAt(encrypted[begin:end], int64(begin))
+			if err != nil {
+				t.Fatalf("write at %d: %s", begin, err)
+			}
+		}
+		if err = dw.Finish(); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if !bytes.Equal(out.buf, plain) && !(size == 0 && len(out.buf) == 0) {
+			t.Fatalf("size %d: decrypted data mismatch", size)
+		}
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	c, _ := e2ee.NewCipher("test passphrase")
+	plain := bytes.Repeat([]byte("a"), 1000)
+	er, _ := c.NewEncryptReaderAt(bytes.NewReader(plain), int64(len(plain)))
+	encrypted := make([]byte, er.Len())
+	er.ReadAt(encrypted, 0)
+	encrypted[len(encrypted)-1] ^= 1
+
+	dw, _ := c.NewDecryptWriterAt(&memWriterAt{}, int64(len(encrypted)))
+	if _, err := dw.WriteAt(encrypted, 0); err != e2ee.ErrAuthFailed {
+		t.Fatalf("want auth failed, got %v", err)
+	}
+
+	other, _ := e2ee.NewCipher("wrong passphrase")
+	encrypted[len(encrypted)-1] ^= 1
+	dw, _ = other.NewDecryptWriterAt(&memWriterAt{}, int64(len(encrypted)))
+	if _, err := dw.WriteAt(encrypted, 0); err != e2ee.ErrAuthFailed {
+		t.Fatalf("want auth failed with wrong passphrase, got %v", err)
+	}
+}
+
+func TestName(t *testing.T) {
+	c, _ := e2ee.NewCipher("test passphrase")
+	for _, name := range []string{"1.txt", "中文 文件名.mp4", ".hidden"} {
+		enc := c.EncryptName(name)
+		if enc != c.EncryptName(name) {
+			t.Fatalf("name encryption not deterministic")
+		}
+		dec, err := c.DecryptName(enc)
+		if err != nil || dec != name {
+			t.Fatalf("decrypt %s: got %s, %v", name, dec, err)
+		}
+	}
+
+	if _, err := c.DecryptName("1.txt"); err == nil {
+		t.Fatalf("want invalid name error")
+	}
+	if p := c.DecryptPath("/a/"+c.EncryptPath("b/c.txt"), "/"); p != "/a/b/c.txt" {
+		t.Fatalf("decrypt path got %s", p)
+	}
+	if p := c.DecryptPath("a"+string(os.PathSeparator)+"b.txt"+e2ee.Suffix, string(os.PathSeparator)); p != "a"+string(os.PathSeparator)+"b.txt" {
+		t.Fatalf("decrypt path with suffix got %s", p)
+	}
+}
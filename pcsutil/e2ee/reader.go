@@ -0,0 +1,124 @@
+package e2ee
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// maxCachedBlocks 缓存的已加密块数量, 多个线程同时读取时减少重复加密
+	maxCachedBlocks = 16
+)
+
+type (
+	// EncryptReaderAt 读取时加密数据, 实现 io.ReaderAt 和 64-bit 长度接口
+	EncryptReaderAt struct {
+		src         io.ReaderAt
+		plainSize   int64
+		header      []byte
+		aead        cipher.AEAD
+		noncePrefix []byte
+
+		mu    sync.Mutex
+		cache map[int64][]byte
+	}
+)
+
+// NewEncryptReaderAt 加密 src, plainSize 为明文大小
+func (c *Cipher) NewEncryptReaderAt(src io.ReaderAt, plainSize int64) (*EncryptReaderAt, error) {
+	header, err := newHeader()
+	if err != nil {
+		return nil, err
+	}
+	aead, noncePrefix, err := c.fileAEAD(header)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptReaderAt{
+		src:         src,
+		plainSize:   plainSize,
+		header:      header,
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		cache:       map[int64][]byte{},
+	}, nil
+}
+
+// Len 返回加密后的大小
+func (er *EncryptReaderAt) Len() int64 {
+	return EncryptedSize(er.plainSize)
+}
+
+// ReadAt 读取加密后的数据
+func (er *EncryptReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("e2ee: negative offset")
+	}
+
+	size := er.Len()
+	for n < len(p) && off < size {
+		if off < HeaderSize {
+			c := copy(p[n:], er.header[off:])
+			n += c
+			off += int64(c)
+			continue
+		}
+
+		index := (off - HeaderSize) / encBlockSize
+		block, err := er.sealBlock(index)
+		if err != nil {
+			return n, err
+		}
+		c := copy(p[n:], block[off-HeaderSize-index*encBlockSize:])
+		n += c
+		off += int64(c)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// sealBlock 读取并加密第 index 块
+func (er *EncryptReaderAt) sealBlock(index int64) ([]byte, error) {
+	er.mu.Lock()
+	block, ok := er.cache[index]
+	er.mu.Unlock()
+	if ok {
+		return block, nil
+	}
+
+	var (
+		start  = index * BlockSize
+		length = er.plainSize - start
+	)
+	if length > BlockSize {
+		length = BlockSize
+	}
+
+	buf := make([]byte, length, length+Overhead)
+	n, err := er.src.ReadAt(buf, start)
+	if int64(n) < length {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	final := index == numBlocks(er.plainSize)-1
+	block = er.aead.Seal(buf[:0], blockNonce(er.noncePrefix, index), buf, blockAD(final))
+
+	er.mu.Lock()
+	if len(er.cache) >= maxCachedBlocks {
+		for k := range er.cache {
+			delete(er.cache, k)
+			break
+		}
+	}
+	er.cache[index] = block
+	er.mu.Unlock()
+	return block, nil
+}
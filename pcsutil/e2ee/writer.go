@@ -0,0 +1,207 @@
+package e2ee
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+	"sync"
+)
+
+type (
+	// DecryptWriterAt 写入加密数据, 解密后输出到 w, 实现 io.WriterAt 接口.
+	// 未写满的块缓存在内存中, 块写满之后才会解密输出
+	DecryptWriterAt struct {
+		c          *Cipher
+		w          io.WriterAt
+		size       int64 // 加密后的大小
+		plainSize  int64
+		blockCount int64
+
+		mu          sync.Mutex
+		header      *pendingBlock
+		aead        cipher.AEAD
+		noncePrefix []byte
+		pending     map[int64]*pendingBlock
+		done        map[int64]bool
+	}
+
+	// pendingBlock 未写满的块
+	pendingBlock struct {
+		data   []byte
+		ranges [][2]int64 // 已写入的区间, 已合并且有序
+	}
+)
+
+// NewDecryptWriterAt 解密输出到 w, size 为加密后的大小
+func (c *Cipher) NewDecryptWriterAt(w io.WriterAt, size int64) (*DecryptWriterAt, error) {
+	plainSize, err := PlainSize(size)
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptWriterAt{
+		c:          c,
+		w:          w,
+		size:       size,
+		plainSize:  plainSize,
+		blockCount: numBlocks(plainSize),
+		header:     newPendingBlock(HeaderSize),
+		pending:    map[int64]*pendingBlock{},
+		done:       map[int64]bool{},
+	}, nil
+}
+
+func newPendingBlock(size int64) *pendingBlock {
+	return &pendingBlock{
+		data: make([]byte, size),
+	}
+}
+
+// write 写入块内偏移量为 off 的数据
+func (pb *pendingBlock) write(p []byte, off int64) {
+	copy(pb.data[off:], p)
+
+	var (
+		begin, end = off, off + int64(len(p))
+		merged     = make([][2]int64, 0, len(pb.ranges)+1)
+		inserted   bool
+	)
+	for _, r := range pb.ranges {
+		switch {
+		case r[1] < begin:
+			merged = append(merged, r)
+		case r[0] > end:
+			if !inserted {
+				merged = append(merged, [2]int64{begin, end})
+				inserted = true
+			}
+			merged = append(merged, r)
+		default:
+			// 重叠或相邻, 合并
+			if r[0] < begin {
+				begin = r[0]
+			}
+			if r[1] > end {
+				end = r[1]
+			}
+		}
+	}
+	if !inserted {
+		merged = append(merged, [2]int64{begin, end})
+	}
+	pb.ranges = merged
+}
+
+// full 是否已写满
+func (pb *pendingBlock) full() bool {
+	return len(pb.ranges) == 1 && pb.ranges[0][0] == 0 && pb.ranges[0][1] == int64(len(pb.data))
+}
+
+// PlainSize 返回明文大小
+func (dw *DecryptWriterAt) PlainSize() int64 {
+	return dw.plainSize
+}
+
+// encBlockLen 第 index 块加密后的大小
+func (dw *DecryptWriterAt) encBlockLen(index int64) int64 {
+	if index == dw.blockCount-1 {
+		return dw.plainSize - index*BlockSize + Overhead
+	}
+	return encBlockSize
+}
+
+// WriteAt 写入偏移量为 off 的加密数据
+func (dw *DecryptWriterAt) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > dw.size {
+		return 0, errors.New("e2ee: write out of range")
+	}
+
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
+	for n < len(p) {
+		if off < HeaderSize {
+			c := int64(len(p) - n)
+			if c > HeaderSize-off {
+				c = HeaderSize - off
+			}
+			dw.header.write(p[n:n+int(c)], off)
+			n += int(c)
+			off += c
+			if dw.aead == nil && dw.header.full() {
+				dw.aead, dw.noncePrefix, err = dw.c.fileAEAD(dw.header.data)
+				if err != nil {
+					return n, err
+				}
+				// 解密等待文件头的块
+				for index, pb := range dw.pending {
+					if pb.full() {
+						err = dw.openBlock(index, pb)
+						if err != nil {
+							return n, err
+						}
+					}
+				}
+			}
+			continue
+		}
+
+		var (
+			index    = (off - HeaderSize) / encBlockSize
+			blockOff = off - HeaderSize - index*encBlockSize
+			c        = dw.encBlockLen(index) - blockOff
+		)
+		if c > int64(len(p)-n) {
+			c = int64(len(p) - n)
+		}
+
+		if dw.done[index] {
+			// 已解密的块, 重复写入, 忽略
+			n += int(c)
+			off += c
+			continue
+		}
+
+		pb, ok := dw.pending[index]
+		if !ok {
+			pb = newPendingBlock(dw.encBlockLen(index))
+			dw.pending[index] = pb
+		}
+		pb.write(p[n:n+int(c)], blockOff)
+		n += int(c)
+		off += c
+
+		if dw.aead != nil && pb.full() {
+			err = dw.openBlock(index, pb)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// openBlock 解密已写满的块, 输出明文
+func (dw *DecryptWriterAt) openBlock(index int64, pb *pendingBlock) error {
+	final := index == dw.blockCount-1
+	plain, err := dw.aead.Open(pb.data[:0], blockNonce(dw.noncePrefix, index), pb.data, blockAD(final))
+	if err != nil {
+		return ErrAuthFailed
+	}
+	_, err = dw.w.WriteAt(plain, index*BlockSize)
+	if err != nil {
+		return err
+	}
+	delete(dw.pending, index)
+	dw.done[index] = true
+	return nil
+}
+
+// Finish 检查是否所有数据都已解密输出
+func (dw *DecryptWriterAt) Finish() error {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	if dw.aead == nil || int64(len(dw.done)) != dw.blockCount {
+		return ErrIncomplete
+	}
+	return nil
+}
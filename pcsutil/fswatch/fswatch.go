@@ -0,0 +1,170 @@
+// Package fswatch 监听本地目录的文件变化
+package fswatch
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Op 文件变化类型
+	Op uint32
+
+	// Event 文件变化事件
+	Event struct {
+		Op      Op
+		Path    string // 发生变化的路径
+		OldPath string // 重命名前的路径, 仅对 Rename 有效
+		IsDir   bool
+	}
+
+	// Debouncer 合并短时间内同一文件的多次写入, 文件静默 Delay 之后才输出事件, 写入中的文件会重新计时.
+	// 删除, 重命名和溢出事件不做等待, 删除和重命名会修正等待中的写入事件
+	Debouncer struct {
+		Delay time.Duration
+
+		mu      sync.Mutex
+		pending map[string]*pendingEvent
+		out     chan []Event
+	}
+
+	pendingEvent struct {
+		event    Event
+		lastSeen time.Time
+	}
+)
+
+const (
+	// Create 新建
+	Create Op = 1 << iota
+	// Write 写入完成
+	Write
+	// Remove 删除
+	Remove
+	// Rename 重命名或移动
+	Rename
+	// Modify 写入中, 只用于推迟等待中的写入事件
+	Modify
+	// Overflow 事件队列溢出, 可能遗漏了事件, 需要重新扫描整个目录
+	Overflow
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "CREATE"
+	case Write:
+		return "WRITE"
+	case Remove:
+		return "REMOVE"
+	case Rename:
+		return "RENAME"
+	case Modify:
+		return "MODIFY"
+	case Overflow:
+		return "OVERFLOW"
+	}
+	return "UNKNOWN"
+}
+
+// NewDebouncer 初始化 Debouncer
+func NewDebouncer(delay time.Duration) *Debouncer {
+	return &Debouncer{
+		Delay:   delay,
+		pending: map[string]*pendingEvent{},
+		out:     make(chan []Event, 16),
+	}
+}
+
+// Events 输出合并后的事件
+func (d *Debouncer) Events() <-chan []Event {
+	return d.out
+}
+
+// Add 加入事件
+func (d *Debouncer) Add(event Event) {
+	if event.Op == Create || event.Op == Write || event.Op == Modify {
+		d.mu.Lock()
+		d.pending[event.Path] = &pendingEvent{
+			event:    Event{Op: Write, Path: event.Path, IsDir: event.IsDir},
+			lastSeen: time.Now(),
+		}
+		d.mu.Unlock()
+		return
+	}
+
+	d.mu.Lock()
+	switch event.Op {
+	case Remove:
+		// 文件已删除, 不再需要上传
+		for p := range d.pending {
+			if p == event.Path || isUnder(p, event.Path) {
+				delete(d.pending, p)
+			}
+		}
+	case Rename:
+		// 等待中的写入跟随重命名
+		moved := map[string]*pendingEvent{}
+		for p, pe := range d.pending {
+			if p == event.OldPath || isUnder(p, event.OldPath) {
+				delete(d.pending, p)
+				pe.event.Path = event.Path + p[len(event.OldPath):]
+				moved[pe.event.Path] = pe
+			}
+		}
+		for p, pe := range moved {
+			d.pending[p] = pe
+		}
+	}
+	d.mu.Unlock()
+	d.out <- []Event{event}
+}
+
+// Flush 输出所有已静默的事件, force 为 true 时输出全部等待中的事件
+func (d *Debouncer) Flush(force bool) {
+	d.mu.Lock()
+	var (
+		now    = time.Now()
+		events []Event
+	)
+	for p, pe := range d.pending {
+		if force || now.Sub(pe.lastSeen) >= d.Delay {
+			events = append(events, pe.event)
+			delete(d.pending, p)
+		}
+	}
+	d.mu.Unlock()
+
+	if len(events) > 0 {
+		d.out <- events
+	}
+}
+
+// Run 从 events 读取事件, 定时输出, events 关闭后结束并关闭输出
+func (d *Debouncer) Run(events <-chan Event) {
+	interval := d.Delay / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(d.out)
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				d.Flush(true)
+				return
+			}
+			d.Add(event)
+		case <-ticker.C:
+			d.Flush(false)
+		}
+	}
+}
+
+// isUnder 判断 p 是否在目录 dir 之下
+func isUnder(p, dir string) bool {
+	return len(p) > len(dir) && p[:len(dir)] == dir && (p[len(dir)] == '/' || p[len(dir)] == '\\')
+}
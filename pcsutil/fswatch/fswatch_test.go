@@ -0,0 +1,119 @@
+package fswatch_test
+
+import (
+	"BaiduPCS-Go/pcsutil/fswatch"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	d := fswatch.NewDebouncer(50 * time.Millisecond)
+	events := make(chan fswatch.Event)
+	go d.Run(events)
+
+	// 多次写入只输出一次
+	for i := 0; i < 5; i++ {
+		events <- fswatch.Event{Op: fswatch.Write, Path: "/a/1.txt"}
+	}
+	events <- fswatch.Event{Op: fswatch.Create, Path: "/a/2.txt"}
+	// 重命名后, 等待中的写入跟随新路径
+	events <- fswatch.Event{Op: fswatch.Rename, Path: "/a/3.txt", OldPath: "/a/2.txt"}
+
+	batch := <-d.Events()
+	if len(batch) != 1 || batch[0].Op != fswatch.Rename {
+		t.Fatalf("want rename first, got %v", batch)
+	}
+
+	batch = <-d.Events()
+	got := map[string]bool{}
+	for _, e := range batch {
+		if e.Op != fswatch.Write {
+			t.Fatalf("want write, got %s", e.Op)
+		}
+		got[e.Path] = true
+	}
+	if len(batch) != 2 || !got["/a/1.txt"] || !got["/a/3.txt"] {
+		t.Fatalf("unexpected batch %v", batch)
+	}
+
+	// 删除后, 等待中的写入被丢弃
+	events <- fswatch.Event{Op: fswatch.Write, Path: "/b/1.txt"}
+	events <- fswatch.Event{Op: fswatch.Remove, Path: "/b", IsDir: true}
+	close(events)
+
+	batch = <-d.Events()
+	if len(batch) != 1 || batch[0].Op != fswatch.Remove {
+		t.Fatalf("want remove, got %v", batch)
+	}
+	if batch, ok := <-d.Events(); ok {
+		t.Fatalf("want closed, got %v", batch)
+	}
+}
+
+func TestDebouncerModify(t *testing.T) {
+	d := fswatch.NewDebouncer(50 * time.Millisecond)
+
+	// 写入中的文件重新计时, 不会在写入完成前输出
+	d.Add(fswatch.Event{Op: fswatch.Create, Path: "/a/1.txt"})
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		d.Add(fswatch.Event{Op: fswatch.Modify, Path: "/a/1.txt"})
+		d.Flush(false)
+		select {
+		case batch := <-d.Events():
+			t.Fatalf("flushed while writing: %v", batch)
+		default:
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	d.Flush(false)
+	batch := <-d.Events()
+	if len(batch) != 1 || batch[0].Op != fswatch.Write || batch[0].Path != "/a/1.txt" {
+		t.Fatalf("unexpected batch %v", batch)
+	}
+
+	// 溢出事件不做等待
+	d.Add(fswatch.Event{Op: fswatch.Overflow, Path: "/a", IsDir: true})
+	batch = <-d.Events()
+	if len(batch) != 1 || batch[0].Op != fswatch.Overflow {
+		t.Fatalf("want overflow, got %v", batch)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fswatch")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := fswatch.NewWatcher(dir)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer w.Close()
+
+	name := filepath.Join(dir, "1.txt")
+	err = ioutil.WriteFile(name, []byte("test"), 0644)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case e := <-w.Events:
+			if e.Path == name && (e.Op == fswatch.Create || e.Op == fswatch.Write) {
+				return
+			}
+		case err := <-w.Errors:
+			t.Fatalf("%s", err)
+		case <-timeout:
+			t.Fatalf("no event for %s", name)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// +build linux
+
+package fswatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	watchMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_DELETE |
+		syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+)
+
+type (
+	// Watcher 基于 inotify 递归监听目录
+	Watcher struct {
+		Events chan Event
+		Errors chan error
+
+		root    string
+		fd      int
+		mu      sync.Mutex
+		watches map[int]string // wd -> 目录
+		paths   map[string]int // 目录 -> wd
+		closed  bool
+	}
+)
+
+// NewWatcher 递归监听目录 root
+func NewWatcher(root string) (w *Watcher, err error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	w = &Watcher{
+		Events:  make(chan Event, 256),
+		Errors:  make(chan error, 16),
+		root:    root,
+		fd:      fd,
+		watches: map[int]string{},
+		paths:   map[string]int{},
+	}
+
+	err = w.addRecursive(root, false)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	go w.readEvents()
+	return w, nil
+}
+
+// addRecursive 监听目录及其子目录, emit 为 true 时为已存在的文件输出 Create 事件
+func (w *Watcher) addRecursive(root string, emit bool) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 文件可能已被删除
+			return nil
+		}
+		if !info.IsDir() {
+			if emit {
+				w.Events <- Event{Op: Create, Path: p}
+			}
+			return nil
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, p, watchMask)
+		if err != nil {
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		w.mu.Lock()
+		w.watches[wd] = p
+		w.paths[p] = wd
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+// removeWatch 移除目录及其子目录的监听记录
+func (w *Watcher) removeWatch(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for p, wd := range w.paths {
+		if p == dir || isUnder(p, dir) {
+			delete(w.paths, p)
+			delete(w.watches, wd)
+		}
+	}
+}
+
+// renameWatch 目录移动后, 修正监听记录中的路径
+func (w *Watcher) renameWatch(oldDir, newDir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for p, wd := range w.paths {
+		if p == oldDir || isUnder(p, oldDir) {
+			np := newDir + p[len(oldDir):]
+			delete(w.paths, p)
+			w.paths[np] = wd
+			w.watches[wd] = np
+		}
+	}
+}
+
+func (w *Watcher) readEvents() {
+	defer close(w.Events)
+	defer close(w.Errors)
+
+	var (
+		buf = make([]byte, syscall.SizeofInotifyEvent*4096)
+		// 等待配对的 IN_MOVED_FROM, cookie -> 事件
+		movedFrom = map[uint32]Event{}
+	)
+
+	for {
+		n, err := syscall.Read(w.fd, buf)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			w.mu.Lock()
+			closed := w.closed
+			w.mu.Unlock()
+			if !closed {
+				w.Errors <- os.NewSyscallError("read", err)
+			}
+			return
+		}
+		if n < syscall.SizeofInotifyEvent {
+			if n == 0 {
+				return
+			}
+			w.Errors <- errors.New("inotify: short read")
+			continue
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(raw.Len)]
+			offset += syscall.SizeofInotifyEvent + int(raw.Len)
+
+			if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				// 可能遗漏了新建的目录, 重新监听整个目录
+				if err := w.addRecursive(w.root, false); err != nil {
+					w.Errors <- err
+				}
+				w.Events <- Event{Op: Overflow, Path: w.root, IsDir: true}
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.watches[int(raw.Wd)]
+			w.mu.Unlock()
+			if !ok {
+				continue
+			}
+
+			name := dir
+			for i, b := range nameBytes {
+				if b == 0 {
+					nameBytes = nameBytes[:i]
+					break
+				}
+			}
+			if len(nameBytes) > 0 {
+				name = filepath.Join(dir, string(nameBytes))
+			}
+			isDir := raw.Mask&syscall.IN_ISDIR != 0
+
+			switch {
+			case raw.Mask&syscall.IN_CREATE != 0:
+				if isDir {
+					// 新目录, 加入监听, 并输出其中已存在的文件
+					if err := w.addRecursive(name, true); err != nil {
+						w.Errors <- err
+					}
+					continue
+				}
+				w.Events <- Event{Op: Create, Path: name}
+			case raw.Mask&syscall.IN_MODIFY != 0:
+				w.Events <- Event{Op: Modify, Path: name}
+			case raw.Mask&syscall.IN_CLOSE_WRITE != 0:
+				w.Events <- Event{Op: Write, Path: name}
+			case raw.Mask&syscall.IN_DELETE != 0:
+				w.Events <- Event{Op: Remove, Path: name, IsDir: isDir}
+			case raw.Mask&syscall.IN_MOVED_FROM != 0:
+				movedFrom[raw.Cookie] = Event{Op: Remove, Path: name, IsDir: isDir}
+			case raw.Mask&syscall.IN_MOVED_TO != 0:
+				from, paired := movedFrom[raw.Cookie]
+				if paired {
+					delete(movedFrom, raw.Cookie)
+					if isDir {
+						w.renameWatch(from.Path, name)
+					}
+					w.Events <- Event{Op: Rename, Path: name, OldPath: from.Path, IsDir: isDir}
+					continue
+				}
+				// 从监听目录外移入
+				if isDir {
+					if err := w.addRecursive(name, true); err != nil {
+						w.Errors <- err
+					}
+					continue
+				}
+				w.Events <- Event{Op: Create, Path: name}
+			case raw.Mask&syscall.IN_DELETE_SELF != 0:
+				w.removeWatch(dir)
+			}
+		}
+
+		// 未配对的 IN_MOVED_FROM, 视为移出监听目录, 即删除
+		for cookie, event := range movedFrom {
+			delete(movedFrom, cookie)
+			if event.IsDir {
+				w.removeWatch(event.Path)
+			}
+			w.Events <- event
+		}
+	}
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	wds := make([]int, 0, len(w.watches))
+	for wd := range w.watches {
+		wds = append(wds, wd)
+	}
+	w.mu.Unlock()
+
+	// 移除所有监听, 使阻塞的 read 返回
+	for _, wd := range wds {
+		syscall.InotifyRmWatch(w.fd, uint32(wd))
+	}
+	return syscall.Close(w.fd)
+}
@@ -0,0 +1,111 @@
+// +build !linux
+
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// PollInterval 轮询间隔
+	PollInterval = 2 * time.Second
+)
+
+type (
+	// Watcher 定时扫描目录, 比较文件的大小和修改时间
+	Watcher struct {
+		Events chan Event
+		Errors chan error
+
+		root     string
+		snapshot map[string]os.FileInfo
+		once     sync.Once
+		done     chan struct{}
+	}
+)
+
+// NewWatcher 递归监听目录 root
+func NewWatcher(root string) (w *Watcher, err error) {
+	w = &Watcher{
+		Events: make(chan Event, 256),
+		Errors: make(chan error, 16),
+		root:   root,
+		done:   make(chan struct{}),
+	}
+
+	w.snapshot, err = w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	go w.poll()
+	return w, nil
+}
+
+func (w *Watcher) scan() (snapshot map[string]os.FileInfo, err error) {
+	snapshot = map[string]os.FileInfo{}
+	err = filepath.Walk(w.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == w.root {
+				return err
+			}
+			return nil
+		}
+		if p != w.root {
+			snapshot[p] = info
+		}
+		return nil
+	})
+	return
+}
+
+func (w *Watcher) poll() {
+	defer close(w.Events)
+	defer close(w.Errors)
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := w.scan()
+		if err != nil {
+			w.Errors <- err
+			continue
+		}
+
+		for p, info := range snapshot {
+			old, ok := w.snapshot[p]
+			switch {
+			case !ok:
+				if !info.IsDir() {
+					w.Events <- Event{Op: Create, Path: p}
+				}
+			case !info.IsDir() && (old.Size() != info.Size() || !old.ModTime().Equal(info.ModTime())):
+				w.Events <- Event{Op: Write, Path: p}
+			}
+		}
+		for p, old := range w.snapshot {
+			if _, ok := snapshot[p]; !ok {
+				w.Events <- Event{Op: Remove, Path: p, IsDir: old.IsDir()}
+			}
+		}
+		w.snapshot = snapshot
+	}
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	return nil
+}
@@ -0,0 +1,216 @@
+// Package jsonrpc 基于 HTTP 的 JSON-RPC 2.0 服务端
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+const (
+	// Version JSON-RPC 版本
+	Version = "2.0"
+
+	// CodeParseError 解析请求错误
+	CodeParseError = -32700
+	// CodeInvalidRequest 无效的请求
+	CodeInvalidRequest = -32600
+	// CodeMethodNotFound 方法不存在
+	CodeMethodNotFound = -32601
+	// CodeInvalidParams 无效的参数
+	CodeInvalidParams = -32602
+	// CodeInternalError 内部错误
+	CodeInternalError = -32603
+)
+
+type (
+	// Request JSON-RPC 请求
+	Request struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id,omitempty"` // 为空时为通知, 不返回结果
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}
+
+	// Response JSON-RPC 响应
+	Response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *Error          `json:"error,omitempty"`
+	}
+
+	// Error JSON-RPC 错误
+	Error struct {
+		Code    int         `json:"code"`
+		Message string      `json:"message"`
+		Data    interface{} `json:"data,omitempty"`
+	}
+
+	// HandlerFunc 处理请求的方法, 返回的 error 不是 *Error 时, 作为内部错误返回
+	HandlerFunc func(params json.RawMessage) (result interface{}, err error)
+
+	// Server JSON-RPC 服务, 实现 http.Handler
+	Server struct {
+		methods map[string]HandlerFunc
+		mu      sync.RWMutex
+	}
+)
+
+var (
+	nullID = json.RawMessage("null")
+)
+
+// NewError 初始化 Error
+func NewError(code int, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// NewInvalidParamsError 参数错误
+func NewInvalidParamsError(err error) *Error {
+	return NewError(CodeInvalidParams, fmt.Sprintf("Invalid params: %s", err))
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// NewServer 初始化 Server
+func NewServer() *Server {
+	return &Server{
+		methods: map[string]HandlerFunc{},
+	}
+}
+
+// Register 注册方法
+func (s *Server) Register(method string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[method] = handler
+}
+
+// Call 处理单个请求, 请求为通知时返回 nil
+func (s *Server) Call(req *Request) *Response {
+	resp := &Response{
+		JSONRPC: Version,
+		ID:      req.ID,
+	}
+	if resp.ID == nil {
+		resp.ID = nullID
+	}
+
+	if req.JSONRPC != Version || req.Method == "" {
+		resp.Error = NewError(CodeInvalidRequest, "Invalid Request")
+		return resp
+	}
+
+	s.mu.RLock()
+	handler, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+	if !ok {
+		resp.Error = NewError(CodeMethodNotFound, "Method not found: "+req.Method)
+		return s.response(req, resp)
+	}
+
+	result, err := handler(req.Params)
+	if err != nil {
+		if rpcErr, ok := err.(*Error); ok {
+			resp.Error = rpcErr
+		} else {
+			resp.Error = NewError(CodeInternalError, err.Error())
+		}
+		return s.response(req, resp)
+	}
+
+	resp.Result, err = json.Marshal(result)
+	if err != nil {
+		resp.Error = NewError(CodeInternalError, err.Error())
+	}
+	return s.response(req, resp)
+}
+
+// response 通知不返回结果
+func (s *Server) response(req *Request, resp *Response) *Response {
+	if req.ID == nil {
+		return nil
+	}
+	return resp
+}
+
+// Handle 处理请求数据, 支持批量请求, 没有需要返回的结果时返回 nil
+func (s *Server) Handle(data []byte) interface{} {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var raws []json.RawMessage
+		err := json.Unmarshal(data, &raws)
+		if err != nil {
+			return s.errorResponse(CodeParseError, "Parse error")
+		}
+		if len(raws) == 0 {
+			return s.errorResponse(CodeInvalidRequest, "Invalid Request")
+		}
+
+		resps := make([]*Response, 0, len(raws))
+		for _, raw := range raws {
+			req := &Request{}
+			if json.Unmarshal(raw, req) != nil {
+				resps = append(resps, s.errorResponse(CodeInvalidRequest, "Invalid Request"))
+				continue
+			}
+			if resp := s.Call(req); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			return nil
+		}
+		return resps
+	}
+
+	req := &Request{}
+	err := json.Unmarshal(data, req)
+	if err != nil {
+		return s.errorResponse(CodeParseError, "Parse error")
+	}
+	if resp := s.Call(req); resp != nil {
+		return resp
+	}
+	return nil
+}
+
+func (s *Server) errorResponse(code int, message string) *Response {
+	return &Response{
+		JSONRPC: Version,
+		ID:      nullID,
+		Error:   NewError(code, message),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := s.Handle(data)
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,60 @@
+package jsonrpc_test
+
+import (
+	"BaiduPCS-Go/pcsutil/jsonrpc"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func newTestServer() *jsonrpc.Server {
+	s := jsonrpc.NewServer()
+	s.Register("add", func(params json.RawMessage) (interface{}, error) {
+		var nums []int
+		err := json.Unmarshal(params, &nums)
+		if err != nil {
+			return nil, jsonrpc.NewInvalidParamsError(err)
+		}
+		sum := 0
+		for _, n := range nums {
+			sum += n
+		}
+		return sum, nil
+	})
+	s.Register("fail", func(params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	return s
+}
+
+func TestServer(t *testing.T) {
+	s := newTestServer()
+
+	cases := []struct {
+		req  string
+		want string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"add","params":[1,2,3]}`, `{"jsonrpc":"2.0","id":1,"result":6}`},
+		{`{"jsonrpc":"2.0","id":"a","method":"add","params":{}}`, `{"jsonrpc":"2.0","id":"a","error":{"code":-32602,"message":"Invalid params: json: cannot unmarshal object into Go value of type []int"}}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"none"}`, `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"Method not found: none"}}`},
+		{`{"jsonrpc":"2.0","id":3,"method":"fail"}`, `{"jsonrpc":"2.0","id":3,"error":{"code":-32603,"message":"failed"}}`},
+		{`{"id":4,"method":"add"}`, `{"jsonrpc":"2.0","id":4,"error":{"code":-32600,"message":"Invalid Request"}}`},
+		{`{"jsonrpc":`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`},
+		{`[{"jsonrpc":"2.0","id":1,"method":"add","params":[1]},{"jsonrpc":"2.0","method":"add","params":[2]},1]`, `[{"jsonrpc":"2.0","id":1,"result":1},{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}]`},
+	}
+
+	for _, c := range cases {
+		data, err := json.Marshal(s.Handle([]byte(c.req)))
+		if err != nil {
+			t.Fatalf("marshal error: %s", err)
+		}
+		if string(data) != c.want {
+			t.Fatalf("request %s:\n got %s\nwant %s", c.req, data, c.want)
+		}
+	}
+
+	// 通知不返回结果
+	if resp := s.Handle([]byte(`{"jsonrpc":"2.0","method":"add","params":[1]}`)); resp != nil {
+		t.Fatalf("notification got response: %v", resp)
+	}
+}
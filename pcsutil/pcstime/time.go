@@ -2,7 +2,10 @@
 package pcstime
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -52,3 +55,40 @@ func FormatTime(t int64) string {
 	hour, min, sec := tt.Clock()
 	return fmt.Sprintf("%d-%02d-%02d %02d:%02d:%02d", year, mon, day, hour, min, sec)
 }
+
+// ParseTimeOption 解析时间条件, 支持相对于 now 的时长, 如 30m, 12h, 7d, 2w,
+// 或者日期, 如 2006-01-02, 2006-01-02 15:04:05
+func ParseTimeOption(s string, now time.Time) (t time.Time, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("时间为空")
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		t, err = time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil || n < 0 {
+			return time.Time{}, errors.New("时间格式错误: " + s)
+		}
+		return now.Add(-time.Duration(n * float64(unit))), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, errors.New("时间格式错误: " + s)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,37 @@
+package pcstime_test
+
+import (
+	"BaiduPCS-Go/pcsutil/pcstime"
+	"testing"
+	"time"
+)
+
+func TestParseTimeOption(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.Local)
+	cases := []struct {
+		s    string
+		want time.Time
+	}{
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"1.5d", now.Add(-36 * time.Hour)},
+		{"2019-12-31", time.Date(2019, 12, 31, 0, 0, 0, 0, time.Local)},
+		{"2019-12-31 08:30:00", time.Date(2019, 12, 31, 8, 30, 0, 0, time.Local)},
+	}
+	for _, c := range cases {
+		got, err := pcstime.ParseTimeOption(c.s, now)
+		if err != nil {
+			t.Fatalf("ParseTimeOption(%s): %s", c.s, err)
+		}
+		if !got.Equal(c.want) {
+			t.Fatalf("ParseTimeOption(%s) = %s, want %s", c.s, got, c.want)
+		}
+	}
+
+	for _, s := range []string{"", "abc", "-3d", "d"} {
+		if _, err := pcstime.ParseTimeOption(s, now); err == nil {
+			t.Fatalf("ParseTimeOption(%s) should fail", s)
+		}
+	}
+}
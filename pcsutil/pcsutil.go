@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"net/http/cookiejar"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 )
@@ -23,6 +24,18 @@ func TrimPathPrefix(path, prefixPath string) string {
 	return strings.TrimPrefix(path, prefixPath)
 }
 
+// IsUnderDirs 判断以 / 分隔的路径 p 是否位于 dirs 中的某个目录之下, 不包括 p 本身
+func IsUnderDirs(p string, dirs map[string]bool) bool {
+	for dir := path.Dir(p); ; dir = path.Dir(dir) {
+		if dirs[dir] {
+			return true
+		}
+		if dir == "/" || dir == "." {
+			return false
+		}
+	}
+}
+
 func GenerateRandomString(length int) string {
 	var seededRand = rand.New(rand.NewSource(time.Now().UnixNano()))
 	result := make([]byte, length)
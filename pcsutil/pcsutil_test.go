@@ -0,0 +1,28 @@
+package pcsutil_test
+
+import (
+	"BaiduPCS-Go/pcsutil"
+	"testing"
+)
+
+func TestIsUnderDirs(t *testing.T) {
+	cases := []struct {
+		p    string
+		dirs map[string]bool
+		want bool
+	}{
+		{"/a/b/c.txt", map[string]bool{"/a": true}, true},
+		{"/a/b/c.txt", map[string]bool{"/a/b": true}, true},
+		{"/a", map[string]bool{"/a": true}, false}, // 不包括本身
+		{"/ab/c.txt", map[string]bool{"/a": true}, false},
+		{"/a.txt", map[string]bool{"/": true}, true},
+		{"a/b.txt", map[string]bool{"a": true}, true}, // 相对路径
+		{"a/b.txt", map[string]bool{"b": true}, false},
+		{"a.txt", map[string]bool{}, false},
+	}
+	for _, c := range cases {
+		if got := pcsutil.IsUnderDirs(c.p, c.dirs); got != c.want {
+			t.Fatalf("IsUnderDirs(%q, %v) = %v, want %v", c.p, c.dirs, got, c.want)
+		}
+	}
+}
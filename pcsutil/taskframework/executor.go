@@ -3,6 +3,7 @@ package taskframework
 import (
 	"BaiduPCS-Go/pcsutil/waitgroup"
 	"strconv"
+	"sync"
 	"time"
 
 	incremental "github.com/GeertJohan/go.incremental"
@@ -18,6 +19,11 @@ type (
 		// 是否统计失败队列
 		IsFailedDeque bool
 		failedDeque   *lane.Deque
+
+		initMu   sync.Mutex
+		notify   chan struct{} // 有新任务加入队列
+		stop     chan struct{} // 停止 Serve
+		stopOnce sync.Once
 	}
 )
 
@@ -26,6 +32,8 @@ func NewTaskExecutor() *TaskExecutor {
 }
 
 func (te *TaskExecutor) lazyInit() {
+	te.initMu.Lock()
+	defer te.initMu.Unlock()
 	if te.deque == nil {
 		te.deque = lane.NewDeque()
 	}
@@ -35,9 +43,24 @@ func (te *TaskExecutor) lazyInit() {
 	if te.parallel < 1 {
 		te.parallel = 1
 	}
-	if te.IsFailedDeque {
+	if te.IsFailedDeque && te.failedDeque == nil {
 		te.failedDeque = lane.NewDeque()
 	}
+	if te.notify == nil {
+		te.notify = make(chan struct{}, 1)
+	}
+	if te.stop == nil {
+		te.stop = make(chan struct{})
+	}
+}
+
+// push 将任务加到队列末尾, 并通知 Serve
+func (te *TaskExecutor) push(task *TaskInfoItem) {
+	te.deque.Append(task)
+	select {
+	case te.notify <- struct{}{}:
+	default:
+	}
 }
 
 // 设置任务的最大并发量
@@ -53,7 +76,22 @@ func (te *TaskExecutor) Append(unit TaskUnit, maxRetry int) *TaskInfo {
 		maxRetry: maxRetry,
 	}
 	unit.SetTaskInfo(taskInfo)
-	te.deque.Append(&TaskInfoItem{
+	te.push(&TaskInfoItem{
+		Info: taskInfo,
+		Unit: unit,
+	})
+	return taskInfo
+}
+
+// AppendWithID 将任务加到任务队列末尾, 使用指定的任务id
+func (te *TaskExecutor) AppendWithID(id string, unit TaskUnit, maxRetry int) *TaskInfo {
+	te.lazyInit()
+	taskInfo := &TaskInfo{
+		id:       id,
+		maxRetry: maxRetry,
+	}
+	unit.SetTaskInfo(taskInfo)
+	te.push(&TaskInfoItem{
 		Info: taskInfo,
 		Unit: unit,
 	})
@@ -91,50 +129,7 @@ func (te *TaskExecutor) Execute() {
 
 			go func(task *TaskInfoItem) {
 				defer wg.Done()
-
-				result := task.Unit.Run()
-
-				// 返回结果为空
-				if result == nil {
-					task.Unit.OnComplete(result)
-					return
-				}
-
-				if result.Succeed {
-					task.Unit.OnSuccess(result)
-					task.Unit.OnComplete(result)
-					return
-				}
-
-				// 需要进行重试
-				if result.NeedRetry {
-					// 重试次数超出限制
-					// 执行失败
-					if task.Info.IsExceedRetry() {
-						task.Unit.OnFailed(result)
-						if te.IsFailedDeque {
-							// 加入失败队列
-							te.failedDeque.Append(task)
-						}
-						task.Unit.OnComplete(result)
-						return
-					}
-					task.Info.retry++         // 增加重试次数
-					task.Unit.OnRetry(result) // 调用重试
-					task.Unit.OnComplete(result)
-
-					time.Sleep(task.Unit.RetryWait()) // 等待
-					te.deque.Append(task)             // 重新加入队列末尾
-					return
-				}
-
-				// 执行失败
-				task.Unit.OnFailed(result)
-				if te.IsFailedDeque && result.Extra != "skip" {
-					// 加入失败队列
-					te.failedDeque.Append(task)
-				}
-				task.Unit.OnComplete(result)
+				te.runTask(task)
 			}(task)
 		}
 
@@ -147,14 +142,90 @@ func (te *TaskExecutor) Execute() {
 	}
 }
 
+// runTask 执行单个任务
+func (te *TaskExecutor) runTask(task *TaskInfoItem) {
+	result := task.Unit.Run()
+
+	// 返回结果为空
+	if result == nil {
+		task.Unit.OnComplete(result)
+		return
+	}
+
+	if result.Succeed {
+		task.Unit.OnSuccess(result)
+		task.Unit.OnComplete(result)
+		return
+	}
+
+	// 需要进行重试
+	if result.NeedRetry {
+		// 重试次数超出限制
+		// 执行失败
+		if task.Info.IsExceedRetry() {
+			task.Unit.OnFailed(result)
+			if te.IsFailedDeque {
+				// 加入失败队列
+				te.failedDeque.Append(task)
+			}
+			task.Unit.OnComplete(result)
+			return
+		}
+		task.Info.retry++         // 增加重试次数
+		task.Unit.OnRetry(result) // 调用重试
+		task.Unit.OnComplete(result)
+
+		time.Sleep(task.Unit.RetryWait()) // 等待
+		te.push(task)                     // 重新加入队列末尾
+		return
+	}
+
+	// 执行失败
+	task.Unit.OnFailed(result)
+	if te.IsFailedDeque && result.Extra != "skip" {
+		// 加入失败队列
+		te.failedDeque.Append(task)
+	}
+	task.Unit.OnComplete(result)
+}
+
+// Serve 持续执行任务, 队列为空时等待新的任务加入, 直到调用 Stop.
+// 正在执行的任务会在 Serve 返回前执行完
+func (te *TaskExecutor) Serve() {
+	te.lazyInit()
+
+	wg := waitgroup.NewWaitGroup(te.parallel)
+	for {
+		e := te.deque.Shift()
+		if e == nil {
+			select {
+			case <-te.notify:
+				continue
+			case <-te.stop:
+				wg.Wait()
+				return
+			}
+		}
+
+		wg.AddDelta()
+		go func(task *TaskInfoItem) {
+			defer wg.Done()
+			te.runTask(task)
+		}(e.(*TaskInfoItem))
+	}
+}
+
 // FailedDeque 获取失败队列
 func (te *TaskExecutor) FailedDeque() *lane.Deque {
 	return te.failedDeque
 }
 
-// Stop 停止执行
+// Stop 停止执行, 用于结束 Serve
 func (te *TaskExecutor) Stop() {
-
+	te.lazyInit()
+	te.stopOnce.Do(func() {
+		close(te.stop)
+	})
 }
 
 // Pause 暂停执行
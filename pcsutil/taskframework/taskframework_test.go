@@ -57,3 +57,56 @@ func TestTaskExecutor(t *testing.T) {
 	}
 	te.Execute()
 }
+
+type countUnit struct {
+	done chan string
+	info *taskframework.TaskInfo
+}
+
+func (cu *countUnit) SetTaskInfo(info *taskframework.TaskInfo) { cu.info = info }
+func (cu *countUnit) Run() *taskframework.TaskUnitRunResult {
+	return &taskframework.TaskUnitRunResult{Succeed: true}
+}
+func (cu *countUnit) OnRetry(lastRunResult *taskframework.TaskUnitRunResult) {}
+func (cu *countUnit) OnSuccess(lastRunResult *taskframework.TaskUnitRunResult) {
+	cu.done <- cu.info.Id()
+}
+func (cu *countUnit) OnFailed(lastRunResult *taskframework.TaskUnitRunResult)   {}
+func (cu *countUnit) OnComplete(lastRunResult *taskframework.TaskUnitRunResult) {}
+func (cu *countUnit) RetryWait() time.Duration                                  { return 0 }
+
+func TestTaskExecutorServe(t *testing.T) {
+	te := taskframework.NewTaskExecutor()
+	te.SetParallel(2)
+	served := make(chan struct{})
+	go func() {
+		te.Serve()
+		close(served)
+	}()
+
+	done := make(chan string, 4)
+	for i := 0; i < 2; i++ {
+		te.AppendWithID(fmt.Sprintf("t%d", i), &countUnit{done: done}, 0)
+	}
+	for i := 0; i < 2; i++ {
+		<-done
+	}
+
+	// 队列为空后加入的任务也会被执行
+	te.AppendWithID("late", &countUnit{done: done}, 0)
+	select {
+	case id := <-done:
+		if id != "late" {
+			t.Fatalf("got task %s, want late", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("task appended to idle executor was not run")
+	}
+
+	te.Stop()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Serve did not return after Stop")
+	}
+}
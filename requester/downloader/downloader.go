@@ -23,6 +23,11 @@ const (
 	DefaultAcceptRanges = "bytes"
 )
 
+var (
+	// ErrCanceled 下载已取消
+	ErrCanceled = errors.New("download canceled")
+)
+
 var BlockSizeList = [5]int64{256 * converter.KB, 512 * converter.KB, 1 * converter.MB, 2 * converter.MB, 999 * converter.GB}
 
 type (
@@ -37,6 +42,8 @@ type (
 		onDownloadStatusEvent DownloadStatusFunc //状态处理事件
 
 		monitorCancelFunc context.CancelFunc
+		cancelMu          sync.Mutex
+		canceled          bool // 已取消, 在开始下载前取消时, Execute 不再开始下载
 
 		firstInfo               *DownloadFirstInfo      // 初始信息
 		loadBalancerCompareFunc LoadBalancerCompareFunc // 负载均衡检测函数
@@ -291,6 +298,9 @@ func (der *Downloader) checkLoadBalancers() *LoadBalancerResponseList {
 // Execute 开始任务
 func (der *Downloader) Execute() error {
 	der.lazyInit()
+	if der.isCanceled() {
+		return ErrCanceled
+	}
 	var (
 		resp *http.Response
 	)
@@ -455,7 +465,15 @@ func (der *Downloader) Execute() error {
 	der.monitor.SetReloadWorker(parallel > 1)
 
 	moniterCtx, moniterCancelFunc := context.WithCancel(context.Background())
+	der.cancelMu.Lock()
+	if der.canceled {
+		// 检测下载链接时已取消
+		der.cancelMu.Unlock()
+		moniterCancelFunc()
+		return ErrCanceled
+	}
 	der.monitorCancelFunc = moniterCancelFunc
+	der.cancelMu.Unlock()
 
 	der.monitor.SetInstanceState(der.instanceState)
 
@@ -518,13 +536,23 @@ func (der *Downloader) Resume() {
 	der.monitor.Resume()
 }
 
-// Cancel 取消
+// Cancel 取消, 在开始下载前调用时, Execute 返回 ErrCanceled
 func (der *Downloader) Cancel() {
-	if der.monitor == nil {
+	der.cancelMu.Lock()
+	der.canceled = true
+	cancelFunc := der.monitorCancelFunc
+	der.cancelMu.Unlock()
+	if cancelFunc == nil {
 		return
 	}
 	pcsutil.Trigger(der.onCancelEvent)
-	pcsutil.Trigger(der.monitorCancelFunc)
+	cancelFunc()
+}
+
+func (der *Downloader) isCanceled() bool {
+	der.cancelMu.Lock()
+	defer der.cancelMu.Unlock()
+	return der.canceled
 }
 
 // OnExecute 设置开始下载事件
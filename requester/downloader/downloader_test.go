@@ -0,0 +1,76 @@
+package downloader_test
+
+import (
+	"BaiduPCS-Go/requester"
+	"BaiduPCS-Go/requester/downloader"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloaderCancelBeforeExecute(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+
+	der := downloader.NewDownloader(ts.URL, nil, &downloader.Config{IsTest: true})
+	der.Cancel()
+	if err := der.Execute(); err != downloader.ErrCanceled {
+		t.Fatalf("Execute: got %v, want %v", err, downloader.ErrCanceled)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Fatalf("got %d requests after cancel", n)
+	}
+}
+
+func TestDownloaderCancelBeforeTransfer(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Length", "10")
+		w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+
+	f, err := ioutil.TempFile("", "downloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	der := downloader.NewDownloader(ts.URL, f, &downloader.Config{
+		MaxParallel:                1,
+		InstanceStatePath:          f.Name() + ".json",
+		InstanceStateStorageFormat: downloader.InstanceStateStorageFormatJSON,
+	})
+	defer os.Remove(f.Name() + ".json")
+	executed := false
+	der.OnExecute(func() {
+		executed = true
+	})
+	// 检测下载链接时取消, 此时还没有开始下载
+	der.SetDURLCheckFunc(func(client *requester.HTTPClient, durl string) (int64, *http.Response, error) {
+		der.Cancel()
+		return downloader.DefaultDURLCheckFunc(client, durl)
+	})
+
+	if err = der.Execute(); err != downloader.ErrCanceled {
+		t.Fatalf("Execute: got %v, want %v", err, downloader.ErrCanceled)
+	}
+	if executed {
+		t.Fatalf("download started after cancel")
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("got %d requests, want only the url check", n)
+	}
+	if info, _ := f.Stat(); info.Size() != 0 {
+		t.Fatalf("wrote %d bytes after cancel", info.Size())
+	}
+}
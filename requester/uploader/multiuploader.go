@@ -62,6 +62,7 @@ func NewMultiUploader(multiUpload MultiUpload, file rio.ReaderAtLen64, config *M
 		file:        file,
 		config:      config,
 		targetPath:  targetPath,
+		canceled:    make(chan struct{}),
 	}
 }
 
@@ -168,7 +169,9 @@ func (muer *MultiUploader) InstanceState() *InstanceState {
 
 // Cancel 取消上传
 func (muer *MultiUploader) Cancel() {
-	close(muer.canceled)
+	muer.closeCanceledOnce.Do(func() {
+		close(muer.canceled)
+	})
 }
 
 // OnExecute 设置开始上传事件